@@ -0,0 +1,42 @@
+package main
+
+import (
+	"coachwise/src/app/models"
+	"coachwise/src/config"
+	"context"
+	"log"
+	"os"
+	"time"
+
+	database "github.com/socious-io/pkg_database"
+)
+
+// Grants roles from the command line, needed to bootstrap the first admin
+func main() {
+	config.Init("config.yml")
+
+	if len(os.Args) < 4 || os.Args[1] != "grant" {
+		log.Fatal("Expected 'grant {email} {role}' command.")
+	}
+
+	database.Connect(&database.ConnectOption{
+		URL:         config.Config.Database.URL,
+		SqlDir:      config.Config.Database.SqlDir,
+		MaxRequests: 5,
+		Interval:    30 * time.Second,
+		Timeout:     5 * time.Second,
+	})
+
+	u, err := models.GetUserByEmail(os.Args[2])
+	if err != nil {
+		log.Fatal(err)
+	}
+	ur := &models.UserRole{
+		UserID: u.ID,
+		Role:   os.Args[3],
+	}
+	if err := ur.Create(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Granted %s to %s", ur.Role, u.Username)
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"coachwise/src/app/models"
+	"coachwise/src/config"
+	"context"
+	"log"
+	"time"
+
+	database "github.com/socious-io/pkg_database"
+)
+
+// Hard deletes accounts whose deletion grace period is over, meant to be run periodically (e.g. daily cron)
+func main() {
+	config.Init("config.yml")
+	database.Connect(&database.ConnectOption{
+		URL:         config.Config.Database.URL,
+		SqlDir:      config.Config.Database.SqlDir,
+		MaxRequests: 5,
+		Interval:    30 * time.Second,
+		Timeout:     5 * time.Second,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	ids, err := models.PurgeDeletedUsers(ctx, config.DeletionGracePeriod())
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Purged %d deleted accounts", len(ids))
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"coachwise/src/app/models"
+	"coachwise/src/config"
+	"context"
+	"log"
+	"os"
+	"time"
+
+	database "github.com/socious-io/pkg_database"
+)
+
+// Loads the exercise catalogue as public system owned exercises, safe to run again after every catalogue change
+func main() {
+	config.Init("config.yml")
+
+	path := "src/seeds/exercises.json"
+	if len(os.Args) > 1 {
+		path = os.Args[1]
+	}
+	catalogue, err := models.LoadCatalogue(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	database.Connect(&database.ConnectOption{
+		URL:         config.Config.Database.URL,
+		SqlDir:      config.Config.Database.SqlDir,
+		MaxRequests: 5,
+		Interval:    30 * time.Second,
+		Timeout:     5 * time.Second,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	written, err := catalogue.Seed(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Seeded catalogue version %d: %d exercises written, %d already up to date", catalogue.Version, written, len(catalogue.Exercises)-written)
+}
@@ -1,6 +1,7 @@
 package app
 
 import (
+	"coachwise/src/app/sms"
 	"coachwise/src/app/views"
 	"coachwise/src/config"
 	"context"
@@ -20,6 +21,7 @@ func Init() *gin.Engine {
 		c.Next()
 	})
 
+	sms.Init()
 	views.Init(router)
 	return router
 }
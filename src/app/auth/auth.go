@@ -18,6 +18,22 @@ type RegisterForm struct {
 	Password  *string `json:"password"`
 }
 
+type PhoneRegisterForm struct {
+	FirstName *string `json:"first_name"`
+	LastName  *string `json:"last_name"`
+	Username  *string `json:"username"`
+	Phone     string  `json:"phone" validate:"required"`
+}
+
+type PhoneOTPSendForm struct {
+	Phone string `json:"phone" validate:"required"`
+}
+
+type PhoneOTPConfirmForm struct {
+	Phone string `json:"phone" validate:"required"`
+	Code  int    `json:"code" validate:"required"`
+}
+
 type LoginForm struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=8"`
@@ -49,6 +65,35 @@ type DirectPasswordChangeForm struct {
 	Password string `json:"password" validate:"required"`
 }
 
+type DeleteAccountForm struct {
+	Password *string `json:"password"`
+	Code     *int    `json:"code"`
+}
+
+type EmailChangeForm struct {
+	Email    string  `json:"email" validate:"required,email"`
+	Password *string `json:"password"`
+	Code     *int    `json:"code"`
+}
+
+type PhoneChangeForm struct {
+	Phone    string  `json:"phone" validate:"required"`
+	Password *string `json:"password"`
+	Code     *int    `json:"code"`
+}
+
+type ChangeConfirmForm struct {
+	Code int `json:"code" validate:"required"`
+}
+
+// RestoreAccountForm finds the account by email or, for phone registered accounts, by phone
+type RestoreAccountForm struct {
+	Email    *string `json:"email"`
+	Phone    *string `json:"phone"`
+	Password *string `json:"password"`
+	Code     *int    `json:"code"`
+}
+
 func HashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	return string(bytes), err
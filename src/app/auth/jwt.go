@@ -1,24 +1,34 @@
 package auth
 
 import (
+	"coachwise/src/app/models"
 	"coachwise/src/config"
 	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
+type RoleClaim struct {
+	Role           string     `json:"role"`
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty"`
+}
+
 type Claims struct {
-	ID      string `json:"id"`
-	Refresh bool   `json:"refresh"`
+	ID             string      `json:"id"`
+	Refresh        bool        `json:"refresh"`
+	Roles          []RoleClaim `json:"roles"`
+	ImpersonatorID *string     `json:"impersonator_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(id string, refresh bool) (string, error) {
+func GenerateToken(id string, roles []RoleClaim, refresh bool) (string, error) {
 	expirationTime := time.Now().Add(24 * time.Hour)
 	claims := &Claims{
 		ID:      id,
 		Refresh: refresh,
+		Roles:   roles,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 		},
@@ -28,6 +38,25 @@ func GenerateToken(id string, refresh bool) (string, error) {
 	return token.SignedString([]byte(config.Config.Secret))
 }
 
+// GenerateImpersonationToken issues a short lived access token for id on behalf of an admin, it can not be refreshed
+func GenerateImpersonationToken(id, impersonatorID string, ttl time.Duration) (string, error) {
+	roles, err := userRoleClaims(id)
+	if err != nil {
+		return "", err
+	}
+	claims := &Claims{
+		ID:             id,
+		Roles:          roles,
+		ImpersonatorID: &impersonatorID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(config.Config.Secret))
+}
+
 func VerifyToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		return []byte(config.Config.Secret), nil
@@ -44,11 +73,16 @@ func VerifyToken(tokenString string) (*Claims, error) {
 }
 
 func GenerateFullTokens(id string) (map[string]any, error) {
-	accessToken, err := GenerateToken(id, false)
+	roles, err := userRoleClaims(id)
 	if err != nil {
 		return nil, err
 	}
-	refreshToken, err := GenerateToken(id, true)
+
+	accessToken, err := GenerateToken(id, roles, false)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := GenerateToken(id, roles, true)
 	if err != nil {
 		return nil, err
 	}
@@ -59,3 +93,19 @@ func GenerateFullTokens(id string) (map[string]any, error) {
 		"token_type":    "Bearer",
 	}, nil
 }
+
+func userRoleClaims(id string) ([]RoleClaim, error) {
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+	userRoles, err := models.GetUserRoles(userID)
+	if err != nil {
+		return nil, err
+	}
+	roles := make([]RoleClaim, len(userRoles))
+	for i, r := range userRoles {
+		roles[i] = RoleClaim{Role: r.Role, OrganizationID: r.OrganizationID}
+	}
+	return roles, nil
+}
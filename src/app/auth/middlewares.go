@@ -2,6 +2,7 @@ package auth
 
 import (
 	"coachwise/src/app/models"
+	"context"
 	"net/http"
 	"strings"
 
@@ -44,7 +45,96 @@ func LoginRequired() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
+		if u.DeletedAt != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "account has been deleted"})
+			c.Abort()
+			return
+		}
+		if u.Status == "SUSPENDED" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "account has been suspended"})
+			c.Abort()
+			return
+		}
+		if claims.ImpersonatorID != nil && !auditImpersonation(c, claims, u) {
+			c.Abort()
+			return
+		}
 		c.Set("user", u)
+		c.Set("claims", claims)
+		c.Next()
+	}
+}
+
+// auditImpersonation records every change made with an impersonation token against the admin
+// who requested it, reads are not recorded
+func auditImpersonation(c *gin.Context, claims *Claims, u *models.User) bool {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	adminID, err := uuid.Parse(*claims.ImpersonatorID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return false
+	}
+	reason := c.Request.Method + " " + c.Request.URL.Path
+	l := &models.AdminAuditLog{
+		AdminID:    &adminID,
+		Action:     "IMPERSONATED_REQUEST",
+		TargetType: "users",
+		TargetID:   u.ID,
+		Reason:     &reason,
+	}
+	ctx, _ := c.Get("ctx")
+	if err := l.Create(ctx.(context.Context)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "message": "Couldn't record the audit log"})
+		return false
+	}
+	return true
+}
+
+// RequirePermission must be used after LoginRequired, roles are checked against database
+// so revoked roles take effect before the token claims expire
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		u, _ := c.Get("user")
+		allowed, err := models.HasPermission(u.(*models.User).ID, nil, permission)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden, gin.H{"error": "permission denied"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireOrganizationPermission is the same as RequirePermission but also accepts roles
+// scoped to the organization given by the param route parameter
+func RequireOrganizationPermission(permission, param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID, err := uuid.Parse(c.Param(param))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+		u, _ := c.Get("user")
+		allowed, err := models.HasPermission(u.(*models.User).ID, &orgID, permission)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden, gin.H{"error": "permission denied"})
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
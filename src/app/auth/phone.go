@@ -0,0 +1,26 @@
+package auth
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrInvalidPhone = errors.New("phone must be in international format e.g. +4915112345678")
+
+	phoneSeparators = regexp.MustCompile(`[\s\-().]`)
+	e164            = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+)
+
+// NormalizePhone converts a user entered international number to E.164, e.g. "0049 (151) 123-45678" to "+4915112345678"
+func NormalizePhone(phone string) (string, error) {
+	phone = phoneSeparators.ReplaceAllString(strings.TrimSpace(phone), "")
+	if strings.HasPrefix(phone, "00") {
+		phone = "+" + phone[2:]
+	}
+	if !e164.MatchString(phone) {
+		return "", ErrInvalidPhone
+	}
+	return phone, nil
+}
@@ -0,0 +1,46 @@
+package events
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Names of the events published by the app
+const (
+	PersonalRecord = "personal_record"
+	GoalAchieved   = "goal_achieved"
+	CommentMention = "comment_mention"
+)
+
+type Event struct {
+	Name      string      `json:"name"`
+	UserID    uuid.UUID   `json:"user_id"`
+	Payload   interface{} `json:"payload"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+type Publisher interface {
+	Publish(e Event) error
+}
+
+// Default is used by Publish, replace it with a queue or push notification backed Publisher on startup
+var Default Publisher = LogPublisher{}
+
+// LogPublisher only logs events, used on development and tests
+type LogPublisher struct{}
+
+func (LogPublisher) Publish(e Event) error {
+	payload, err := json.Marshal(e.Payload)
+	if err != nil {
+		return err
+	}
+	log.Printf("Event %s for %s: %s\n", e.Name, e.UserID, payload)
+	return nil
+}
+
+func Publish(name string, userID uuid.UUID, payload interface{}) error {
+	return Default.Publish(Event{Name: name, UserID: userID, Payload: payload, CreatedAt: time.Now()})
+}
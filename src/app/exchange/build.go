@@ -0,0 +1,116 @@
+package exchange
+
+import (
+	"coachwise/src/app/models"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Report tells how every exercise of the file was matched against the library
+type Report struct {
+	Groups    int            `json:"groups"`
+	Exercises int            `json:"exercises"`
+	Matched   []MatchedRow   `json:"matched"`
+	Unmatched []UnmatchedRow `json:"unmatched"`
+}
+
+// MatchedRow Forked is set when the imported sets differ from the library exercise, the plan then gets a private copy with them
+type MatchedRow struct {
+	Line     int                  `json:"line"`
+	Name     string               `json:"name"`
+	Exercise models.ExerciseMatch `json:"exercise"`
+	Sets     int                  `json:"sets"`
+	Forked   bool                 `json:"forked"`
+}
+
+type UnmatchedRow struct {
+	Line int    `json:"line"`
+	Name string `json:"name"`
+}
+
+// Build matches the blocks against the exercises visible to userID and lays them out as the groups of a new plan,
+// the plan is only complete when the report has no unmatched rows
+func Build(userID uuid.UUID, blocks []Block) (*models.Plan, *Report, error) {
+	p := &models.Plan{UserID: userID}
+	report := &Report{Groups: len(blocks), Matched: []MatchedRow{}, Unmatched: []UnmatchedRow{}}
+	library := map[uuid.UUID]*models.Exercise{}
+
+	for _, b := range blocks {
+		g := models.PlanGroup{GroupType: b.GroupType, Rounds: b.Rounds}
+		for _, entry := range b.Entries {
+			report.Exercises++
+			match, err := models.MatchExercise(userID, entry.Name)
+			if err != nil {
+				return nil, nil, err
+			}
+			if match == nil {
+				report.Unmatched = append(report.Unmatched, UnmatchedRow{Line: entry.Line, Name: entry.Name})
+				continue
+			}
+			source, ok := library[match.ID]
+			if !ok {
+				if source, err = models.GetExrcise(match.ID); err != nil {
+					return nil, nil, err
+				}
+				library[match.ID] = source
+			}
+
+			pe := models.PlanExercise{ExerciseID: source.ID}
+			forked := !sameSets(source.Sets, entry.Sets)
+			if forked {
+				pe.ExerciseID = uuid.Nil
+				pe.Exercise = &models.Exercise{
+					UserID:           &userID,
+					Name:             source.Name,
+					Description:      source.Description,
+					Sports:           source.Sports,
+					PrimaryMuscles:   source.PrimaryMuscles,
+					SecondaryMuscles: source.SecondaryMuscles,
+					Equipment:        source.Equipment,
+					Difficulty:       source.Difficulty,
+					Language:         source.Language,
+					ForkedFrom:       &source.ID,
+					Sets:             entry.Sets,
+				}
+			}
+			g.Exercises = append(g.Exercises, pe)
+			report.Matched = append(report.Matched, MatchedRow{
+				Line:     entry.Line,
+				Name:     entry.Name,
+				Exercise: *match,
+				Sets:     len(entry.Sets),
+				Forked:   forked,
+			})
+		}
+		if len(g.Exercises) == len(b.Entries) {
+			if err := g.Validate(); err != nil {
+				return nil, nil, fmt.Errorf("line %d: %s", b.Line, err.Error())
+			}
+		}
+		p.Groups = append(p.Groups, g)
+	}
+	return p, report, nil
+}
+
+// sameSets compares the prescriptions and ignores the ids, numbering and timestamps
+func sameSets(a, b []models.Set) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		x, y := a[i], b[i]
+		x.ID, y.ID = uuid.Nil, uuid.Nil
+		x.ExerciseID, y.ExerciseID = uuid.Nil, uuid.Nil
+		x.SetNumber, y.SetNumber = 0, 0
+		x.Name, y.Name = nil, nil
+		x.CreatedAt, y.CreatedAt = time.Time{}, time.Time{}
+		x.UpdatedAt, y.UpdatedAt = time.Time{}, time.Time{}
+		if !reflect.DeepEqual(x, y) {
+			return false
+		}
+	}
+	return true
+}
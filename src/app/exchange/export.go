@@ -0,0 +1,243 @@
+package exchange
+
+import (
+	"bytes"
+	"coachwise/src/app/models"
+	"coachwise/src/utils"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Header is the first row of exported tables, the same columns Parse reads back
+var Header = []string{"group", "group_type", "rounds", "exercise", "sets", "reps", "rest", "load"}
+
+// Table lays the plan out as rows of Header, a row per run of identical consecutive sets of an exercise
+func Table(p *models.Plan) [][]string {
+	rows := [][]string{Header}
+	for gi, g := range p.Groups {
+		for _, pe := range g.Exercises {
+			if pe.Exercise == nil {
+				continue
+			}
+			name := pe.Exercise.Name
+			if len(pe.Exercise.Sets) < 1 {
+				rows = append(rows, []string{strconv.Itoa(gi + 1), g.GroupType, strconv.Itoa(g.Rounds), name, "", "", "", ""})
+				continue
+			}
+			for _, run := range setRuns(pe.Exercise.Sets) {
+				s := run.set
+				rows = append(rows, []string{
+					strconv.Itoa(gi + 1), g.GroupType, strconv.Itoa(g.Rounds), name,
+					strconv.Itoa(run.count), FormatReps(s), FormatDuration(s.RestTime), FormatLoad(s),
+				})
+			}
+		}
+	}
+	return rows
+}
+
+// formulaPrefixes start cells spreadsheets evaluate as formulas
+const formulaPrefixes = "=+-@"
+
+// escapeCells quotes the text cells a spreadsheet would run as a formula with a leading ', numbers are kept as they are
+func escapeCells(rows [][]string) [][]string {
+	escaped := make([][]string, len(rows))
+	for i, row := range rows {
+		escaped[i] = make([]string, len(row))
+		for j, cell := range row {
+			if cell != "" && strings.ContainsRune(formulaPrefixes, rune(cell[0])) {
+				if _, err := strconv.ParseFloat(cell, 64); err != nil {
+					cell = "'" + cell
+				}
+			}
+			escaped[i][j] = cell
+		}
+	}
+	return escaped
+}
+
+func CSV(p *models.Plan) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.WriteAll(escapeCells(Table(p))); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func XLSX(p *models.Plan) ([]byte, error) {
+	// sheet names are limited to 31 characters and a few symbols
+	name := strings.NewReplacer("/", " ", "\\", " ", "?", " ", "*", " ", "[", " ", "]", " ", ":", " ").Replace(p.Name)
+	if runes := []rune(name); len(runes) > 31 {
+		name = string(runes[:31])
+	}
+	if strings.TrimSpace(name) == "" {
+		name = "Plan"
+	}
+	return utils.WriteXLSX(name, escapeCells(Table(p)))
+}
+
+// PDF prints the plan as a table on A4 pages with the description above it
+func PDF(p *models.Plan) []byte {
+	const margin = 40.0
+	doc := utils.NewPDF()
+	widths := []float64{40, 70, 45, 160, 35, 60, 50, 55}
+	y := margin + 20
+
+	doc.Text(margin, y, 18, true, utils.FitText(p.Name, 18, true, utils.PageWidth-2*margin))
+	y += 20
+	if p.Description != nil && *p.Description != "" {
+		for _, line := range utils.WrapText(*p.Description, 10, false, utils.PageWidth-2*margin) {
+			doc.Text(margin, y, 10, false, line)
+			y += 14
+		}
+	}
+	y += 10
+
+	header := func() {
+		x := margin
+		for i, title := range Header {
+			doc.Text(x, y, 9, true, strings.ReplaceAll(title, "_", " "))
+			x += widths[i]
+		}
+		doc.Line(margin, y+4, utils.PageWidth-margin, y+4, 0.8)
+		y += 18
+	}
+	header()
+	for _, row := range Table(p)[1:] {
+		if y > utils.PageHeight-margin {
+			doc.AddPage()
+			y = margin + 20
+			header()
+		}
+		x := margin
+		for i, value := range row {
+			doc.Text(x, y, 9, false, utils.FitText(value, 9, false, widths[i]-4))
+			x += widths[i]
+		}
+		doc.Gray(0.8)
+		doc.Line(margin, y+5, utils.PageWidth-margin, y+5, 0.4)
+		doc.Gray(0)
+		y += 16
+	}
+	doc.Text(margin, utils.PageHeight-20, 8, false, fmt.Sprintf("Exported %s", time.Now().Format("2006-01-02")))
+	return doc.Bytes()
+}
+
+// FormatReps writes the set the way Parse reads it back, EMOM, INTERVAL and DROP sets are only described
+func FormatReps(s models.Set) string {
+	switch s.SetType {
+	case "RANGE":
+		if s.RepMin != nil && s.RepMax != nil {
+			return fmt.Sprintf("%d-%d", *s.RepMin, *s.RepMax)
+		}
+	case "AMRAP":
+		if s.RepMin != nil {
+			return fmt.Sprintf("%d+", *s.RepMin)
+		}
+		return "AMRAP"
+	case "EMOM":
+		if s.RepCount != nil && s.Rounds != nil {
+			return fmt.Sprintf("EMOM %dx%d", *s.Rounds, *s.RepCount)
+		}
+	case "INTERVAL":
+		if s.Duration != nil && s.Rounds != nil {
+			return fmt.Sprintf("%dx%s", *s.Rounds, FormatDuration(*s.Duration))
+		}
+	case "DROP":
+		if s.RepCount != nil && s.Drops != nil {
+			return fmt.Sprintf("%d + %d drops", *s.RepCount, *s.Drops)
+		}
+	}
+	switch {
+	case s.RepCount != nil:
+		return strconv.Itoa(*s.RepCount)
+	case s.Duration != nil:
+		return FormatDuration(*s.Duration)
+	case s.Distance != nil && s.DistanceUnit != nil:
+		return formatNumber(*s.Distance) + strings.ToLower(*s.DistanceUnit)
+	}
+	return ""
+}
+
+func FormatLoad(s models.Set) string {
+	switch {
+	case s.Percent1RM != nil:
+		return formatNumber(*s.Percent1RM) + "%"
+	case s.Load != nil && s.LoadUnit != nil:
+		return formatNumber(*s.Load) + strings.ToLower(*s.LoadUnit)
+	case s.Load != nil:
+		return formatNumber(*s.Load)
+	}
+	return ""
+}
+
+// FormatDuration writes whole minutes as 2m and anything else in seconds, zero is left blank
+func FormatDuration(d time.Duration) string {
+	switch {
+	case d <= 0:
+		return ""
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	default:
+		return formatNumber(d.Seconds()) + "s"
+	}
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+// Summary lists the exercises of the plan a line each, like A. Back Squat: 3 x 5 @ 100kg, 1 x 3 @ 110kg
+func Summary(p *models.Plan) string {
+	lines := []string{}
+	for gi, g := range p.Groups {
+		for ei, pe := range g.Exercises {
+			if pe.Exercise == nil {
+				continue
+			}
+			label := groupLabel(gi)
+			if len(g.Exercises) > 1 {
+				label += strconv.Itoa(ei + 1)
+			}
+			runs := []string{}
+			for _, run := range setRuns(pe.Exercise.Sets) {
+				text := fmt.Sprintf("%d x %s", run.count, FormatReps(run.set))
+				if load := FormatLoad(run.set); load != "" {
+					text += " @ " + load
+				}
+				runs = append(runs, text)
+			}
+			line := fmt.Sprintf("%s. %s", label, pe.Exercise.Name)
+			if len(runs) > 0 {
+				line += ": " + strings.Join(runs, ", ")
+			}
+			if g.Rounds > 1 && ei == 0 {
+				line += fmt.Sprintf(" (%d rounds)", g.Rounds)
+			}
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+type setRun struct {
+	set   models.Set
+	count int
+}
+
+// setRuns collapses identical consecutive sets, 5x5 followed by a top set is two runs
+func setRuns(sets []models.Set) []setRun {
+	runs := []setRun{}
+	for _, s := range sets {
+		if n := len(runs); n > 0 && sameSets([]models.Set{runs[n-1].set}, []models.Set{s}) {
+			runs[n-1].count++
+			continue
+		}
+		runs = append(runs, setRun{set: s, count: 1})
+	}
+	return runs
+}
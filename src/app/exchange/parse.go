@@ -0,0 +1,296 @@
+package exchange
+
+import (
+	"bytes"
+	"coachwise/src/app/models"
+	"coachwise/src/utils"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Entry is an exercise of the spreadsheet with the sets of its consecutive rows, Line is the first of them
+type Entry struct {
+	Line int
+	Name string
+	Sets []models.Set
+}
+
+// Block becomes a plan group, consecutive rows sharing a group label are one block
+type Block struct {
+	Line      int
+	Label     string
+	GroupType string
+	Rounds    int
+	Entries   []Entry
+}
+
+var columnAliases = map[string]string{
+	"exercise":      "exercise",
+	"exercise name": "exercise",
+	"name":          "exercise",
+	"movement":      "exercise",
+	"sets":          "sets",
+	"reps":          "reps",
+	"rep":           "reps",
+	"repetitions":   "reps",
+	"rest":          "rest",
+	"rest time":     "rest",
+	"load":          "load",
+	"weight":        "load",
+	"group":         "group",
+	"block":         "group",
+	"group type":    "group_type",
+	"group_type":    "group_type",
+	"rounds":        "rounds",
+}
+
+var (
+	rangePattern    = regexp.MustCompile(`^(\d+)\s*[-–]\s*(\d+)$`)
+	durationPattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*(s|sec|secs|m|min|mins)$`)
+	clockPattern    = regexp.MustCompile(`^(\d+):([0-5]\d)$`)
+	loadPattern     = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*(kg|kgs|lb|lbs|%)?$`)
+)
+
+// Parse reads a CSV or XLSX programme by the file extension, the first row names the columns
+func Parse(filename string, data []byte) ([]Block, error) {
+	var rows [][]string
+	var err error
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+		r.FieldsPerRecord = -1
+		r.TrimLeadingSpace = true
+		rows, err = r.ReadAll()
+	case ".xlsx":
+		rows, err = utils.ReadXLSX(data)
+	default:
+		return nil, errors.New("file must be a .csv or .xlsx")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) < 2 {
+		return nil, errors.New("file has no rows after the header")
+	}
+
+	columns := map[string]int{}
+	for i, title := range rows[0] {
+		if name, ok := columnAliases[strings.ToLower(strings.TrimSpace(title))]; ok {
+			if _, seen := columns[name]; !seen {
+				columns[name] = i
+			}
+		}
+	}
+	if _, ok := columns["exercise"]; !ok {
+		return nil, errors.New("header must have an exercise column")
+	}
+	cell := func(row []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		value := strings.TrimSpace(row[i])
+		// exported cells that look like formulas are quoted with a leading '
+		if len(value) > 1 && value[0] == '\'' && strings.ContainsRune(formulaPrefixes, rune(value[1])) {
+			value = value[1:]
+		}
+		return value
+	}
+
+	blocks := []Block{}
+	for i, row := range rows[1:] {
+		line := i + 2
+		name := cell(row, "exercise")
+		if name == "" {
+			if strings.TrimSpace(strings.Join(row, "")) == "" {
+				continue
+			}
+			return nil, fmt.Errorf("line %d: exercise is required", line)
+		}
+		sets, err := parseSets(row, cell)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err.Error())
+		}
+		label := cell(row, "group")
+
+		// consecutive rows of the same exercise are more sets of it, like 3x5 followed by a 1x3 top set
+		var block *Block
+		if n := len(blocks); n > 0 && blocks[n-1].Label == label && (label != "" || sameExercise(blocks[n-1], name)) {
+			block = &blocks[n-1]
+		} else {
+			blocks = append(blocks, Block{Line: line, Label: label})
+			block = &blocks[len(blocks)-1]
+		}
+		if groupType := strings.ToUpper(cell(row, "group_type")); groupType != "" && block.GroupType == "" {
+			block.GroupType = groupType
+		}
+		if rounds := cell(row, "rounds"); rounds != "" && block.Rounds == 0 {
+			if block.Rounds, err = strconv.Atoi(rounds); err != nil || block.Rounds < 1 {
+				return nil, fmt.Errorf("line %d: rounds must be a positive number", line)
+			}
+			if block.Rounds > models.MaxRounds {
+				return nil, fmt.Errorf("line %d: rounds can not be more than %d", line, models.MaxRounds)
+			}
+		}
+
+		if sameExercise(*block, name) {
+			n := len(block.Entries)
+			if len(block.Entries[n-1].Sets)+len(sets) > models.MaxSets {
+				return nil, fmt.Errorf("line %d: %s can not have more than %d sets", line, name, models.MaxSets)
+			}
+			block.Entries[n-1].Sets = append(block.Entries[n-1].Sets, sets...)
+			continue
+		}
+		block.Entries = append(block.Entries, Entry{Line: line, Name: name, Sets: sets})
+	}
+	if len(blocks) < 1 {
+		return nil, errors.New("file has no exercises")
+	}
+
+	for i := range blocks {
+		if blocks[i].GroupType == "" {
+			blocks[i].GroupType = "SINGLE"
+			if len(blocks[i].Entries) > 1 {
+				blocks[i].GroupType = "SUPERSET"
+			}
+		}
+	}
+	return blocks, nil
+}
+
+func sameExercise(b Block, name string) bool {
+	n := len(b.Entries)
+	return n > 0 && strings.EqualFold(b.Entries[n-1].Name, name)
+}
+
+func parseSets(row []string, cell func([]string, string) string) ([]models.Set, error) {
+	count := 1
+	if value := cell(row, "sets"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return nil, errors.New("sets must be a positive number")
+		}
+		if n > models.MaxSets {
+			return nil, fmt.Errorf("sets can not be more than %d", models.MaxSets)
+		}
+		count = n
+	}
+
+	s := models.Set{}
+	if err := parseReps(cell(row, "reps"), &s); err != nil {
+		return nil, err
+	}
+	if value := cell(row, "rest"); value != "" {
+		rest, err := ParseDuration(value, time.Second)
+		if err != nil {
+			return nil, fmt.Errorf("rest %q is not like 90, 90s, 2m or 1:30", value)
+		}
+		s.RestTime = rest
+	}
+	if err := parseLoad(cell(row, "load"), &s); err != nil {
+		return nil, err
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	sets := make([]models.Set, count)
+	for i := range sets {
+		if err := utils.Copy(&s, &sets[i]); err != nil {
+			return nil, err
+		}
+	}
+	return sets, nil
+}
+
+// parseReps accepts 8, 8-12 for a range, AMRAP, max or 8+ for as many as possible and 30s or 1:30 for a timed set
+func parseReps(value string, s *models.Set) error {
+	value = strings.ToLower(strings.ReplaceAll(value, " ", ""))
+	switch {
+	case value == "":
+		return errors.New("reps is required")
+	case value == "amrap" || value == "max":
+		s.SetType = "AMRAP"
+	case strings.HasSuffix(value, "+"):
+		n, err := strconv.Atoi(strings.TrimSuffix(value, "+"))
+		if err != nil {
+			return fmt.Errorf("reps %q is not a number", value)
+		}
+		s.SetType = "AMRAP"
+		s.RepMin = &n
+	case rangePattern.MatchString(value):
+		m := rangePattern.FindStringSubmatch(value)
+		min, _ := strconv.Atoi(m[1])
+		max, _ := strconv.Atoi(m[2])
+		s.SetType = "RANGE"
+		s.RepMin = &min
+		s.RepMax = &max
+	case durationPattern.MatchString(value) || clockPattern.MatchString(value):
+		d, err := ParseDuration(value, time.Second)
+		if err != nil {
+			return err
+		}
+		s.Duration = &d
+	default:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("reps %q is not like 8, 8-12, AMRAP or 30s", value)
+		}
+		s.RepCount = &n
+	}
+	return nil
+}
+
+// parseLoad accepts 100, 100kg or 225lb for a weight and 75% for a share of the one rep max, bodyweight leaves it out
+func parseLoad(value string, s *models.Set) error {
+	value = strings.ToLower(strings.ReplaceAll(value, " ", ""))
+	if value == "" || value == "bw" || value == "bodyweight" {
+		return nil
+	}
+	m := loadPattern.FindStringSubmatch(strings.ReplaceAll(value, ",", "."))
+	if m == nil {
+		return fmt.Errorf("load %q is not like 100, 100kg, 225lb or 75%%", value)
+	}
+	n, _ := strconv.ParseFloat(m[1], 64)
+	switch m[2] {
+	case "%":
+		s.Percent1RM = &n
+	case "lb", "lbs":
+		unit := "LB"
+		s.Load = &n
+		s.LoadUnit = &unit
+	default:
+		unit := "KG"
+		s.Load = &n
+		s.LoadUnit = &unit
+	}
+	return nil
+}
+
+// ParseDuration reads 90s, 2m, 1:30 or a bare number counted in unit
+func ParseDuration(value string, unit time.Duration) (time.Duration, error) {
+	value = strings.ToLower(strings.ReplaceAll(value, " ", ""))
+	if m := clockPattern.FindStringSubmatch(value); m != nil {
+		minutes, _ := strconv.Atoi(m[1])
+		seconds, _ := strconv.Atoi(m[2])
+		return time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second, nil
+	}
+	if m := durationPattern.FindStringSubmatch(value); m != nil {
+		n, _ := strconv.ParseFloat(m[1], 64)
+		if strings.HasPrefix(m[2], "m") {
+			return time.Duration(n * float64(time.Minute)), nil
+		}
+		return time.Duration(n * float64(time.Second)), nil
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	return time.Duration(n * float64(unit)), nil
+}
@@ -0,0 +1,236 @@
+package exchange
+
+import (
+	"coachwise/src/app/models"
+	"coachwise/src/utils"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	sheetMargin = 40.0
+	sheetRow    = 20.0
+	qrModule    = 2.2
+)
+
+// sheet columns, the load and reps columns are left blank for the athlete to fill in
+var sheetColumns = []struct {
+	title string
+	width float64
+	blank bool
+}{
+	{"Set", 35, false},
+	{"Target", 110, false},
+	{"Prescribed", 120, false},
+	{"Rest", 60, false},
+	{"Load", 95, true},
+	{"Reps", 95, true},
+}
+
+// Sheet prints the plan as a workout sheet to take to the gym, with a set table per exercise,
+// the coach notes and a QR code linking back to the plan at url
+func Sheet(p *models.Plan, url string) ([]byte, error) {
+	qr, err := utils.QRCode([]byte(url))
+	if err != nil {
+		return nil, err
+	}
+	doc := utils.NewPDF()
+	width := utils.PageWidth - 2*sheetMargin
+	page := 1
+	y := sheetMargin
+
+	footer := func() {
+		doc.Gray(0.4)
+		doc.Text(sheetMargin, utils.PageHeight-20, 8, false, utils.FitText(p.Name, 8, false, width-60))
+		label := fmt.Sprintf("Page %d", page)
+		doc.Text(utils.PageWidth-sheetMargin-utils.TextWidth(label, 8, false), utils.PageHeight-20, 8, false, label)
+		doc.Gray(0)
+	}
+	// ensure starts a new page when the next h points would run into the footer
+	ensure := func(h float64) {
+		if y+h <= utils.PageHeight-sheetMargin {
+			return
+		}
+		footer()
+		doc.AddPage()
+		page++
+		y = sheetMargin
+	}
+
+	// the QR code sits in the top right corner with the title and notes flowing on its left
+	qrSize := float64(len(qr)) * qrModule
+	qrX := utils.PageWidth - sheetMargin - qrSize
+	for row := range qr {
+		for col := range qr[row] {
+			if qr[row][col] {
+				doc.Rect(qrX+float64(col)*qrModule, y+float64(row)*qrModule, qrModule, qrModule, true)
+			}
+		}
+	}
+	doc.Gray(0.4)
+	caption := "Scan to open the plan"
+	doc.Text(qrX+(qrSize-utils.TextWidth(caption, 7, false))/2, y+qrSize+10, 7, false, caption)
+	doc.Gray(0)
+
+	textWidth := qrX - sheetMargin - 15
+	y += 16
+	for _, line := range utils.WrapText(p.Name, 18, true, textWidth) {
+		doc.Text(sheetMargin, y, 18, true, line)
+		y += 22
+	}
+	doc.Text(sheetMargin, y, 10, false, "Date ____________________    Bodyweight __________")
+	y += 20
+	if p.Description != nil && strings.TrimSpace(*p.Description) != "" {
+		doc.Text(sheetMargin, y, 10, true, "Coach notes")
+		y += 14
+		for _, line := range utils.WrapText(*p.Description, 10, false, textWidth) {
+			ensure(14)
+			doc.Text(sheetMargin, y, 10, false, line)
+			y += 14
+		}
+	}
+	if top := sheetMargin + qrSize + 24; y < top {
+		y = top
+	}
+
+	for gi, g := range p.Groups {
+		ensure(sheetRow * 4)
+		y += 10
+		doc.Gray(0.9)
+		doc.Rect(sheetMargin, y-13, width, 18, true)
+		doc.Gray(0)
+		doc.Text(sheetMargin+6, y, 11, true, utils.FitText(groupTitle(gi, g), 11, true, width-12))
+		y += 20
+
+		for ei, pe := range g.Exercises {
+			if pe.Exercise == nil {
+				continue
+			}
+			sets := pe.Exercise.Sets
+			notes := []string{}
+			if pe.Notes != nil && strings.TrimSpace(*pe.Notes) != "" {
+				notes = utils.WrapText(*pe.Notes, 9, false, width-12)
+			}
+			// keep the name, notes and table header together with the first set
+			ensure(18 + float64(len(notes))*12 + sheetRow*2)
+
+			name := pe.Exercise.Name
+			if len(g.Exercises) > 1 {
+				name = fmt.Sprintf("%s%d. %s", groupLabel(gi), ei+1, name)
+			}
+			doc.Text(sheetMargin, y, 12, true, utils.FitText(name, 12, true, width))
+			y += 14
+			doc.Gray(0.3)
+			for _, line := range notes {
+				doc.Text(sheetMargin+6, y, 9, false, line)
+				y += 12
+			}
+			doc.Gray(0)
+			y += 4
+
+			header := func() {
+				x := sheetMargin
+				for _, col := range sheetColumns {
+					doc.Text(x+4, y, 8, true, col.title)
+					x += col.width
+				}
+				doc.Line(sheetMargin, y+4, sheetMargin+width, y+4, 0.8)
+				y += sheetRow - 4
+			}
+			header()
+			for si, s := range sets {
+				if y+sheetRow > utils.PageHeight-sheetMargin {
+					ensure(sheetRow * 2)
+					header()
+				}
+				values := []string{strconv.Itoa(si + 1), FormatReps(s), prescription(s), FormatDuration(s.RestTime)}
+				x := sheetMargin
+				for ci, col := range sheetColumns {
+					if col.blank {
+						doc.Rect(x+4, y-11, col.width-8, 15, false)
+					} else {
+						doc.Text(x+4, y, 9, false, utils.FitText(values[ci], 9, false, col.width-8))
+					}
+					x += col.width
+				}
+				y += sheetRow
+			}
+			if len(sets) < 1 {
+				doc.Gray(0.4)
+				doc.Text(sheetMargin+4, y, 9, false, "No sets prescribed")
+				doc.Gray(0)
+				y += sheetRow
+			}
+			if pe.RestTime > 0 {
+				doc.Gray(0.4)
+				doc.Text(sheetMargin+4, y-4, 8, false, "Rest "+FormatDuration(pe.RestTime)+" before the next exercise")
+				doc.Gray(0)
+				y += 10
+			}
+			y += 8
+		}
+	}
+
+	ensure(80)
+	y += 10
+	doc.Text(sheetMargin, y, 10, true, "Athlete notes")
+	for i := 0; i < 3; i++ {
+		y += 22
+		doc.Gray(0.6)
+		doc.Line(sheetMargin, y, sheetMargin+width, y, 0.5)
+		doc.Gray(0)
+	}
+	footer()
+	return doc.Bytes(), nil
+}
+
+func groupTitle(i int, g models.PlanGroup) string {
+	title := groupLabel(i)
+	if g.Name != nil && *g.Name != "" {
+		title += " · " + *g.Name
+	}
+	if g.GroupType != "SINGLE" {
+		title += " · " + strings.ToLower(g.GroupType)
+	}
+	if g.Rounds > 1 {
+		title += fmt.Sprintf(" · %d rounds", g.Rounds)
+	}
+	if g.RestTime > 0 {
+		title += " · rest " + FormatDuration(g.RestTime) + " between rounds"
+	}
+	return title
+}
+
+// groupLabel names the groups A to Z, then AA, AB and so on
+func groupLabel(i int) string {
+	label := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		label = string(rune('A'+(i-1)%26)) + label
+	}
+	return label
+}
+
+// prescription describes the intensity of the set, load or share of one rep max followed by effort and tempo
+func prescription(s models.Set) string {
+	parts := []string{}
+	if load := FormatLoad(s); load != "" {
+		if s.Percent1RM != nil {
+			load += " 1RM"
+		}
+		parts = append(parts, load)
+	}
+	if s.RPE != nil {
+		parts = append(parts, "RPE "+formatNumber(*s.RPE))
+	}
+	if s.RIR != nil {
+		parts = append(parts, fmt.Sprintf("%d RIR", *s.RIR))
+	}
+	if s.Tempo != nil {
+		parts = append(parts, *s.Tempo)
+	}
+	if s.Side != nil && *s.Side != "GENERAL" {
+		parts = append(parts, strings.ToLower(*s.Side))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,24 @@
+package mailer
+
+import (
+	"log"
+)
+
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// Default is used by Send, replace it with a provider backed Mailer on startup
+var Default Mailer = LogMailer{}
+
+// LogMailer only logs messages, used on development and tests
+type LogMailer struct{}
+
+func (LogMailer) Send(to, subject, body string) error {
+	log.Printf("Mail to %s: %s\n%s\n", to, subject, body)
+	return nil
+}
+
+func Send(to, subject, body string) error {
+	return Default.Send(to, subject, body)
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	database "github.com/socious-io/pkg_database"
+
+	"github.com/google/uuid"
+)
+
+// AdminAuditLog Outcome is SUCCEEDED or FAILED with the Error of the action, nil on impersonated requests
+// which are recorded before they run
+type AdminAuditLog struct {
+	ID         uuid.UUID  `db:"id" json:"id"`
+	AdminID    *uuid.UUID `db:"admin_id" json:"admin_id"`
+	Action     string     `db:"action" json:"action"`
+	TargetType string     `db:"target_type" json:"target_type"`
+	TargetID   uuid.UUID  `db:"target_id" json:"target_id"`
+	Reason     *string    `db:"reason" json:"reason"`
+	Outcome    *string    `db:"outcome" json:"outcome"`
+	Error      *string    `db:"error" json:"error"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+}
+
+func (AdminAuditLog) TableName() string {
+	return "admin_audit_logs"
+}
+
+func (AdminAuditLog) FetchQuery() string {
+	return "admin/fetch_audit_logs"
+}
+
+func (l *AdminAuditLog) Create(ctx context.Context) error {
+	rows, err := database.Query(
+		ctx,
+		"admin/create_audit_log",
+		l.AdminID, l.Action, l.TargetType, l.TargetID, l.Reason, l.Outcome, l.Error,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := rows.StructScan(l); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func GetAuditLogs(targetID, adminID *uuid.UUID, limit, offset int) ([]AdminAuditLog, int, error) {
+	rows := []struct {
+		AdminAuditLog
+		TotalCount int `db:"total_count"`
+	}{}
+	if err := database.QuerySelect("admin/list_audit_logs", &rows, targetID, adminID, limit, offset); err != nil {
+		return nil, 0, err
+	}
+	logs := make([]AdminAuditLog, len(rows))
+	total := 0
+	for i, r := range rows {
+		logs[i] = r.AdminAuditLog
+		total = r.TotalCount
+	}
+	return logs, total, nil
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	database "github.com/socious-io/pkg_database"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx/types"
+)
+
+// CalendarToken is the secret in the url of the personal calendar feed
+type CalendarToken struct {
+	UserID    uuid.UUID `json:"user_id" db:"user_id"`
+	Token     string    `json:"token" db:"token"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// CalendarEntry is a scheduled workout, an assigned plan with a due date or a programme session,
+// Plan is loaded for plans and the progressed snapshot for programme sessions
+type CalendarEntry struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	Kind        string     `json:"kind" db:"kind"`
+	PlanID      *uuid.UUID `json:"plan_id" db:"plan_id"`
+	Title       string     `json:"title" db:"title"`
+	Week        *int       `json:"week" db:"week"`
+	Day         *int       `json:"day" db:"day"`
+	ScheduledAt time.Time  `json:"scheduled_at" db:"scheduled_at"`
+	Plan        *Plan      `json:"plan" db:"-"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+
+	PlanJson types.JSONText `db:"plan" json:"-"`
+}
+
+// GetCalendarToken returns the feed token of the user, creating it on first use
+func GetCalendarToken(ctx context.Context, userID uuid.UUID) (*CalendarToken, error) {
+	return upsertCalendarToken(ctx, "calendar/get_token", userID)
+}
+
+// RegenerateCalendarToken replaces the feed token, subscriptions with the old one stop working
+func RegenerateCalendarToken(ctx context.Context, userID uuid.UUID) (*CalendarToken, error) {
+	return upsertCalendarToken(ctx, "calendar/regenerate_token", userID)
+}
+
+func upsertCalendarToken(ctx context.Context, queryName string, userID uuid.UUID) (*CalendarToken, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	rows, err := database.Query(ctx, queryName, userID, hex.EncodeToString(secret))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	t := new(CalendarToken)
+	for rows.Next() {
+		if err := rows.StructScan(t); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+func GetCalendarTokenByToken(token string) (*CalendarToken, error) {
+	t := new(CalendarToken)
+	if err := database.Get(t, "calendar/fetch_by_token", token); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// GetCalendarEntries lists the workouts of the user scheduled from since on, oldest first
+func GetCalendarEntries(userID uuid.UUID, since time.Time) ([]CalendarEntry, error) {
+	entries := []CalendarEntry{}
+	if err := database.QuerySelect("calendar/entries", &entries, userID, since); err != nil {
+		return nil, err
+	}
+	if err := database.UnmarshalJSONTextFields(&entries); err != nil {
+		return nil, err
+	}
+	ids := []uuid.UUID{}
+	for _, e := range entries {
+		if e.Kind == "PLAN" && e.PlanID != nil {
+			ids = append(ids, *e.PlanID)
+		}
+	}
+	plans, err := GetPlansByID(ids...)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		if entries[i].Kind == "PLAN" && entries[i].PlanID != nil {
+			entries[i].Plan = plans[*entries[i].PlanID]
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,192 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"math/big"
+	"time"
+
+	database "github.com/socious-io/pkg_database"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx/types"
+)
+
+// CoachAthlete links a coach to an athlete, it starts as an invite to Email and the athlete
+// accepts it with the mailed Code. Athlete, AssignedPlans and LastActivityAt are only loaded
+// once the invite is accepted and AssignedPlans only lists plans of the coach.
+type CoachAthlete struct {
+	ID             uuid.UUID      `json:"id" db:"id"`
+	CoachID        uuid.UUID      `json:"coach_id" db:"coach_id"`
+	AthleteID      *uuid.UUID     `json:"athlete_id" db:"athlete_id"`
+	Email          string         `json:"email" db:"email"`
+	Code           int            `json:"-" db:"code"`
+	Status         string         `json:"status" db:"status"`
+	Coach          *UserProfile   `json:"coach" db:"-"`
+	Athlete        *UserProfile   `json:"athlete" db:"-"`
+	AssignedPlans  []AssignedPlan `json:"assigned_plans" db:"-"`
+	LastActivityAt *time.Time     `json:"last_activity_at" db:"last_activity_at"`
+	ExpiresAt      time.Time      `json:"expires_at" db:"expires_at"`
+	RespondedAt    *time.Time     `json:"responded_at" db:"responded_at"`
+	CreatedAt      time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at" db:"updated_at"`
+
+	CoachJson         types.JSONText `db:"coach" json:"-"`
+	AthleteJson       types.JSONText `db:"athlete" json:"-"`
+	AssignedPlansJson types.JSONText `db:"assigned_plans" json:"-"`
+}
+
+// UserProfile is the part of a user shown to their coaches and athletes
+type UserProfile struct {
+	ID        uuid.UUID  `json:"id"`
+	Username  string     `json:"username"`
+	FirstName *string    `json:"first_name"`
+	LastName  *string    `json:"last_name"`
+	Email     *string    `json:"email"`
+	AvatarID  *uuid.UUID `json:"avatar_id"`
+}
+
+type AssignedPlan struct {
+	ID        uuid.UUID  `json:"id"`
+	PlanID    uuid.UUID  `json:"plan_id"`
+	Name      string     `json:"name"`
+	DueAt     *time.Time `json:"due_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+var (
+	ErrAlreadyCoached = errors.New("athlete is already coached by you")
+	ErrInviteInvalid  = errors.New("invite is invalid or expired")
+)
+
+func (*CoachAthlete) TableName() string {
+	return "coach_athletes"
+}
+
+func (*CoachAthlete) FetchQuery() string {
+	return "coaching/fetch"
+}
+
+// InviteAthlete invites email to be coached by coachID, inviting the same email again while
+// the invite is pending sends a new code. The account behind the email is only linked on accept.
+func InviteAthlete(ctx context.Context, coachID uuid.UUID, email string) (*CoachAthlete, error) {
+	ca := new(CoachAthlete)
+	n, err := rand.Int(rand.Reader, big.NewInt(900000))
+	if err != nil {
+		return nil, err
+	}
+	code := 100000 + int(n.Int64())
+	if err := scanCoachAthlete(ctx, ca, "coaching/invite", coachID, email, code); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrAlreadyCoached
+		}
+		return nil, err
+	}
+	// Fetch loads the profiles and leaves the code out
+	invite, err := GetCoachAthlete(ca.ID)
+	if err != nil {
+		return nil, err
+	}
+	invite.Code = code
+	return invite, nil
+}
+
+// Accept links the invite to the athlete, the invite has to be addressed to them and the code match
+func (ca *CoachAthlete) Accept(ctx context.Context, athlete *User, code int) error {
+	if err := scanCoachAthlete(ctx, ca, "coaching/accept", ca.ID, athlete.ID, athlete.Email, code); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrInviteInvalid
+		}
+		return err
+	}
+	return database.Fetch(ca, ca.ID)
+}
+
+func (ca *CoachAthlete) Decline(ctx context.Context, athlete *User) error {
+	if err := scanCoachAthlete(ctx, ca, "coaching/decline", ca.ID, athlete.ID, athlete.Email); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrInviteInvalid
+		}
+		return err
+	}
+	return database.Fetch(ca, ca.ID)
+}
+
+// Revoke ends the relationship or cancels the invite, either side can revoke
+func (ca *CoachAthlete) Revoke(ctx context.Context, userID uuid.UUID) error {
+	if err := scanCoachAthlete(ctx, ca, "coaching/revoke", ca.ID, userID); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrInviteInvalid
+		}
+		return err
+	}
+	return database.Fetch(ca, ca.ID)
+}
+
+func scanCoachAthlete(ctx context.Context, ca *CoachAthlete, queryName string, args ...interface{}) error {
+	rows, err := database.Query(ctx, queryName, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	found := false
+	for rows.Next() {
+		if err := rows.StructScan(ca); err != nil {
+			return err
+		}
+		found = true
+	}
+	if !found {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func GetCoachAthlete(id uuid.UUID) (*CoachAthlete, error) {
+	ca := new(CoachAthlete)
+	if err := database.Fetch(ca, id); err != nil {
+		return nil, err
+	}
+	return ca, nil
+}
+
+// GetRoster lists the accepted athletes of the coach, latest first
+func GetRoster(coachID uuid.UUID, limit, offset int) ([]CoachAthlete, int, error) {
+	return getCoachAthletes("coaching/roster", coachID, limit, offset)
+}
+
+// GetCoaches lists the coaches the athlete accepted, latest first
+func GetCoaches(athleteID uuid.UUID, limit, offset int) ([]CoachAthlete, int, error) {
+	return getCoachAthletes("coaching/coaches", athleteID, limit, offset)
+}
+
+// GetSentInvites lists the invites of the coach still waiting for an answer, expired ones included
+func GetSentInvites(coachID uuid.UUID, limit, offset int) ([]CoachAthlete, int, error) {
+	return getCoachAthletes("coaching/sent_invites", coachID, limit, offset)
+}
+
+// GetReceivedInvites lists the unexpired invites addressed to the user or their email
+func GetReceivedInvites(u *User, limit, offset int) ([]CoachAthlete, int, error) {
+	return getCoachAthletes("coaching/received_invites", u.ID, u.Email, limit, offset)
+}
+
+func getCoachAthletes(queryName string, args ...interface{}) ([]CoachAthlete, int, error) {
+	list := []database.FetchList{}
+	if err := database.QuerySelect(queryName, &list, args...); err != nil {
+		return nil, 0, err
+	}
+	return orderByIDs(list, func(ca CoachAthlete) uuid.UUID { return ca.ID })
+}
+
+// IsCoachOf reports whether the athlete accepted the coach
+func IsCoachOf(coachID, athleteID uuid.UUID) (bool, error) {
+	result := struct {
+		Coach bool `db:"coach"`
+	}{}
+	if err := database.Get(&result, "coaching/is_coach", coachID, athleteID); err != nil {
+		return false, err
+	}
+	return result.Coach, nil
+}
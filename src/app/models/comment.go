@@ -0,0 +1,256 @@
+package models
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	database "github.com/socious-io/pkg_database"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/types"
+	"github.com/lib/pq"
+)
+
+// CommentTargets are what comments are left on
+var CommentTargets = []string{"PLAN", "PLAN_ASSIGNMENT", "WORKOUT_SESSION", "PARAM_LOG"}
+
+// mentions are @username at the start of the body or after a space, usernames are generated from [a-z0-9._-]
+var mentionPattern = regexp.MustCompile(`(?:^|\s)@([A-Za-z0-9._-]+)`)
+
+// Comment is a note on a target, replies point to their ParentID. Body and Mentions are hidden once
+// the comment is deleted, the comment itself stays to keep its replies in the thread.
+type Comment struct {
+	ID         uuid.UUID     `json:"id" db:"id"`
+	TargetType string        `json:"target_type" db:"target_type"`
+	TargetID   uuid.UUID     `json:"target_id" db:"target_id"`
+	ParentID   *uuid.UUID    `json:"parent_id" db:"parent_id"`
+	UserID     uuid.UUID     `json:"user_id" db:"user_id"`
+	Author     *UserProfile  `json:"author" db:"-"`
+	Body       *string       `json:"body" db:"body"`
+	Mentions   []UserProfile `json:"mentions" db:"-"`
+	Depth      int           `json:"depth" db:"depth"`
+	Replies    int           `json:"replies" db:"replies"`
+	Revisions  int           `json:"revisions" db:"revisions"`
+	EditedAt   *time.Time    `json:"edited_at" db:"edited_at"`
+	DeletedAt  *time.Time    `json:"deleted_at" db:"deleted_at"`
+	CreatedAt  time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time     `json:"updated_at" db:"updated_at"`
+
+	AuthorJson   types.JSONText `db:"author" json:"-"`
+	MentionsJson types.JSONText `db:"mentions" json:"-"`
+}
+
+// CommentRevision is a previous body of an edited comment, WrittenAt is when it was posted or last edited
+type CommentRevision struct {
+	ID         uuid.UUID `json:"id" db:"id"`
+	CommentID  uuid.UUID `json:"comment_id" db:"comment_id"`
+	Body       string    `json:"body" db:"body"`
+	WrittenAt  time.Time `json:"written_at" db:"written_at"`
+	ReplacedAt time.Time `json:"replaced_at" db:"replaced_at"`
+}
+
+// CommentTarget tells who takes part in the comments of a target: the athlete a workout session or param log
+// belongs to with their coaches, the editors of a plan, and the athlete of an assignment with the coach
+// whose plan was assigned
+type CommentTarget struct {
+	Type      string     `db:"-"`
+	ID        uuid.UUID  `db:"-"`
+	AthleteID *uuid.UUID `db:"athlete_id"`
+	PlanID    *uuid.UUID `db:"plan_id"`
+	CoachID   *uuid.UUID `db:"coach_id"`
+
+	plan *Plan
+}
+
+func (*Comment) TableName() string {
+	return "comments"
+}
+
+func (*Comment) FetchQuery() string {
+	return "comments/fetch"
+}
+
+func GetCommentTarget(targetType string, id uuid.UUID) (*CommentTarget, error) {
+	t := &CommentTarget{Type: targetType, ID: id}
+	if err := database.Get(t, "comments/target", targetType, id); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// CanAccess reports whether the user reads and writes the comments of the target
+func (t *CommentTarget) CanAccess(userID uuid.UUID) (bool, error) {
+	if t.Type == "PLAN_ASSIGNMENT" {
+		return (t.AthleteID != nil && *t.AthleteID == userID) || (t.CoachID != nil && *t.CoachID == userID), nil
+	}
+	if t.AthleteID != nil {
+		if *t.AthleteID == userID {
+			return true, nil
+		}
+		coach, err := IsCoachOf(userID, *t.AthleteID)
+		if err != nil || coach {
+			return coach, err
+		}
+	}
+	if t.PlanID == nil {
+		return false, nil
+	}
+	if t.plan == nil {
+		p, err := GetPlan(*t.PlanID)
+		if err != nil {
+			return false, err
+		}
+		t.plan = p
+	}
+	return CanEdit(userID, &t.plan.UserID, t.plan.OrganizationID, t.plan.Visibility)
+}
+
+// MentionedUsers resolves the @usernames of the body to the users taking part in the target, the author left out
+func (t *CommentTarget) MentionedUsers(body string, authorID uuid.UUID) ([]uuid.UUID, error) {
+	usernames := pq.StringArray{}
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		// a sentence may end right after the mention
+		usernames = append(usernames, strings.ToLower(strings.TrimRight(match[1], ".")))
+	}
+	result := []uuid.UUID{}
+	if len(usernames) < 1 {
+		return result, nil
+	}
+	ids := []uuid.UUID{}
+	if err := database.QuerySelect("comments/mentioned_users", &ids, usernames); err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		if id == authorID {
+			continue
+		}
+		ok, err := t.CanAccess(id)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			result = append(result, id)
+		}
+	}
+	return result, nil
+}
+
+// Create mentions the users in the same transaction and returns the mentioned ones
+func (c *Comment) Create(ctx context.Context, mentions []uuid.UUID) ([]uuid.UUID, error) {
+	tx, err := database.GetDB().Beginx()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := database.TxQuery(ctx, tx, "comments/create", c.TargetType, c.TargetID, c.ParentID, c.UserID, c.Body)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	for rows.Next() {
+		if err := rows.Scan(&c.ID); err != nil {
+			rows.Close()
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	rows.Close()
+	added, err := c.setMentions(ctx, tx, mentions)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return added, database.Fetch(c, c.ID)
+}
+
+// Update keeps the previous body as a revision and replaces the mentions in the same transaction,
+// it returns the users the comment did not mention before
+func (c *Comment) Update(ctx context.Context, body string, mentions []uuid.UUID) ([]uuid.UUID, error) {
+	tx, err := database.GetDB().Beginx()
+	if err != nil {
+		return nil, err
+	}
+	if err := txQueryOne(ctx, tx, "comments/update", c.ID, body); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	added, err := c.setMentions(ctx, tx, mentions)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return added, database.Fetch(c, c.ID)
+}
+
+// Delete hides the body of the comment, its replies are kept
+func (c *Comment) Delete(ctx context.Context) error {
+	if err := queryOne(ctx, "comments/delete", c.ID); err != nil {
+		return err
+	}
+	return database.Fetch(c, c.ID)
+}
+
+// setMentions replaces the users mentioned by the comment and returns the ones it did not mention before
+func (c *Comment) setMentions(ctx context.Context, tx *sqlx.Tx, userIDs []uuid.UUID) ([]uuid.UUID, error) {
+	ids := make(pq.StringArray, len(userIDs))
+	for i, id := range userIDs {
+		ids[i] = id.String()
+	}
+	rows, err := database.TxQuery(ctx, tx, "comments/set_mentions", c.ID, ids)
+	if err != nil {
+		return nil, err
+	}
+	added := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		added = append(added, id)
+	}
+	rows.Close()
+	return added, rows.Err()
+}
+
+func (c *Comment) GetRevisions(limit, offset int) ([]CommentRevision, int, error) {
+	rows := []struct {
+		CommentRevision
+		TotalCount int `db:"total_count"`
+	}{}
+	if err := database.QuerySelect("comments/revisions", &rows, c.ID, limit, offset); err != nil {
+		return nil, 0, err
+	}
+	revisions := make([]CommentRevision, len(rows))
+	total := 0
+	for i, r := range rows {
+		revisions[i] = r.CommentRevision
+		total = r.TotalCount
+	}
+	return revisions, total, nil
+}
+
+func GetComment(id uuid.UUID) (*Comment, error) {
+	c := new(Comment)
+	if err := database.Fetch(c, id); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// GetComments lists the comments of the target thread by thread, every reply right after the comment it answers
+func GetComments(targetType string, targetID uuid.UUID, limit, offset int) ([]Comment, int, error) {
+	list := []database.FetchList{}
+	if err := database.QuerySelect("comments/list", &list, targetType, targetID, limit, offset); err != nil {
+		return nil, 0, err
+	}
+	return orderByIDs(list, func(c Comment) uuid.UUID { return c.ID })
+}
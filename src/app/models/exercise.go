@@ -2,24 +2,51 @@ package models
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"regexp"
 	"time"
 
 	database "github.com/socious-io/pkg_database"
 
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
 	"github.com/jmoiron/sqlx/types"
+	"github.com/lib/pq"
 )
 
+// Exercise Public is kept for older clients, it is true for PUBLIC exercises
 type Exercise struct {
 	ID          uuid.UUID  `json:"id" db:"id"`
 	UserID      *uuid.UUID `json:"user_id" db:"user_id"`
 	Name        string     `json:"name" db:"name"`
 	Description string     `json:"description" db:"description"`
-	Public      bool       `json:"public" db:"public"`
 	Sets        []Set      `json:"sets" db:"-"`
 	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
 
+	// OwnerDeletedAt is set when the author was purged and the exercise kept for its users
+	OwnerDeletedAt *time.Time `json:"owner_deleted_at" db:"owner_deleted_at"`
+
+	Sports           pq.StringArray `json:"sports" db:"sports"`
+	PrimaryMuscles   pq.StringArray `json:"primary_muscles" db:"primary_muscles"`
+	SecondaryMuscles pq.StringArray `json:"secondary_muscles" db:"secondary_muscles"`
+	Equipment        pq.StringArray `json:"equipment" db:"equipment"`
+	Difficulty       *string        `json:"difficulty" db:"difficulty"`
+
+	Language     string `json:"language" db:"language"`
+	SearchVector string `json:"-" db:"search_vector"`
+
+	ForkedFrom *uuid.UUID `json:"forked_from" db:"forked_from"`
+	Forks      int        `json:"forks" db:"forks"`
+
+	Visibility     string     `json:"visibility" db:"visibility"`
+	OrganizationID *uuid.UUID `json:"organization_id" db:"organization_id"`
+	Public         bool       `json:"public" db:"public"`
+
+	SeedKey     *string `json:"seed_key" db:"seed_key"`
+	SeedVersion *int    `json:"-" db:"seed_version"`
+
 	SetsJson types.JSONText `db:"sets" json:"-"`
 }
 
@@ -33,8 +60,29 @@ type Set struct {
 	Duration   *time.Duration `json:"duration" db:"duration"`
 	CreatedAt  time.Time      `json:"created_at" db:"created_at"`
 	UpdatedAt  time.Time      `json:"updated_at" db:"updated_at"`
+
+	SetType     string         `json:"set_type" db:"set_type"`
+	RepMin      *int           `json:"rep_min" db:"rep_min"`
+	RepMax      *int           `json:"rep_max" db:"rep_max"`
+	Interval    *time.Duration `json:"interval" db:"interval"`
+	Rounds      *int           `json:"rounds" db:"rounds"`
+	Drops       *int           `json:"drops" db:"drops"`
+	DropPercent *float64       `json:"drop_percent" db:"drop_percent"`
+
+	Load         *float64 `json:"load" db:"load"`
+	LoadUnit     *string  `json:"load_unit" db:"load_unit"`
+	Percent1RM   *float64 `json:"percent_1rm" db:"percent_1rm"`
+	RPE          *float64 `json:"rpe" db:"rpe"`
+	RIR          *int     `json:"rir" db:"rir"`
+	Tempo        *string  `json:"tempo" db:"tempo"`
+	Distance     *float64 `json:"distance" db:"distance"`
+	DistanceUnit *string  `json:"distance_unit" db:"distance_unit"`
+	Grade        *string  `json:"grade" db:"grade"`
+	Side         *string  `json:"side" db:"side"`
 }
 
+var tempoPattern = regexp.MustCompile(`^[0-9X]-[0-9X]-[0-9X]-[0-9X]$`)
+
 func (*Exercise) TableName() string {
 	return "exercises"
 }
@@ -48,19 +96,36 @@ func (e *Exercise) Create(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	if err := e.insert(ctx, tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	return database.Fetch(e, e.ID)
+}
+
+// insert writes the exercise with its sets and first revision within tx
+func (e *Exercise) insert(ctx context.Context, tx *sqlx.Tx) error {
+	if e.Visibility == "" {
+		e.Visibility = VisibilityPrivate
+	}
 	rows, err := database.TxQuery(
 		ctx,
 		tx,
 		"exercises/create",
-		e.UserID, e.Name, e.Description, e.Public,
+		e.UserID, e.Name, e.Description, e.Visibility,
+		e.Sports, e.PrimaryMuscles, e.SecondaryMuscles, e.Equipment, e.Difficulty, e.Language,
+		e.ForkedFrom, e.OrganizationID,
 	)
 	if err != nil {
-		tx.Rollback()
 		return err
 	}
 	for rows.Next() {
 		if err := rows.StructScan(e); err != nil {
-			tx.Rollback()
+			rows.Close()
 			return err
 		}
 	}
@@ -71,18 +136,21 @@ func (e *Exercise) Create(ctx context.Context) error {
 		e.Sets[i].SetNumber = i + 1
 	}
 
-	if _, err := database.TxExecuteQuery(tx, "exercises/create_sets", e.Sets); err != nil {
-		tx.Rollback()
-		return err
+	if len(e.Sets) > 0 {
+		if _, err := database.TxExecuteQuery(tx, "exercises/create_sets", e.Sets); err != nil {
+			return err
+		}
 	}
 
-	if err := tx.Commit(); err != nil {
+	rows, err = database.TxQuery(ctx, tx, "exercises/create_revision", e.ID)
+	if err != nil {
 		return err
 	}
-
-	return database.Fetch(e, e.ID)
+	return rows.Close()
 }
 
+// Update overwrites the exercise and records a new revision, sets are replaced by set number when given
+// and kept when Sets is nil, an empty Sets removes them all
 func (e *Exercise) Update(ctx context.Context) error {
 	tx, err := database.GetDB().Beginx()
 	if err != nil {
@@ -92,7 +160,9 @@ func (e *Exercise) Update(ctx context.Context) error {
 		ctx,
 		tx,
 		"exercises/update",
-		e.ID, e.Name, e.Description, e.Public,
+		e.ID, e.Name, e.Description, e.Visibility,
+		e.Sports, e.PrimaryMuscles, e.SecondaryMuscles, e.Equipment, e.Difficulty, e.Language,
+		e.OrganizationID,
 	)
 	if err != nil {
 		tx.Rollback()
@@ -106,14 +176,19 @@ func (e *Exercise) Update(ctx context.Context) error {
 	}
 	rows.Close()
 
-	for i := range e.Sets {
-		e.Sets[i].SetNumber = i + 1
+	if e.Sets != nil {
+		if err := e.replaceSets(ctx, tx); err != nil {
+			tx.Rollback()
+			return err
+		}
 	}
 
-	if _, err := database.TxExecuteQuery(tx, "exercises/update_sets", e.Sets); err != nil {
+	rows, err = database.TxQuery(ctx, tx, "exercises/create_revision", e.ID)
+	if err != nil {
 		tx.Rollback()
 		return err
 	}
+	rows.Close()
 
 	if err := tx.Commit(); err != nil {
 		return err
@@ -122,6 +197,295 @@ func (e *Exercise) Update(ctx context.Context) error {
 	return database.Fetch(e, e.ID)
 }
 
+// replaceSets upserts the sets by set number and drops the ones past the last of them
+func (e *Exercise) replaceSets(ctx context.Context, tx *sqlx.Tx) error {
+	for i := range e.Sets {
+		e.Sets[i].ExerciseID = e.ID
+		e.Sets[i].SetNumber = i + 1
+	}
+	if len(e.Sets) > 0 {
+		if _, err := database.TxExecuteQuery(tx, "exercises/update_sets", e.Sets); err != nil {
+			return err
+		}
+	}
+	rows, err := database.TxQuery(ctx, tx, "exercises/delete_sets", e.ID, len(e.Sets))
+	if err != nil {
+		return err
+	}
+	return rows.Close()
+}
+
+// Fork copies the exercise and its sets into a private exercise owned by userID
+func (e *Exercise) Fork(ctx context.Context, userID uuid.UUID) (*Exercise, error) {
+	tx, err := database.GetDB().Beginx()
+	if err != nil {
+		return nil, err
+	}
+	fork, err := forkExercise(ctx, tx, e.ID, userID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	if err := database.Fetch(fork, fork.ID); err != nil {
+		return nil, err
+	}
+	return fork, nil
+}
+
+// forkExercise copies the exercise id with its sets into a private exercise of userID within tx
+func forkExercise(ctx context.Context, tx *sqlx.Tx, id, userID uuid.UUID) (*Exercise, error) {
+	fork := new(Exercise)
+	rows, err := database.TxQuery(ctx, tx, "exercises/fork", id, userID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		if err := rows.StructScan(fork); err != nil {
+			rows.Close()
+			return nil, err
+		}
+	}
+	rows.Close()
+
+	rows, err = database.TxQuery(ctx, tx, "exercises/fork_sets", id, fork.ID)
+	if err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	rows, err = database.TxQuery(ctx, tx, "exercises/create_revision", fork.ID)
+	if err != nil {
+		return nil, err
+	}
+	rows.Close()
+	return fork, nil
+}
+
+// Restore brings the content and sets of the revision back as a new revision, visibility is kept as it is
+func (e *Exercise) Restore(ctx context.Context, r *ExerciseRevision) error {
+	if r.Snapshot == nil {
+		return errors.New("revision has no snapshot")
+	}
+	e.Name = r.Snapshot.Name
+	e.Description = r.Snapshot.Description
+	e.Sports = r.Snapshot.Sports
+	e.PrimaryMuscles = r.Snapshot.PrimaryMuscles
+	e.SecondaryMuscles = r.Snapshot.SecondaryMuscles
+	e.Equipment = r.Snapshot.Equipment
+	e.Difficulty = r.Snapshot.Difficulty
+	e.Language = r.Snapshot.Language
+	// a revision without sets still replaces the current ones
+	e.Sets = r.Snapshot.Sets
+	if e.Sets == nil {
+		e.Sets = []Set{}
+	}
+	return e.Update(ctx)
+}
+
+// MaxSets is the most sets an exercise prescribes and MaxRounds the most rounds of a set or plan group
+const (
+	MaxSets   = 20
+	MaxRounds = 20
+)
+
+// Validate normalizes the taxonomy tags and checks they are known, language defaults to english
+func (e *Exercise) Validate() error {
+	if len(e.Sets) > MaxSets {
+		return fmt.Errorf("an exercise can not have more than %d sets", MaxSets)
+	}
+	var err error
+	if e.Language, err = SearchLanguage(e.Language); err != nil {
+		return err
+	}
+	if e.Sports, err = NormalizeTerms("sports", e.Sports); err != nil {
+		return err
+	}
+	if e.PrimaryMuscles, err = NormalizeTerms("muscle_groups", e.PrimaryMuscles); err != nil {
+		return err
+	}
+	if e.SecondaryMuscles, err = NormalizeTerms("muscle_groups", e.SecondaryMuscles); err != nil {
+		return err
+	}
+	if e.Equipment, err = NormalizeTerms("equipment", e.Equipment); err != nil {
+		return err
+	}
+	if e.Difficulty != nil {
+		difficulty, err := NormalizeTerms("difficulties", []string{*e.Difficulty})
+		if err != nil {
+			return err
+		}
+		if len(difficulty) < 1 {
+			e.Difficulty = nil
+		} else {
+			e.Difficulty = &difficulty[0]
+		}
+	}
+	return nil
+}
+
+func (e *Exercise) Unpublish(ctx context.Context) error {
+	return queryOne(ctx, "exercises/unpublish", e.ID)
+}
+
+// Validate checks the prescription is a sensible combination, set type defaults to STANDARD,
+// units to KG and M and EMOM intervals to one minute when missing
+func (s *Set) Validate() error {
+	if s.SetType == "" {
+		s.SetType = "STANDARD"
+	}
+	if s.RestTime < 0 {
+		return errors.New("rest_time can not be negative")
+	}
+	if s.RepCount != nil && s.Duration != nil {
+		return errors.New("set can have either rep_count or duration")
+	}
+	if err := s.validateType(); err != nil {
+		return err
+	}
+	if s.RepCount != nil && *s.RepCount < 1 {
+		return errors.New("rep_count must be positive")
+	}
+	if s.Duration != nil && *s.Duration <= 0 {
+		return errors.New("duration must be positive")
+	}
+	if s.Load != nil {
+		if *s.Load < 0 {
+			return errors.New("load can not be negative")
+		}
+		if s.Percent1RM != nil {
+			return errors.New("set can have either load or percent_1rm")
+		}
+		if s.LoadUnit == nil {
+			unit := "KG"
+			s.LoadUnit = &unit
+		}
+		if *s.LoadUnit != "KG" && *s.LoadUnit != "LB" {
+			return errors.New("load_unit must be KG or LB")
+		}
+	} else if s.LoadUnit != nil {
+		return errors.New("load_unit requires load")
+	}
+	if s.Percent1RM != nil && (*s.Percent1RM <= 0 || *s.Percent1RM > 120) {
+		return errors.New("percent_1rm must be between 0 and 120")
+	}
+	if s.RPE != nil && s.RIR != nil {
+		return errors.New("set can have either rpe or rir")
+	}
+	if s.RPE != nil && (*s.RPE < 1 || *s.RPE > 10) {
+		return errors.New("rpe must be between 1 and 10")
+	}
+	if s.RIR != nil && *s.RIR < 0 {
+		return errors.New("rir can not be negative")
+	}
+	if s.Tempo != nil && !tempoPattern.MatchString(*s.Tempo) {
+		return errors.New("tempo must be like 3-1-1-0, X for explosive")
+	}
+	if s.Distance != nil {
+		if *s.Distance <= 0 {
+			return errors.New("distance must be positive")
+		}
+		if s.DistanceUnit == nil {
+			unit := "M"
+			s.DistanceUnit = &unit
+		}
+		if *s.DistanceUnit != "CM" && *s.DistanceUnit != "M" && *s.DistanceUnit != "KM" {
+			return errors.New("distance_unit must be CM, M or KM")
+		}
+	} else if s.DistanceUnit != nil {
+		return errors.New("distance_unit requires distance")
+	}
+	if s.Grade != nil && len(*s.Grade) > 16 {
+		return errors.New("grade is too long")
+	}
+	if s.Side != nil && *s.Side != "LEFT" && *s.Side != "RIGHT" && *s.Side != "GENERAL" {
+		return errors.New("side must be LEFT, RIGHT or GENERAL")
+	}
+	return nil
+}
+
+func (s *Set) validateType() error {
+	if s.SetType != "RANGE" && s.SetType != "AMRAP" && s.SetType != "DROP" && (s.RepMin != nil || s.RepMax != nil) {
+		return errors.New("rep_min and rep_max are only allowed on RANGE, AMRAP and DROP sets")
+	}
+	if s.SetType != "EMOM" && s.SetType != "INTERVAL" && (s.Interval != nil || s.Rounds != nil) {
+		return errors.New("interval and rounds are only allowed on EMOM and INTERVAL sets")
+	}
+	if s.SetType != "DROP" && (s.Drops != nil || s.DropPercent != nil) {
+		return errors.New("drops and drop_percent are only allowed on DROP sets")
+	}
+	if s.RepMin != nil && *s.RepMin < 1 {
+		return errors.New("rep_min must be positive")
+	}
+	if s.RepMin != nil && s.RepMax != nil && *s.RepMin > *s.RepMax {
+		return errors.New("rep_min can not be greater than rep_max")
+	}
+
+	switch s.SetType {
+	case "STANDARD", "WARMUP":
+		if s.RepCount == nil && s.Duration == nil && s.Distance == nil {
+			return errors.New("one of rep_count, duration or distance is required")
+		}
+	case "RANGE":
+		if s.RepMin == nil || s.RepMax == nil {
+			return errors.New("RANGE sets require rep_min and rep_max")
+		}
+		if s.RepCount != nil || s.Duration != nil {
+			return errors.New("RANGE sets can not have rep_count or duration")
+		}
+	case "AMRAP":
+		if s.RepCount != nil || s.RepMax != nil {
+			return errors.New("AMRAP sets can not have rep_count or rep_max")
+		}
+	case "EMOM":
+		if s.RepCount == nil && s.Distance == nil {
+			return errors.New("EMOM sets require rep_count or distance per round")
+		}
+		if s.Duration != nil {
+			return errors.New("EMOM sets can not have duration, use interval and rounds")
+		}
+		if s.Interval == nil {
+			interval := time.Minute
+			s.Interval = &interval
+		}
+	case "INTERVAL":
+		if s.Duration == nil {
+			return errors.New("INTERVAL sets require duration of work per round")
+		}
+		if s.Interval != nil && *s.Interval < *s.Duration {
+			return errors.New("interval can not be shorter than duration")
+		}
+	case "DROP":
+		if s.RepCount == nil && s.RepMin == nil {
+			return errors.New("DROP sets require rep_count or rep_min")
+		}
+		if s.Load == nil && s.Percent1RM == nil {
+			return errors.New("DROP sets require load or percent_1rm")
+		}
+		if s.Drops == nil || *s.Drops < 1 {
+			return errors.New("DROP sets require at least one drop")
+		}
+		if s.DropPercent != nil && (*s.DropPercent <= 0 || *s.DropPercent > 50) {
+			return errors.New("drop_percent must be between 0 and 50")
+		}
+	default:
+		return errors.New("set_type must be STANDARD, WARMUP, RANGE, AMRAP, EMOM, INTERVAL or DROP")
+	}
+
+	if s.Interval != nil && *s.Interval <= 0 {
+		return errors.New("interval must be positive")
+	}
+	if (s.SetType == "EMOM" || s.SetType == "INTERVAL") && (s.Rounds == nil || *s.Rounds < 1) {
+		return errors.New("rounds must be positive")
+	}
+	if s.Rounds != nil && *s.Rounds > MaxRounds {
+		return fmt.Errorf("rounds can not be more than %d", MaxRounds)
+	}
+	return nil
+}
+
 func (*Set) TableName() string {
 	return "sets"
 }
@@ -130,6 +494,56 @@ func (*Set) FetchQuery() string {
 	return "exercises/fetch_sets"
 }
 
+// ExerciseFilter narrows down the library, every set field must match one of its terms
+type ExerciseFilter struct {
+	Query          *string
+	Visibility     *string
+	OrganizationID *uuid.UUID
+	Sports         []string
+	Muscles        []string
+	PrimaryMuscles []string
+	Equipment      []string
+	Difficulties   []string
+}
+
+// GetExercises lists own, public and organization shared exercises matching the filter
+func GetExercises(userID uuid.UUID, f ExerciseFilter, limit, offset int) ([]Exercise, int, error) {
+	list := []database.FetchList{}
+	if err := database.QuerySelect(
+		"exercises/list", &list,
+		userID, f.Visibility,
+		pq.StringArray(f.Sports), pq.StringArray(f.Muscles), pq.StringArray(f.PrimaryMuscles),
+		pq.StringArray(f.Equipment), pq.StringArray(f.Difficulties),
+		limit, offset, f.Query, f.OrganizationID, pq.StringArray(SearchLanguages),
+	); err != nil {
+		return nil, 0, err
+	}
+	return orderByIDs(list, func(e Exercise) uuid.UUID { return e.ID })
+}
+
+// ExerciseMatch is the closest visible exercise to an imported name, Score is the trigram similarity from 0 to 1
+type ExerciseMatch struct {
+	ID     uuid.UUID  `json:"id" db:"id"`
+	Name   string     `json:"name" db:"name"`
+	UserID *uuid.UUID `json:"user_id" db:"user_id"`
+	Score  float64    `json:"score" db:"score"`
+}
+
+// MatchThreshold is the lowest similarity accepted as the same exercise
+const MatchThreshold = 0.4
+
+// MatchExercise finds the public or own exercise named most like name, own exercises win ties, nil when nothing is close enough
+func MatchExercise(userID uuid.UUID, name string) (*ExerciseMatch, error) {
+	matches := []ExerciseMatch{}
+	if err := database.QuerySelect("exercises/match", &matches, userID, name, MatchThreshold); err != nil {
+		return nil, err
+	}
+	if len(matches) < 1 {
+		return nil, nil
+	}
+	return &matches[0], nil
+}
+
 func GetExrcise(id uuid.UUID) (*Exercise, error) {
 	e := new(Exercise)
 	if err := database.Fetch(e, id); err != nil {
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"fmt"
+
+	database "github.com/socious-io/pkg_database"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// fetchByIDs fetches the rows of ids with the FetchQuery of T, database.Fetch looks the query up on the
+// elements of a slice and misses the models declaring it on a pointer receiver
+func fetchByIDs[T any](ids ...interface{}) ([]T, error) {
+	result := []T{}
+	if len(ids) < 1 {
+		return result, nil
+	}
+	model, ok := any(new(T)).(database.Model)
+	if !ok {
+		return nil, fmt.Errorf("can not cast %T to Model", new(T))
+	}
+	q, err := database.LoadQuery(model.FetchQuery())
+	if err != nil {
+		return nil, err
+	}
+	query, args, err := sqlx.In(q, ids)
+	if err != nil {
+		return nil, err
+	}
+	db := database.GetDB()
+	if err := db.Select(&result, db.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+	if err := database.UnmarshalJSONTextFields(&result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// orderByIDs fetches the rows of a list query in the order of the list with its total count,
+// the fetch query does not keep the order of ids
+func orderByIDs[T any](list []database.FetchList, id func(T) uuid.UUID) ([]T, int, error) {
+	if len(list) < 1 {
+		return []T{}, 0, nil
+	}
+	ids := make([]interface{}, len(list))
+	for i, l := range list {
+		ids[i] = l.ID
+	}
+	rows, err := fetchByIDs[T](ids...)
+	if err != nil {
+		return nil, 0, err
+	}
+	byID := make(map[uuid.UUID]T, len(rows))
+	for _, r := range rows {
+		byID[id(r)] = r
+	}
+	ordered := make([]T, 0, len(list))
+	for _, l := range list {
+		if r, ok := byID[l.ID]; ok {
+			ordered = append(ordered, r)
+		}
+	}
+	return ordered, list[0].TotalCount, nil
+}
@@ -0,0 +1,189 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	database "github.com/socious-io/pkg_database"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// GoalStatuses ACTIVE goals are evaluated on every new log of their param or set logged on their exercise,
+// they turn MISSED once their deadline passed
+var GoalStatuses = []string{"ACTIVE", "ACHIEVED", "MISSED", "CANCELLED"}
+
+// Goal targets either a param, read from the latest log of Side, or the best personal record of Metric on an exercise.
+// StartValue is the value when the goal was set, or the first one logged after, a target below it is reached by going down.
+// Progress is the percent of the way from StartValue to TargetValue.
+type Goal struct {
+	ID           uuid.UUID  `json:"id" db:"id"`
+	UserID       uuid.UUID  `json:"user_id" db:"user_id"`
+	ParamID      *uuid.UUID `json:"param_id" db:"param_id"`
+	ParamName    *string    `json:"param_name" db:"param_name"`
+	ExerciseID   *uuid.UUID `json:"exercise_id" db:"exercise_id"`
+	ExerciseName *string    `json:"exercise_name" db:"exercise_name"`
+	Side         string     `json:"side" db:"side"`
+	Metric       *string    `json:"metric" db:"metric"`
+	TargetValue  float64    `json:"target_value" db:"target_value"`
+	Unit         string     `json:"unit" db:"unit"`
+	StartValue   *float64   `json:"start_value" db:"start_value"`
+	CurrentValue *float64   `json:"current_value" db:"current_value"`
+	Progress     float64    `json:"progress" db:"progress"`
+	Deadline     *time.Time `json:"deadline" db:"deadline"`
+	Status       string     `json:"status" db:"status"`
+	Note         *string    `json:"note" db:"note"`
+	AchievedAt   *time.Time `json:"achieved_at" db:"achieved_at"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+func (*Goal) TableName() string {
+	return "goals"
+}
+
+func (*Goal) FetchQuery() string {
+	return "goals/fetch"
+}
+
+// Name is the param or exercise the goal targets
+func (g *Goal) Name() string {
+	if g.ParamName != nil {
+		return *g.ParamName
+	}
+	if g.ExerciseName != nil {
+		return *g.ExerciseName
+	}
+	return ""
+}
+
+// Create writes the goal and evaluates it against the logs so far, which also sets its StartValue
+func (g *Goal) Create(ctx context.Context) error {
+	tx, err := database.GetDB().Beginx()
+	if err != nil {
+		return err
+	}
+	rows, err := database.TxQuery(
+		ctx,
+		tx,
+		"goals/create",
+		g.UserID, g.ParamID, g.ExerciseID, g.Side, g.Metric, g.TargetValue, g.Unit, g.Deadline, g.Note,
+	)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	for rows.Next() {
+		if err := rows.Scan(&g.ID); err != nil {
+			rows.Close()
+			tx.Rollback()
+			return err
+		}
+	}
+	rows.Close()
+	if _, err := evaluateGoals(ctx, tx, g.UserID, &g.ID, nil, nil, true); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return database.Fetch(g, g.ID)
+}
+
+// Update changes the target of the goal and reopens it, the StartValue is kept
+func (g *Goal) Update(ctx context.Context) error {
+	tx, err := database.GetDB().Beginx()
+	if err != nil {
+		return err
+	}
+	rows, err := database.TxQuery(ctx, tx, "goals/update", g.ID, g.TargetValue, g.Deadline, g.Note)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	found := rows.Next()
+	rows.Close()
+	if !found {
+		tx.Rollback()
+		return sql.ErrNoRows
+	}
+	if _, err := evaluateGoals(ctx, tx, g.UserID, &g.ID, nil, nil, false); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return database.Fetch(g, g.ID)
+}
+
+// Cancel stops evaluating an ACTIVE or MISSED goal
+func (g *Goal) Cancel(ctx context.Context) error {
+	if err := queryOne(ctx, "goals/cancel", g.ID); err != nil {
+		return err
+	}
+	return database.Fetch(g, g.ID)
+}
+
+// evaluateGoals compares the ACTIVE goals matching the filters with the current value, baseline sets it as their StartValue.
+// It returns the ids of the goals achieved, to fetch once the transaction is committed.
+func evaluateGoals(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, goalID, paramID, exerciseID *uuid.UUID, baseline bool) ([]interface{}, error) {
+	rows, err := database.TxQuery(ctx, tx, "goals/evaluate", userID, goalID, paramID, exerciseID, baseline)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ids := []interface{}{}
+	for rows.Next() {
+		var id uuid.UUID
+		var status string
+		if err := rows.Scan(&id, &status); err != nil {
+			return nil, err
+		}
+		if status == "ACHIEVED" {
+			ids = append(ids, id)
+		}
+	}
+	return ids, rows.Err()
+}
+
+// GetGoal marks the goal MISSED first when its deadline passed
+func GetGoal(ctx context.Context, id uuid.UUID) (*Goal, error) {
+	g := new(Goal)
+	if err := database.Fetch(g, id); err != nil {
+		return nil, err
+	}
+	if g.Status != "ACTIVE" || g.Deadline == nil {
+		return g, nil
+	}
+	if err := expireGoals(ctx, g.UserID); err != nil {
+		return nil, err
+	}
+	if err := database.Fetch(g, id); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// GetGoals lists the goals of the user by status then deadline, status filters them when set
+func GetGoals(ctx context.Context, userID uuid.UUID, status *string, limit, offset int) ([]Goal, int, error) {
+	if err := expireGoals(ctx, userID); err != nil {
+		return nil, 0, err
+	}
+	list := []database.FetchList{}
+	if err := database.QuerySelect("goals/list", &list, userID, status, limit, offset); err != nil {
+		return nil, 0, err
+	}
+	return orderByIDs(list, func(g Goal) uuid.UUID { return g.ID })
+}
+
+func expireGoals(ctx context.Context, userID uuid.UUID) error {
+	rows, err := database.Query(ctx, "goals/expire", userID)
+	if err != nil {
+		return err
+	}
+	return rows.Close()
+}
@@ -0,0 +1,315 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	database "github.com/socious-io/pkg_database"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Visibility of exercises and plans, ORG shares them with the members of their organization
+const (
+	VisibilityPrivate = "PRIVATE"
+	VisibilityOrg     = "ORG"
+	VisibilityPublic  = "PUBLIC"
+)
+
+// OrganizationRoles are the roles organization managers can give to members
+var OrganizationRoles = []string{"manager", "coach", "athlete"}
+
+// Organization members are the users holding roles scoped to it, UserID is the creator
+type Organization struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	Name        string     `json:"name" db:"name"`
+	Description *string    `json:"description" db:"description"`
+	UserID      *uuid.UUID `json:"user_id" db:"user_id"`
+	Members     int        `json:"members" db:"members"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+type OrganizationMember struct {
+	ID        uuid.UUID      `json:"id" db:"id"`
+	Username  string         `json:"username" db:"username"`
+	FirstName *string        `json:"first_name" db:"first_name"`
+	LastName  *string        `json:"last_name" db:"last_name"`
+	Email     *string        `json:"email" db:"email"`
+	AvatarID  *uuid.UUID     `json:"avatar_id" db:"avatar_id"`
+	Roles     pq.StringArray `json:"roles" db:"roles"`
+	JoinedAt  time.Time      `json:"joined_at" db:"joined_at"`
+}
+
+// OrganizationInvite asks Email to join the organization, the user behind it becomes a member with Role on accept
+type OrganizationInvite struct {
+	ID               uuid.UUID  `json:"id" db:"id"`
+	OrganizationID   uuid.UUID  `json:"organization_id" db:"organization_id"`
+	OrganizationName string     `json:"organization_name" db:"organization_name"`
+	Email            string     `json:"email" db:"email"`
+	Role             string     `json:"role" db:"role"`
+	InvitedBy        *uuid.UUID `json:"invited_by" db:"invited_by"`
+	Status           string     `json:"status" db:"status"`
+	RespondedAt      *time.Time `json:"responded_at" db:"responded_at"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+func (*OrganizationInvite) TableName() string {
+	return "organization_invites"
+}
+
+func (*OrganizationInvite) FetchQuery() string {
+	return "organizations/fetch_invite"
+}
+
+func (*Organization) TableName() string {
+	return "organizations"
+}
+
+func (*Organization) FetchQuery() string {
+	return "organizations/fetch"
+}
+
+// Create writes the organization and makes the creator its manager
+func (o *Organization) Create(ctx context.Context) error {
+	tx, err := database.GetDB().Beginx()
+	if err != nil {
+		return err
+	}
+	rows, err := database.TxQuery(ctx, tx, "organizations/create", o.Name, o.Description, o.UserID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	for rows.Next() {
+		if err := rows.Scan(&o.ID); err != nil {
+			rows.Close()
+			tx.Rollback()
+			return err
+		}
+	}
+	rows.Close()
+
+	rows, err = database.TxQuery(ctx, tx, "organizations/set_member_role", o.UserID, o.ID, "manager", o.UserID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	rows.Close()
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return database.Fetch(o, o.ID)
+}
+
+func (o *Organization) Update(ctx context.Context) error {
+	if err := queryOne(ctx, "organizations/update", o.ID, o.Name, o.Description); err != nil {
+		return err
+	}
+	return database.Fetch(o, o.ID)
+}
+
+// Delete removes the organization with its roles, content shared with it turns private to its owner
+func (o *Organization) Delete(ctx context.Context) error {
+	return queryOne(ctx, "organizations/delete", o.ID)
+}
+
+// SetMemberRole adds the user to the organization or replaces their roles in it with role
+func (o *Organization) SetMemberRole(ctx context.Context, userID uuid.UUID, role string, grantedBy uuid.UUID) error {
+	return queryOne(ctx, "organizations/set_member_role", userID, o.ID, role, grantedBy)
+}
+
+// Invite asks email to join with role, inviting the same email again while the invite is pending replaces its role
+func (o *Organization) Invite(ctx context.Context, email, role string, invitedBy uuid.UUID) (*OrganizationInvite, error) {
+	invite := new(OrganizationInvite)
+	rows, err := database.Query(ctx, "organizations/invite", o.ID, email, role, invitedBy)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		if err := rows.Scan(&invite.ID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+	}
+	rows.Close()
+	return invite, database.Fetch(invite, invite.ID)
+}
+
+// Accept gives the invited role to the user, the invite has to be addressed to their email
+func (i *OrganizationInvite) Accept(ctx context.Context, u *User) error {
+	if u.Email == nil {
+		return ErrInviteInvalid
+	}
+	tx, err := database.GetDB().Beginx()
+	if err != nil {
+		return err
+	}
+	rows, err := database.TxQuery(ctx, tx, "organizations/accept_invite", i.ID, *u.Email)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	accepted := rows.Next()
+	rows.Close()
+	if !accepted {
+		tx.Rollback()
+		return ErrInviteInvalid
+	}
+
+	rows, err = database.TxQuery(ctx, tx, "organizations/set_member_role", u.ID, i.OrganizationID, i.Role, i.InvitedBy)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	rows.Close()
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return database.Fetch(i, i.ID)
+}
+
+func (i *OrganizationInvite) Decline(ctx context.Context, u *User) error {
+	if u.Email == nil {
+		return ErrInviteInvalid
+	}
+	if err := queryOne(ctx, "organizations/decline_invite", i.ID, *u.Email); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrInviteInvalid
+		}
+		return err
+	}
+	return database.Fetch(i, i.ID)
+}
+
+func (o *Organization) RemoveMember(ctx context.Context, userID uuid.UUID) error {
+	return queryOne(ctx, "organizations/remove_member", userID, o.ID)
+}
+
+// IsLastManager reports whether the user is the only member holding the manager role
+func (o *Organization) IsLastManager(userID uuid.UUID) (bool, error) {
+	result := struct {
+		Manager bool `db:"manager"`
+		Others  int  `db:"others"`
+	}{}
+	if err := database.Get(&result, "organizations/managers", o.ID, userID); err != nil {
+		return false, err
+	}
+	return result.Manager && result.Others < 1, nil
+}
+
+func (o *Organization) GetMembers(limit, offset int) ([]OrganizationMember, int, error) {
+	rows := []struct {
+		OrganizationMember
+		TotalCount int `db:"total_count"`
+	}{}
+	if err := database.QuerySelect("organizations/members", &rows, o.ID, limit, offset); err != nil {
+		return nil, 0, err
+	}
+	members := make([]OrganizationMember, len(rows))
+	total := 0
+	for i, r := range rows {
+		members[i] = r.OrganizationMember
+		total = r.TotalCount
+	}
+	return members, total, nil
+}
+
+func GetOrganizationInvite(id uuid.UUID) (*OrganizationInvite, error) {
+	i := new(OrganizationInvite)
+	if err := database.Fetch(i, id); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+// GetReceivedOrganizationInvites lists the pending invites addressed to email, latest first
+func GetReceivedOrganizationInvites(email string, limit, offset int) ([]OrganizationInvite, int, error) {
+	rows := []struct {
+		OrganizationInvite
+		TotalCount int `db:"total_count"`
+	}{}
+	if err := database.QuerySelect("organizations/received_invites", &rows, email, limit, offset); err != nil {
+		return nil, 0, err
+	}
+	invites := make([]OrganizationInvite, len(rows))
+	total := 0
+	for i, r := range rows {
+		invites[i] = r.OrganizationInvite
+		total = r.TotalCount
+	}
+	return invites, total, nil
+}
+
+func GetOrganization(id uuid.UUID) (*Organization, error) {
+	o := new(Organization)
+	if err := database.Fetch(o, id); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// GetOrganizations lists the organizations the user is a member of by name
+func GetOrganizations(userID uuid.UUID, limit, offset int) ([]Organization, int, error) {
+	list := []database.FetchList{}
+	if err := database.QuerySelect("organizations/list", &list, userID, limit, offset); err != nil {
+		return nil, 0, err
+	}
+	return orderByIDs(list, func(o Organization) uuid.UUID { return o.ID })
+}
+
+func IsOrganizationMember(userID, organizationID uuid.UUID) (bool, error) {
+	result := struct {
+		Member bool `db:"member"`
+	}{}
+	if err := database.Get(&result, "organizations/is_member", userID, organizationID); err != nil {
+		return false, err
+	}
+	return result.Member, nil
+}
+
+// HasOrganizationPermission only checks roles scoped to the organization, unlike HasPermission global roles
+// don't count except for platform admins
+func HasOrganizationPermission(userID, organizationID uuid.UUID, permission string) (bool, error) {
+	result := struct {
+		Allowed bool `db:"allowed"`
+	}{}
+	if err := database.Get(&result, "roles/has_organization_permission", userID, organizationID, permission); err != nil {
+		return false, err
+	}
+	return result.Allowed, nil
+}
+
+// CanView reports whether the user sees content owned by ownerID, public content is seen by everyone
+// and ORG content by the members of its organization
+func CanView(userID uuid.UUID, ownerID, organizationID *uuid.UUID, visibility string) (bool, error) {
+	if visibility == VisibilityPublic || (ownerID != nil && *ownerID == userID) {
+		return true, nil
+	}
+	if visibility != VisibilityOrg || organizationID == nil {
+		return false, nil
+	}
+	return IsOrganizationMember(userID, *organizationID)
+}
+
+// CanEdit reports whether the user changes content owned by ownerID, besides the owner members allowed
+// to update the library of the organization it is shared with can
+func CanEdit(userID uuid.UUID, ownerID, organizationID *uuid.UUID, visibility string) (bool, error) {
+	if ownerID != nil && *ownerID == userID {
+		return true, nil
+	}
+	if visibility == VisibilityPrivate || organizationID == nil {
+		return false, nil
+	}
+	return HasOrganizationPermission(userID, *organizationID, "library:update")
+}
+
+// IsVisibility checks the value is one of the visibility levels
+func IsVisibility(value string) bool {
+	return value == VisibilityPrivate || value == VisibilityOrg || value == VisibilityPublic
+}
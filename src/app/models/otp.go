@@ -17,6 +17,7 @@ type OTP struct {
 	UserID     uuid.UUID `db:"user_id" json:"user_id"`
 	Code       int       `db:"code" json:"code"`
 	Perpose    string    `db:"perpose" json:"perpose"`
+	Target     *string   `db:"target" json:"target"`
 	IsVerified bool      `db:"is_verified" json:"is_verified"`
 	ExpiresAt  time.Time `db:"expired_at" json:"expired_at"`
 	CreatedAt  time.Time `db:"created_at" json:"created_at"`
@@ -39,7 +40,7 @@ func (o *OTP) Create(ctx context.Context) error {
 	rows, err := database.Query(
 		ctx,
 		"otp/create",
-		o.UserID, o.Code, o.Perpose,
+		o.UserID, o.Code, o.Perpose, o.Target,
 	)
 	if err != nil {
 		return err
@@ -73,6 +74,27 @@ func (o *OTP) Verify(ctx context.Context) error {
 	return nil
 }
 
+// VerifyPerpose only accepts unexpired codes issued for the OTP perpose
+func (o *OTP) VerifyPerpose(ctx context.Context) error {
+	rows, err := database.Query(
+		ctx,
+		"otp/verify_perpose",
+		o.UserID, o.Code, o.Perpose,
+	)
+
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := o.Scan(rows); err != nil {
+			return err
+		}
+
+	}
+	return nil
+}
+
 func NewOTP(ctx context.Context, userID uuid.UUID, perpose string) (*OTP, error) {
 	o := &OTP{
 		UserID:  userID,
@@ -86,6 +108,21 @@ func NewOTP(ctx context.Context, userID uuid.UUID, perpose string) (*OTP, error)
 	return o, nil
 }
 
+// NewTargetOTP creates an OTP bound to a new email/phone which is applied after confirmation
+func NewTargetOTP(ctx context.Context, userID uuid.UUID, perpose, target string) (*OTP, error) {
+	o := &OTP{
+		UserID:  userID,
+		Code:    int(100000 + rand.Float64()*900000),
+		Perpose: perpose,
+		Target:  &target,
+	}
+	if err := o.Create(ctx); err != nil {
+		return nil, err
+	}
+	log.Printf("OTP generated %d for %s \n", o.Code, target)
+	return o, nil
+}
+
 func GetOTPByUserID(user_id uuid.UUID) (*OTP, error) {
 	o := new(OTP)
 	if err := database.Get(o, "otp/fetch_by_userid", user_id); err != nil {
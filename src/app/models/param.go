@@ -0,0 +1,166 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	database "github.com/socious-io/pkg_database"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// TrendBuckets are the periods param logs are grouped by
+var TrendBuckets = []string{"day", "week", "month"}
+
+type Param struct {
+	ID              uuid.UUID      `json:"id" db:"id"`
+	Name            string         `json:"name" db:"name"`
+	Description     *string        `json:"description" db:"description"`
+	Unit            string         `json:"unit" db:"unit"`
+	Side            string         `json:"side" db:"side"`
+	AvailableSports pq.StringArray `json:"available_sports" db:"available_sports"`
+	UpdatedAt       time.Time      `json:"updated_at" db:"updated_at"`
+	CreatedAt       time.Time      `json:"created_at" db:"created_at"`
+}
+
+type ParamLog struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	UserID    uuid.UUID `json:"user_id" db:"user_id"`
+	ParamID   uuid.UUID `json:"param_id" db:"param_id"`
+	Side      string    `json:"side" db:"side"`
+	Value     float64   `json:"value" db:"value"`
+	Note      *string   `json:"note" db:"note"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+
+	// Goals are the goals of the user this log achieved, only filled when it's created
+	Goals []Goal `json:"-" db:"-"`
+}
+
+// TrendBucket is a period of logs of one side. MovingAverage weights every log of the bucket and the
+// previous ones in the window alike, Change and RatePerDay compare the average with the previous bucket
+// of the same side and are nil on the first one.
+type TrendBucket struct {
+	Bucket        time.Time `json:"bucket" db:"bucket"`
+	Side          string    `json:"side" db:"side"`
+	Count         int       `json:"count" db:"count"`
+	Min           float64   `json:"min" db:"min"`
+	Max           float64   `json:"max" db:"max"`
+	Average       float64   `json:"average" db:"average"`
+	MovingAverage float64   `json:"moving_average" db:"moving_average"`
+	Change        *float64  `json:"change" db:"change"`
+	RatePerDay    *float64  `json:"rate_per_day" db:"rate_per_day"`
+}
+
+// TrendSummary covers the whole window of one side, RatePerDay is the slope of the least squares line of the logs
+type TrendSummary struct {
+	Side       string  `json:"side" db:"side"`
+	Count      int     `json:"count" db:"count"`
+	Min        float64 `json:"min" db:"min"`
+	Max        float64 `json:"max" db:"max"`
+	Average    float64 `json:"average" db:"average"`
+	First      float64 `json:"first" db:"first"`
+	Last       float64 `json:"last" db:"last"`
+	RatePerDay float64 `json:"rate_per_day" db:"rate_per_day"`
+}
+
+// Asymmetry compares the LEFT and RIGHT averages of buckets logged on both sides,
+// Asymmetry is their difference in percent of the larger one, positive when the left side is ahead
+type Asymmetry struct {
+	Bucket     time.Time `json:"bucket" db:"bucket"`
+	Left       float64   `json:"left" db:"left"`
+	Right      float64   `json:"right" db:"right"`
+	Difference float64   `json:"difference" db:"difference"`
+	Asymmetry  float64   `json:"asymmetry" db:"asymmetry"`
+}
+
+type ParamTrend struct {
+	Param     *Param         `json:"param"`
+	UserID    uuid.UUID      `json:"user_id"`
+	Bucket    string         `json:"bucket"`
+	Window    int            `json:"window"`
+	From      time.Time      `json:"from"`
+	To        time.Time      `json:"to"`
+	Summary   []TrendSummary `json:"summary"`
+	Buckets   []TrendBucket  `json:"buckets"`
+	Asymmetry []Asymmetry    `json:"asymmetry"`
+}
+
+func (*Param) TableName() string {
+	return "params"
+}
+
+func (*Param) FetchQuery() string {
+	return "params/fetch"
+}
+
+func GetParam(id uuid.UUID) (*Param, error) {
+	p := new(Param)
+	if err := database.Fetch(p, id); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Create logs the value and evaluates the goals of the user on the param with it
+func (l *ParamLog) Create(ctx context.Context) error {
+	tx, err := database.GetDB().Beginx()
+	if err != nil {
+		return err
+	}
+	rows, err := database.TxQuery(ctx, tx, "params/create_log", l.UserID, l.ParamID, l.Side, l.Value, l.Note)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	for rows.Next() {
+		if err := rows.StructScan(l); err != nil {
+			rows.Close()
+			tx.Rollback()
+			return err
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	achieved, err := evaluateGoals(ctx, tx, l.UserID, nil, &l.ParamID, nil, false)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	l.Goals, err = fetchByIDs[Goal](achieved...)
+	return err
+}
+
+// GetParamTrend analyses the logs of the user from until to, the moving average spans window buckets
+func GetParamTrend(param *Param, userID uuid.UUID, bucket string, window int, from, to time.Time) (*ParamTrend, error) {
+	t := &ParamTrend{
+		Param:     param,
+		UserID:    userID,
+		Bucket:    bucket,
+		Window:    window,
+		From:      from,
+		To:        to,
+		Summary:   []TrendSummary{},
+		Buckets:   []TrendBucket{},
+		Asymmetry: []Asymmetry{},
+	}
+	movingWindow := fmt.Sprintf("%d %s", window-1, bucket)
+	if err := database.QuerySelect("params/trend", &t.Buckets, param.ID, userID, bucket, from, to, movingWindow); err != nil {
+		return nil, err
+	}
+	if err := database.QuerySelect("params/trend_summary", &t.Summary, param.ID, userID, from, to); err != nil {
+		return nil, err
+	}
+	if err := database.QuerySelect("params/asymmetry", &t.Asymmetry, param.ID, userID, bucket, from, to); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
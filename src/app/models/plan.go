@@ -0,0 +1,633 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	database "github.com/socious-io/pkg_database"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/types"
+)
+
+// Plan Public is kept for older clients, it is true for PUBLIC plans
+type Plan struct {
+	ID          uuid.UUID   `json:"id" db:"id"`
+	UserID      uuid.UUID   `json:"user_id" db:"user_id"`
+	Name        string      `json:"name" db:"name"`
+	Description *string     `json:"description" db:"description"`
+	Language    string      `json:"language" db:"language"`
+	Groups      []PlanGroup `json:"groups" db:"-"`
+	CreatedAt   time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at" db:"updated_at"`
+
+	ForkedFrom *uuid.UUID `json:"forked_from" db:"forked_from"`
+	Forks      int        `json:"forks" db:"forks"`
+
+	Visibility     string     `json:"visibility" db:"visibility"`
+	OrganizationID *uuid.UUID `json:"organization_id" db:"organization_id"`
+	Public         bool       `json:"public" db:"public"`
+
+	GroupsJson   types.JSONText `db:"groups" json:"-"`
+	SearchVector string         `db:"search_vector" json:"-"`
+}
+
+// PlanGroup is a single exercise, superset or circuit performed for Rounds rounds with RestTime between rounds
+type PlanGroup struct {
+	ID         uuid.UUID      `json:"id" db:"id"`
+	PlanID     uuid.UUID      `json:"plan_id" db:"plan_id"`
+	GroupOrder int            `json:"group_order" db:"group_order"`
+	GroupType  string         `json:"group_type" db:"group_type"`
+	Name       *string        `json:"name" db:"name"`
+	Rounds     int            `json:"rounds" db:"rounds"`
+	RestTime   time.Duration  `json:"rest_time" db:"rest_time"`
+	Exercises  []PlanExercise `json:"exercises" db:"-"`
+	CreatedAt  time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// PlanExercise RestTime is the rest after the exercise before the next one in the group
+type PlanExercise struct {
+	ID            uuid.UUID     `json:"id" db:"id"`
+	PlanID        uuid.UUID     `json:"plan_id" db:"plan_id"`
+	GroupID       uuid.UUID     `json:"group_id" db:"group_id"`
+	ExerciseID    uuid.UUID     `json:"exercise_id" db:"exercise_id"`
+	ExerciseOrder int           `json:"exercise_order" db:"exercise_order"`
+	RestTime      time.Duration `json:"rest_time" db:"rest_time"`
+	Notes         *string       `json:"notes" db:"notes"`
+	Exercise      *Exercise     `json:"exercise,omitempty" db:"-"`
+	CreatedAt     time.Time     `json:"created_at" db:"created_at"`
+}
+
+type PlanAssignee struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	PlanID    uuid.UUID  `json:"plan_id" db:"plan_id"`
+	UserID    uuid.UUID  `json:"user_id" db:"user_id"`
+	DueAt     *time.Time `json:"due_at" db:"due_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+func (*Plan) TableName() string {
+	return "plans"
+}
+
+func (*Plan) FetchQuery() string {
+	return "plans/fetch"
+}
+
+// Create writes the plan with its groups, exercises of the groups without an ExerciseID are created from Exercise first
+func (p *Plan) Create(ctx context.Context) error {
+	tx, err := database.GetDB().Beginx()
+	if err != nil {
+		return err
+	}
+	if p.Visibility == "" {
+		p.Visibility = VisibilityPrivate
+	}
+	rows, err := database.TxQuery(ctx, tx, "plans/create", p.UserID, p.Name, p.Description, p.Visibility, p.Language, p.OrganizationID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	for rows.Next() {
+		if err := rows.StructScan(p); err != nil {
+			rows.Close()
+			tx.Rollback()
+			return err
+		}
+	}
+	rows.Close()
+
+	for i := range p.Groups {
+		g := &p.Groups[i]
+		g.PlanID = p.ID
+		for j := range g.Exercises {
+			pe := &g.Exercises[j]
+			if pe.ExerciseID != uuid.Nil || pe.Exercise == nil {
+				continue
+			}
+			if err := pe.Exercise.insert(ctx, tx); err != nil {
+				tx.Rollback()
+				return err
+			}
+			pe.ExerciseID = pe.Exercise.ID
+		}
+		if err := g.insert(ctx, tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	rows, err = database.TxQuery(ctx, tx, "plans/create_revision", p.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	rows.Close()
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return database.Fetch(p, p.ID)
+}
+
+// Validate checks the plan details, language defaults to english
+func (p *Plan) Validate() error {
+	if p.Name == "" {
+		return errors.New("name is required")
+	}
+	var err error
+	p.Language, err = SearchLanguage(p.Language)
+	return err
+}
+
+// Update overwrites the plan details and records a new revision
+func (p *Plan) Update(ctx context.Context) error {
+	tx, err := database.GetDB().Beginx()
+	if err != nil {
+		return err
+	}
+	rows, err := database.TxQuery(ctx, tx, "plans/update", p.ID, p.Name, p.Description, p.Visibility, p.Language, p.OrganizationID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	for rows.Next() {
+		if err := rows.StructScan(p); err != nil {
+			rows.Close()
+			tx.Rollback()
+			return err
+		}
+	}
+	rows.Close()
+
+	rows, err = database.TxQuery(ctx, tx, "plans/create_revision", p.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	rows.Close()
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return database.Fetch(p, p.ID)
+}
+
+// Delete removes the plan with its assignments and the comments left on them
+func (p *Plan) Delete(ctx context.Context) error {
+	return queryOne(ctx, "plans/delete", p.ID)
+}
+
+func (p *Plan) Unpublish(ctx context.Context) error {
+	return queryOne(ctx, "plans/unpublish", p.ID)
+}
+
+// Fork copies the plan with its groups into a private plan owned by userID,
+// every exercise of the plan is forked with its sets so later changes to the source don't leak into the fork
+func (p *Plan) Fork(ctx context.Context, userID uuid.UUID) (*Plan, error) {
+	tx, err := database.GetDB().Beginx()
+	if err != nil {
+		return nil, err
+	}
+	fork := new(Plan)
+	rows, err := database.TxQuery(ctx, tx, "plans/fork", p.ID, userID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	for rows.Next() {
+		if err := rows.StructScan(fork); err != nil {
+			rows.Close()
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	rows.Close()
+
+	exercises := map[uuid.UUID]uuid.UUID{}
+	for _, source := range p.Groups {
+		g := &PlanGroup{
+			PlanID:     fork.ID,
+			GroupOrder: source.GroupOrder,
+			GroupType:  source.GroupType,
+			Name:       source.Name,
+			Rounds:     source.Rounds,
+			RestTime:   source.RestTime,
+		}
+		for _, pe := range source.Exercises {
+			exerciseID, ok := exercises[pe.ExerciseID]
+			if !ok {
+				e, err := forkExercise(ctx, tx, pe.ExerciseID, userID)
+				if err != nil {
+					tx.Rollback()
+					return nil, err
+				}
+				exerciseID = e.ID
+				exercises[pe.ExerciseID] = exerciseID
+			}
+			g.Exercises = append(g.Exercises, PlanExercise{ExerciseID: exerciseID, RestTime: pe.RestTime, Notes: pe.Notes})
+		}
+		if err := g.insert(ctx, tx); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	rows, err = database.TxQuery(ctx, tx, "plans/create_revision", fork.ID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	rows.Close()
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	if err := database.Fetch(fork, fork.ID); err != nil {
+		return nil, err
+	}
+	return fork, nil
+}
+
+// RemoveExercise drops the exercise from every group of the plan, groups left empty are deleted and
+// supersets or circuits left with a single exercise become SINGLE groups
+func (p *Plan) RemoveExercise(ctx context.Context, exerciseID uuid.UUID) error {
+	tx, err := database.GetDB().Beginx()
+	if err != nil {
+		return err
+	}
+	if err := txQueryOne(ctx, tx, "plans/remove_exercise", p.ID, exerciseID); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := txQueryOne(ctx, tx, "plans/create_revision", p.ID); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Restore brings the details and groups of the revision back as a new revision, visibility is kept as it is
+func (p *Plan) Restore(ctx context.Context, r *PlanRevision) error {
+	if r.Snapshot == nil {
+		return errors.New("revision has no snapshot")
+	}
+	tx, err := database.GetDB().Beginx()
+	if err != nil {
+		return err
+	}
+	rows, err := database.TxQuery(
+		ctx,
+		tx,
+		"plans/update",
+		p.ID, r.Snapshot.Name, r.Snapshot.Description, p.Visibility, r.Snapshot.Language, p.OrganizationID,
+	)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	rows.Close()
+
+	rows, err = database.TxQuery(ctx, tx, "plans/delete_groups", p.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	rows.Close()
+
+	for _, source := range r.Snapshot.Groups {
+		g := &PlanGroup{
+			PlanID:     p.ID,
+			GroupOrder: source.GroupOrder,
+			GroupType:  source.GroupType,
+			Name:       source.Name,
+			Rounds:     source.Rounds,
+			RestTime:   source.RestTime,
+		}
+		for _, pe := range source.Exercises {
+			g.Exercises = append(g.Exercises, PlanExercise{ExerciseID: pe.ExerciseID, RestTime: pe.RestTime, Notes: pe.Notes})
+		}
+		if err := g.insert(ctx, tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	rows, err = database.TxQuery(ctx, tx, "plans/create_revision", p.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	rows.Close()
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return database.Fetch(p, p.ID)
+}
+
+// Exercises flattens the groups in the order they are performed
+func (p *Plan) Exercises() []PlanExercise {
+	exercises := []PlanExercise{}
+	for _, g := range p.Groups {
+		exercises = append(exercises, g.Exercises...)
+	}
+	return exercises
+}
+
+func (p *Plan) Group(id uuid.UUID) *PlanGroup {
+	for i := range p.Groups {
+		if p.Groups[i].ID == id {
+			return &p.Groups[i]
+		}
+	}
+	return nil
+}
+
+func GetPlan(id uuid.UUID) (*Plan, error) {
+	p := new(Plan)
+	if err := database.Fetch(p, id); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetPlans lists own, public and organization shared plans, visibility and organizationID narrow it down when set
+func GetPlans(userID uuid.UUID, visibility *string, organizationID *uuid.UUID, limit, offset int) ([]Plan, int, error) {
+	list := []database.FetchList{}
+	if err := database.QuerySelect("plans/list", &list, userID, visibility, limit, offset, organizationID); err != nil {
+		return nil, 0, err
+	}
+	return orderByIDs(list, func(p Plan) uuid.UUID { return p.ID })
+}
+
+// GetPlansByID fetches the plans keyed by id, missing ids are left out
+func GetPlansByID(ids ...uuid.UUID) (map[uuid.UUID]*Plan, error) {
+	byID := map[uuid.UUID]*Plan{}
+	if len(ids) < 1 {
+		return byID, nil
+	}
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	plans, err := fetchByIDs[Plan](args...)
+	if err != nil {
+		return nil, err
+	}
+	for i := range plans {
+		byID[plans[i].ID] = &plans[i]
+	}
+	return byID, nil
+}
+
+// Validate checks the group shape, type defaults to SINGLE and rounds to one when missing
+func (g *PlanGroup) Validate() error {
+	if g.GroupType == "" {
+		g.GroupType = "SINGLE"
+	}
+	if g.Rounds == 0 {
+		g.Rounds = 1
+	}
+	switch g.GroupType {
+	case "SINGLE":
+		if len(g.Exercises) != 1 {
+			return errors.New("SINGLE groups must have exactly one exercise")
+		}
+	case "SUPERSET", "CIRCUIT":
+		if len(g.Exercises) < 2 {
+			return errors.New("SUPERSET and CIRCUIT groups must have at least two exercises")
+		}
+	default:
+		return errors.New("group_type must be SINGLE, SUPERSET or CIRCUIT")
+	}
+	if g.Rounds < 1 {
+		return errors.New("rounds must be positive")
+	}
+	if g.Rounds > MaxRounds {
+		return fmt.Errorf("rounds can not be more than %d", MaxRounds)
+	}
+	if g.RestTime < 0 {
+		return errors.New("rest_time can not be negative")
+	}
+	for _, pe := range g.Exercises {
+		if pe.RestTime < 0 {
+			return errors.New("exercise rest_time can not be negative")
+		}
+	}
+	return nil
+}
+
+func (g *PlanGroup) Create(ctx context.Context) error {
+	tx, err := database.GetDB().Beginx()
+	if err != nil {
+		return err
+	}
+	if err := g.insert(ctx, tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := g.createRevision(ctx, tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (g *PlanGroup) insert(ctx context.Context, tx *sqlx.Tx) error {
+	rows, err := database.TxQuery(
+		ctx,
+		tx,
+		"plans/create_group",
+		g.PlanID, g.groupOrder(), g.GroupType, g.Name, g.Rounds, g.RestTime,
+	)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		if err := rows.StructScan(g); err != nil {
+			rows.Close()
+			return err
+		}
+	}
+	rows.Close()
+	return g.createExercises(tx)
+}
+
+// Update replaces the group settings and its exercises
+func (g *PlanGroup) Update(ctx context.Context) error {
+	tx, err := database.GetDB().Beginx()
+	if err != nil {
+		return err
+	}
+	rows, err := database.TxQuery(
+		ctx,
+		tx,
+		"plans/update_group",
+		g.ID, g.groupOrder(), g.GroupType, g.Name, g.Rounds, g.RestTime,
+	)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	for rows.Next() {
+		if err := rows.StructScan(g); err != nil {
+			rows.Close()
+			tx.Rollback()
+			return err
+		}
+	}
+	rows.Close()
+
+	rows, err = database.TxQuery(ctx, tx, "plans/delete_group_exercises", g.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	rows.Close()
+
+	if err := g.createExercises(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := g.createRevision(ctx, tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (g *PlanGroup) Delete(ctx context.Context) error {
+	tx, err := database.GetDB().Beginx()
+	if err != nil {
+		return err
+	}
+	if err := txQueryOne(ctx, tx, "plans/delete_group", g.ID); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := g.createRevision(ctx, tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (g *PlanGroup) createRevision(ctx context.Context, tx *sqlx.Tx) error {
+	rows, err := database.TxQuery(ctx, tx, "plans/create_revision", g.PlanID)
+	if err != nil {
+		return err
+	}
+	return rows.Close()
+}
+
+func (g *PlanGroup) createExercises(tx *sqlx.Tx) error {
+	for i := range g.Exercises {
+		g.Exercises[i].PlanID = g.PlanID
+		g.Exercises[i].GroupID = g.ID
+		g.Exercises[i].ExerciseOrder = i + 1
+	}
+	if len(g.Exercises) < 1 {
+		return nil
+	}
+	_, err := database.TxExecuteQuery(tx, "plans/create_exercises", g.Exercises)
+	return err
+}
+
+func (g *PlanGroup) groupOrder() *int {
+	if g.GroupOrder < 1 {
+		return nil
+	}
+	return &g.GroupOrder
+}
+
+// Create appends the exercise to its group, or places it at ExerciseOrder when set
+func (pe *PlanExercise) Create(ctx context.Context) error {
+	var order *int
+	if pe.ExerciseOrder > 0 {
+		order = &pe.ExerciseOrder
+	}
+	tx, err := database.GetDB().Beginx()
+	if err != nil {
+		return err
+	}
+	rows, err := database.TxQuery(
+		ctx,
+		tx,
+		"plans/add_exercise",
+		pe.PlanID, pe.GroupID, pe.ExerciseID, order, pe.RestTime, pe.Notes,
+	)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	for rows.Next() {
+		if err := rows.StructScan(pe); err != nil {
+			rows.Close()
+			tx.Rollback()
+			return err
+		}
+	}
+	rows.Close()
+	if err := txQueryOne(ctx, tx, "plans/create_revision", pe.PlanID); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (*PlanAssignee) TableName() string {
+	return "plan_assignees"
+}
+
+func (*PlanAssignee) FetchQuery() string {
+	return "plans/fetch_assignees"
+}
+
+func GetPlanAssignee(id uuid.UUID) (*PlanAssignee, error) {
+	pa := new(PlanAssignee)
+	if err := database.Fetch(pa, id); err != nil {
+		return nil, err
+	}
+	return pa, nil
+}
+
+// IsPlanAssignee reports whether the plan is assigned to the user
+func IsPlanAssignee(planID, userID uuid.UUID) (bool, error) {
+	result := struct {
+		Assignee bool `db:"assignee"`
+	}{}
+	if err := database.Get(&result, "plans/is_assignee", planID, userID); err != nil {
+		return false, err
+	}
+	return result.Assignee, nil
+}
+
+// queryOne runs a query expected to return at least one row, sql.ErrNoRows otherwise
+func queryOne(ctx context.Context, queryName string, args ...interface{}) error {
+	rows, err := database.Query(ctx, queryName, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// txQueryOne is queryOne in the transaction
+func txQueryOne(ctx context.Context, tx *sqlx.Tx, queryName string, args ...interface{}) error {
+	rows, err := database.TxQuery(ctx, tx, queryName, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,320 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	database "github.com/socious-io/pkg_database"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/types"
+)
+
+// Programme Public is kept for older clients, it is true for PUBLIC programmes
+type Programme struct {
+	ID             uuid.UUID      `json:"id" db:"id"`
+	UserID         uuid.UUID      `json:"user_id" db:"user_id"`
+	Name           string         `json:"name" db:"name"`
+	Description    *string        `json:"description" db:"description"`
+	Visibility     string         `json:"visibility" db:"visibility"`
+	OrganizationID *uuid.UUID     `json:"organization_id" db:"organization_id"`
+	Public         bool           `json:"public" db:"public"`
+	Weeks          int            `json:"weeks" db:"weeks"`
+	Days           []ProgrammeDay `json:"days" db:"-"`
+	Progressions   []Progression  `json:"progressions" db:"-"`
+	CreatedAt      time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at" db:"updated_at"`
+
+	DaysJson         types.JSONText `db:"days" json:"-"`
+	ProgressionsJson types.JSONText `db:"progressions" json:"-"`
+}
+
+// ProgrammeDay Day is 1 to 7 counted from the start date of the week
+type ProgrammeDay struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	ProgrammeID uuid.UUID `json:"programme_id" db:"programme_id"`
+	Week        int       `json:"week" db:"week"`
+	Day         int       `json:"day" db:"day"`
+	PlanID      uuid.UUID `json:"plan_id" db:"plan_id"`
+	Note        *string   `json:"note" db:"note"`
+}
+
+// Progression adds Amount to the Target of matching sets once per week or per session of the exercise,
+// PlanID and ExerciseID narrow it down when set and Max caps the progressed value
+type Progression struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	ProgrammeID uuid.UUID  `json:"programme_id" db:"programme_id"`
+	PlanID      *uuid.UUID `json:"plan_id" db:"plan_id"`
+	ExerciseID  *uuid.UUID `json:"exercise_id" db:"exercise_id"`
+	Target      string     `json:"target" db:"target"`
+	Amount      float64    `json:"amount" db:"amount"`
+	Per         string     `json:"per" db:"per"`
+	Max         *float64   `json:"max" db:"max"`
+}
+
+type ProgrammeAssignment struct {
+	ID          uuid.UUID          `json:"id" db:"id"`
+	ProgrammeID uuid.UUID          `json:"programme_id" db:"programme_id"`
+	UserID      uuid.UUID          `json:"user_id" db:"user_id"`
+	AssignedBy  *uuid.UUID         `json:"assigned_by" db:"assigned_by"`
+	StartAt     time.Time          `json:"start_at" db:"start_at"`
+	Sessions    []ProgrammeSession `json:"sessions" db:"-"`
+	CreatedAt   time.Time          `json:"created_at" db:"created_at"`
+
+	SessionsJson types.JSONText `db:"sessions" json:"-"`
+}
+
+// ProgrammeSession is a concrete session of a programme, Plan is the snapshot with progressions applied
+type ProgrammeSession struct {
+	ID           uuid.UUID  `json:"id" db:"id"`
+	AssignmentID uuid.UUID  `json:"assignment_id" db:"assignment_id"`
+	PlanID       *uuid.UUID `json:"plan_id" db:"plan_id"`
+	Week         int        `json:"week" db:"week"`
+	Day          int        `json:"day" db:"day"`
+	ScheduledAt  time.Time  `json:"scheduled_at" db:"scheduled_at"`
+	Plan         *Plan      `json:"plan" db:"-"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+
+	PlanJson types.JSONText `db:"plan" json:"-"`
+}
+
+func (*Programme) TableName() string {
+	return "programmes"
+}
+
+func (*Programme) FetchQuery() string {
+	return "programmes/fetch"
+}
+
+// Validate checks the schedule fits in the programme weeks, progressions per default to WEEK
+func (p *Programme) Validate() error {
+	if p.Name == "" {
+		return errors.New("name is required")
+	}
+	if p.Weeks < 1 || p.Weeks > 52 {
+		return errors.New("weeks must be between 1 and 52")
+	}
+	if len(p.Days) < 1 {
+		return errors.New("at least one day is required")
+	}
+	for _, d := range p.Days {
+		if d.Week < 1 || d.Week > p.Weeks {
+			return errors.New("day week must be within the programme weeks")
+		}
+		if d.Day < 1 || d.Day > 7 {
+			return errors.New("day must be between 1 and 7")
+		}
+	}
+	for i := range p.Progressions {
+		pr := &p.Progressions[i]
+		if pr.Per == "" {
+			pr.Per = "WEEK"
+		}
+		if pr.Per != "WEEK" && pr.Per != "SESSION" {
+			return errors.New("progression per must be WEEK or SESSION")
+		}
+		switch pr.Target {
+		case "LOAD", "PERCENT_1RM", "REP_COUNT", "DURATION", "DISTANCE":
+		default:
+			return errors.New("progression target must be LOAD, PERCENT_1RM, REP_COUNT, DURATION or DISTANCE")
+		}
+		if pr.Amount == 0 {
+			return errors.New("progression amount can not be zero")
+		}
+	}
+	return nil
+}
+
+func (p *Programme) Create(ctx context.Context) error {
+	tx, err := database.GetDB().Beginx()
+	if err != nil {
+		return err
+	}
+	rows, err := database.TxQuery(
+		ctx,
+		tx,
+		"programmes/create",
+		p.UserID, p.Name, p.Description, p.Visibility, p.OrganizationID, p.Weeks,
+	)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	for rows.Next() {
+		if err := rows.StructScan(p); err != nil {
+			rows.Close()
+			tx.Rollback()
+			return err
+		}
+	}
+	rows.Close()
+
+	if err := p.createSchedule(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return database.Fetch(p, p.ID)
+}
+
+// Update replaces the programme details, days and progressions
+func (p *Programme) Update(ctx context.Context) error {
+	tx, err := database.GetDB().Beginx()
+	if err != nil {
+		return err
+	}
+	rows, err := database.TxQuery(
+		ctx,
+		tx,
+		"programmes/update",
+		p.ID, p.Name, p.Description, p.Visibility, p.OrganizationID, p.Weeks,
+	)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	for rows.Next() {
+		if err := rows.StructScan(p); err != nil {
+			rows.Close()
+			tx.Rollback()
+			return err
+		}
+	}
+	rows.Close()
+
+	rows, err = database.TxQuery(ctx, tx, "programmes/delete_schedule", p.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	rows.Close()
+
+	if err := p.createSchedule(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return database.Fetch(p, p.ID)
+}
+
+func (p *Programme) Delete(ctx context.Context) error {
+	return queryOne(ctx, "programmes/delete", p.ID)
+}
+
+func (p *Programme) createSchedule(tx *sqlx.Tx) error {
+	for i := range p.Days {
+		p.Days[i].ProgrammeID = p.ID
+	}
+	for i := range p.Progressions {
+		p.Progressions[i].ProgrammeID = p.ID
+	}
+	if len(p.Days) > 0 {
+		if _, err := database.TxExecuteQuery(tx, "programmes/create_days", p.Days); err != nil {
+			return err
+		}
+	}
+	if len(p.Progressions) > 0 {
+		if _, err := database.TxExecuteQuery(tx, "programmes/create_progressions", p.Progressions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PlanIDs returns the distinct plans the programme is built from
+func (p *Programme) PlanIDs() []uuid.UUID {
+	seen := map[uuid.UUID]bool{}
+	ids := []uuid.UUID{}
+	for _, d := range p.Days {
+		if !seen[d.PlanID] {
+			seen[d.PlanID] = true
+			ids = append(ids, d.PlanID)
+		}
+	}
+	return ids
+}
+
+func GetProgramme(id uuid.UUID) (*Programme, error) {
+	p := new(Programme)
+	if err := database.Fetch(p, id); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func GetProgrammes(userID uuid.UUID, limit, offset int) ([]Programme, int, error) {
+	list := []database.FetchList{}
+	if err := database.QuerySelect("programmes/list", &list, userID, limit, offset); err != nil {
+		return nil, 0, err
+	}
+	return orderByIDs(list, func(p Programme) uuid.UUID { return p.ID })
+}
+
+func (*ProgrammeAssignment) TableName() string {
+	return "programme_assignments"
+}
+
+func (*ProgrammeAssignment) FetchQuery() string {
+	return "programmes/fetch_assignments"
+}
+
+// Create stores the assignment together with its generated Sessions
+func (a *ProgrammeAssignment) Create(ctx context.Context) error {
+	tx, err := database.GetDB().Beginx()
+	if err != nil {
+		return err
+	}
+	rows, err := database.TxQuery(
+		ctx,
+		tx,
+		"programmes/create_assignment",
+		a.ProgrammeID, a.UserID, a.AssignedBy, a.StartAt,
+	)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	for rows.Next() {
+		if err := rows.StructScan(a); err != nil {
+			rows.Close()
+			tx.Rollback()
+			return err
+		}
+	}
+	rows.Close()
+
+	for i := range a.Sessions {
+		a.Sessions[i].AssignmentID = a.ID
+		plan, err := json.Marshal(a.Sessions[i].Plan)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		a.Sessions[i].PlanJson = plan
+	}
+	if len(a.Sessions) > 0 {
+		if _, err := database.TxExecuteQuery(tx, "programmes/create_sessions", a.Sessions); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return database.Fetch(a, a.ID)
+}
+
+func GetProgrammeAssignment(id uuid.UUID) (*ProgrammeAssignment, error) {
+	a := new(ProgrammeAssignment)
+	if err := database.Fetch(a, id); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	database "github.com/socious-io/pkg_database"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// RecordKinds MAX_LOAD and E1RM are in kilograms, MAX_REPS counts reps at Load kilograms or more
+// and MAX_DURATION is in seconds
+var RecordKinds = []string{"MAX_LOAD", "MAX_REPS", "E1RM", "MAX_DURATION"}
+
+// PersonalRecord is set by a logged set beating the best value of its kind, PreviousValue is nil for the first one.
+// E1RM estimates the one rep max with Brzycki up to 10 reps and Epley above.
+type PersonalRecord struct {
+	ID            uuid.UUID  `json:"id" db:"id"`
+	UserID        uuid.UUID  `json:"user_id" db:"user_id"`
+	ExerciseID    uuid.UUID  `json:"exercise_id" db:"exercise_id"`
+	ExerciseName  string     `json:"exercise_name" db:"exercise_name"`
+	SetLogID      *uuid.UUID `json:"set_log_id" db:"set_log_id"`
+	Kind          string     `json:"kind" db:"kind"`
+	Value         float64    `json:"value" db:"value"`
+	Unit          string     `json:"unit" db:"unit"`
+	Load          *float64   `json:"load" db:"load"`
+	PreviousValue *float64   `json:"previous_value" db:"previous_value"`
+	AchievedAt    time.Time  `json:"achieved_at" db:"achieved_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+}
+
+// detectRecords stores the records the set log beats, warmup sets and loads in other units than KG or LB set none
+func (l *SetLog) detectRecords(ctx context.Context, tx *sqlx.Tx) error {
+	rows, err := database.TxQuery(ctx, tx, "records/detect", l.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	l.Records = []PersonalRecord{}
+	for rows.Next() {
+		r := PersonalRecord{}
+		if err := rows.StructScan(&r); err != nil {
+			return err
+		}
+		l.Records = append(l.Records, r)
+	}
+	return rows.Err()
+}
+
+// GetPersonalRecords lists the current records of the user, reps records lifted at a lower load
+// than another one with as many reps are left out
+func GetPersonalRecords(userID uuid.UUID, exerciseID *uuid.UUID, kind *string, limit, offset int) ([]PersonalRecord, int, error) {
+	return getPersonalRecords("records/current", userID, exerciseID, kind, limit, offset)
+}
+
+// GetRecordHistory lists every record the user set on the exercise, latest first
+func GetRecordHistory(userID, exerciseID uuid.UUID, kind *string, limit, offset int) ([]PersonalRecord, int, error) {
+	return getPersonalRecords("records/history", userID, exerciseID, kind, limit, offset)
+}
+
+func getPersonalRecords(queryName string, args ...interface{}) ([]PersonalRecord, int, error) {
+	rows := []struct {
+		PersonalRecord
+		TotalCount int `db:"total_count"`
+	}{}
+	if err := database.QuerySelect(queryName, &rows, args...); err != nil {
+		return nil, 0, err
+	}
+	records := make([]PersonalRecord, len(rows))
+	total := 0
+	for i, r := range rows {
+		records[i] = r.PersonalRecord
+		total = r.TotalCount
+	}
+	return records, total, nil
+}
@@ -0,0 +1,163 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	database "github.com/socious-io/pkg_database"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx/types"
+)
+
+// ExerciseRevision is an immutable snapshot of the exercise and its sets taken after every change
+type ExerciseRevision struct {
+	ID         uuid.UUID `json:"id" db:"id"`
+	ExerciseID uuid.UUID `json:"exercise_id" db:"exercise_id"`
+	Revision   int       `json:"revision" db:"revision"`
+	Snapshot   *Exercise `json:"snapshot" db:"-"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+
+	SnapshotJson types.JSONText `db:"snapshot" json:"-"`
+}
+
+// PlanRevision is an immutable snapshot of the plan with its groups and prescribed sets taken after every change
+type PlanRevision struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	PlanID    uuid.UUID `json:"plan_id" db:"plan_id"`
+	Revision  int       `json:"revision" db:"revision"`
+	Snapshot  *Plan     `json:"snapshot" db:"-"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+
+	SnapshotJson types.JSONText `db:"snapshot" json:"-"`
+}
+
+// RevisionChange is a changed value between two snapshots, Path is like sets[1].rep_count
+type RevisionChange struct {
+	Path string      `json:"path"`
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// ids and timestamps change on every save and would drown the actual changes
+var ignoredRevisionKeys = map[string]bool{
+	"id":         true,
+	"group_id":   true,
+	"created_at": true,
+	"updated_at": true,
+}
+
+func (*ExerciseRevision) TableName() string {
+	return "exercise_revisions"
+}
+
+func (*ExerciseRevision) FetchQuery() string {
+	return "exercises/fetch_revisions"
+}
+
+func (*PlanRevision) TableName() string {
+	return "plan_revisions"
+}
+
+func (*PlanRevision) FetchQuery() string {
+	return "plans/fetch_revisions"
+}
+
+func GetExerciseRevision(exerciseID uuid.UUID, revision int) (*ExerciseRevision, error) {
+	r := new(ExerciseRevision)
+	if err := database.Get(r, "exercises/get_revision", exerciseID, revision); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetExerciseRevisions lists the revisions of the exercise, latest first
+func GetExerciseRevisions(exerciseID uuid.UUID, limit, offset int) ([]ExerciseRevision, int, error) {
+	list := []database.FetchList{}
+	if err := database.QuerySelect("exercises/list_revisions", &list, exerciseID, limit, offset); err != nil {
+		return nil, 0, err
+	}
+	return orderByIDs(list, func(er ExerciseRevision) uuid.UUID { return er.ID })
+}
+
+func GetPlanRevision(planID uuid.UUID, revision int) (*PlanRevision, error) {
+	r := new(PlanRevision)
+	if err := database.Get(r, "plans/get_revision", planID, revision); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetPlanRevisions lists the revisions of the plan, latest first
+func GetPlanRevisions(planID uuid.UUID, limit, offset int) ([]PlanRevision, int, error) {
+	list := []database.FetchList{}
+	if err := database.QuerySelect("plans/list_revisions", &list, planID, limit, offset); err != nil {
+		return nil, 0, err
+	}
+	return orderByIDs(list, func(pr PlanRevision) uuid.UUID { return pr.ID })
+}
+
+// DiffSnapshots walks both snapshots and returns every value that differs, ignoring ids and timestamps
+func DiffSnapshots(from, to types.JSONText) ([]RevisionChange, error) {
+	var a, b interface{}
+	if err := json.Unmarshal(from, &a); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(to, &b); err != nil {
+		return nil, err
+	}
+	changes := []RevisionChange{}
+	diffValues("", a, b, &changes)
+	return changes, nil
+}
+
+func diffValues(path string, a, b interface{}, changes *[]RevisionChange) {
+	am, aIsMap := a.(map[string]interface{})
+	bm, bIsMap := b.(map[string]interface{})
+	if aIsMap && bIsMap {
+		keys := []string{}
+		for k := range am {
+			keys = append(keys, k)
+		}
+		for k := range bm {
+			if _, ok := am[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if ignoredRevisionKeys[k] {
+				continue
+			}
+			key := k
+			if path != "" {
+				key = path + "." + k
+			}
+			diffValues(key, am[k], bm[k], changes)
+		}
+		return
+	}
+
+	as, aIsSlice := a.([]interface{})
+	bs, bIsSlice := b.([]interface{})
+	if aIsSlice && bIsSlice {
+		for i := 0; i < len(as) || i < len(bs); i++ {
+			var av, bv interface{}
+			if i < len(as) {
+				av = as[i]
+			}
+			if i < len(bs) {
+				bv = bs[i]
+			}
+			diffValues(fmt.Sprintf("%s[%d]", path, i), av, bv, changes)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		*changes = append(*changes, RevisionChange{Path: path, From: a, To: b})
+	}
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	database "github.com/socious-io/pkg_database"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type Role struct {
+	Name        string         `db:"name" json:"name"`
+	Description *string        `db:"description" json:"description"`
+	Permissions pq.StringArray `db:"permissions" json:"permissions"`
+	CreatedAt   time.Time      `db:"created_at" json:"created_at"`
+}
+
+type UserRole struct {
+	ID             uuid.UUID  `db:"id" json:"id"`
+	UserID         uuid.UUID  `db:"user_id" json:"user_id"`
+	Role           string     `db:"role" json:"role"`
+	OrganizationID *uuid.UUID `db:"organization_id" json:"organization_id"`
+	GrantedBy      *uuid.UUID `db:"granted_by" json:"granted_by"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+}
+
+func (Role) TableName() string {
+	return "roles"
+}
+
+func (Role) FetchQuery() string {
+	return "roles/fetch"
+}
+
+func (UserRole) TableName() string {
+	return "user_roles"
+}
+
+func (UserRole) FetchQuery() string {
+	return "roles/fetch_user_roles"
+}
+
+func (ur *UserRole) Create(ctx context.Context) error {
+	rows, err := database.Query(
+		ctx,
+		"roles/grant",
+		ur.UserID, ur.Role, ur.OrganizationID, ur.GrantedBy,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := rows.StructScan(ur); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (ur *UserRole) Delete(ctx context.Context) error {
+	rows, err := database.Query(
+		ctx,
+		"roles/revoke",
+		ur.UserID, ur.Role, ur.OrganizationID,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	found := false
+	for rows.Next() {
+		found = true
+		if err := rows.StructScan(ur); err != nil {
+			return err
+		}
+	}
+	if !found {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func GetRoles() ([]Role, error) {
+	roles := []Role{}
+	if err := database.QuerySelect("roles/list", &roles); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func GetUserRoles(userID uuid.UUID) ([]UserRole, error) {
+	roles := []UserRole{}
+	if err := database.QuerySelect("roles/fetch_by_user", &roles, userID); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// HasPermission checks global roles of the user and, when organizationID is given, roles scoped to that organization
+func HasPermission(userID uuid.UUID, organizationID *uuid.UUID, permission string) (bool, error) {
+	result := struct {
+		Allowed bool `db:"allowed"`
+	}{}
+	if err := database.Get(&result, "roles/has_permission", userID, organizationID, permission); err != nil {
+		return false, err
+	}
+	return result.Allowed, nil
+}
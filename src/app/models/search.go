@@ -0,0 +1,70 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	database "github.com/socious-io/pkg_database"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// SearchLanguages are the text search configurations exercises and plans can be stemmed with
+var SearchLanguages = []string{
+	"simple", "danish", "dutch", "english", "finnish", "french", "german", "hungarian", "italian",
+	"norwegian", "portuguese", "romanian", "russian", "spanish", "swedish", "turkish",
+}
+
+// SearchResult Headline is the highlighted name and Snippet the best matching fragments of the description
+type SearchResult struct {
+	Type       string     `json:"type" db:"type"`
+	ID         uuid.UUID  `json:"id" db:"id"`
+	UserID     *uuid.UUID `json:"user_id" db:"user_id"`
+	Name       string     `json:"name" db:"name"`
+	Visibility string     `json:"visibility" db:"visibility"`
+	Rank       float64    `json:"rank" db:"rank"`
+	Headline   string     `json:"headline" db:"headline"`
+	Snippet    string     `json:"snippet" db:"snippet"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+// SearchLanguage defaults an empty language to english and checks it's one of the SearchLanguages
+func SearchLanguage(language string) (string, error) {
+	if language == "" {
+		language = "english"
+	}
+	if !IsSearchLanguage(language) {
+		return "", fmt.Errorf("unsupported language %q", language)
+	}
+	return language, nil
+}
+
+func IsSearchLanguage(language string) bool {
+	for _, l := range SearchLanguages {
+		if l == language {
+			return true
+		}
+	}
+	return false
+}
+
+// Search ranks public, own and organization shared exercises and plans against a web search style query,
+// searchType limits it to "exercise" or "plan" when set
+func Search(userID uuid.UUID, query string, searchType *string, limit, offset int) ([]SearchResult, int, error) {
+	rows := []struct {
+		SearchResult
+		TotalCount int `db:"total_count"`
+	}{}
+	// the query is built once per language so the search_vector indexes can be used
+	if err := database.QuerySelect("search/search", &rows, userID, query, searchType, limit, offset, pq.StringArray(SearchLanguages)); err != nil {
+		return nil, 0, err
+	}
+	results := make([]SearchResult, len(rows))
+	total := 0
+	for i, r := range rows {
+		results[i] = r.SearchResult
+		total = r.TotalCount
+	}
+	return results, total, nil
+}
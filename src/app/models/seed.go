@@ -0,0 +1,129 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	database "github.com/socious-io/pkg_database"
+)
+
+// Catalogue is the versioned library of standard exercises, seeded rows are only rewritten when Version grows
+type Catalogue struct {
+	Version   int                 `json:"version"`
+	Exercises []CatalogueExercise `json:"exercises"`
+}
+
+// CatalogueExercise Key is the stable identity of the exercise across catalogue versions
+type CatalogueExercise struct {
+	Key string `json:"key"`
+	Exercise
+}
+
+// LoadCatalogue reads the catalogue file and validates every exercise and set in it
+func LoadCatalogue(path string) (*Catalogue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := new(Catalogue)
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, err
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Catalogue) Validate() error {
+	if c.Version < 1 {
+		return errors.New("catalogue version must be positive")
+	}
+	keys := map[string]bool{}
+	for i := range c.Exercises {
+		ce := &c.Exercises[i]
+		if ce.Key == "" {
+			return fmt.Errorf("exercise %d: key is required", i+1)
+		}
+		if keys[ce.Key] {
+			return fmt.Errorf("exercise %s: duplicated key", ce.Key)
+		}
+		keys[ce.Key] = true
+		if ce.Name == "" {
+			return fmt.Errorf("exercise %s: name is required", ce.Key)
+		}
+		if err := ce.Exercise.Validate(); err != nil {
+			return fmt.Errorf("exercise %s: %s", ce.Key, err.Error())
+		}
+		for j := range ce.Sets {
+			if err := ce.Sets[j].Validate(); err != nil {
+				return fmt.Errorf("exercise %s set %d: %s", ce.Key, j+1, err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// Seed upserts the catalogue as public exercises without an owner and returns how many were written,
+// the others were already seeded at this version or a later one
+func (c *Catalogue) Seed(ctx context.Context) (int, error) {
+	written := 0
+	for i := range c.Exercises {
+		ce := &c.Exercises[i]
+		ok, err := ce.Exercise.seed(ctx, ce.Key, c.Version)
+		if err != nil {
+			return written, fmt.Errorf("exercise %s: %s", ce.Key, err.Error())
+		}
+		if ok {
+			written++
+		}
+	}
+	return written, nil
+}
+
+func (e *Exercise) seed(ctx context.Context, key string, version int) (bool, error) {
+	tx, err := database.GetDB().Beginx()
+	if err != nil {
+		return false, err
+	}
+	rows, err := database.TxQuery(
+		ctx,
+		tx,
+		"exercises/seed",
+		key, version, e.Name, e.Description,
+		e.Sports, e.PrimaryMuscles, e.SecondaryMuscles, e.Equipment, e.Difficulty, e.Language,
+	)
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	written := false
+	for rows.Next() {
+		written = true
+		if err := rows.StructScan(e); err != nil {
+			rows.Close()
+			tx.Rollback()
+			return false, err
+		}
+	}
+	rows.Close()
+	if !written {
+		return false, tx.Rollback()
+	}
+
+	if err := e.replaceSets(ctx, tx); err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	rows, err = database.TxQuery(ctx, tx, "exercises/create_revision", e.ID)
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	rows.Close()
+
+	return true, tx.Commit()
+}
@@ -0,0 +1,144 @@
+package models
+
+import (
+	"time"
+
+	database "github.com/socious-io/pkg_database"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx/types"
+)
+
+// AssignmentProgress is the adherence of an athlete to an assigned plan. Completion is the percent of the
+// plan sets (times the rounds of their groups) logged in sessions of the assignment, a plan without sets
+// completes with its first finished session. Missed is set once the due date passed before completion.
+// User leaves out the email since editors of shared plans see every assignee.
+type AssignmentProgress struct {
+	ID               uuid.UUID    `json:"id" db:"id"`
+	PlanID           uuid.UUID    `json:"plan_id" db:"plan_id"`
+	PlanName         string       `json:"plan_name" db:"plan_name"`
+	UserID           uuid.UUID    `json:"user_id" db:"user_id"`
+	User             *UserProfile `json:"user" db:"-"`
+	DueAt            *time.Time   `json:"due_at" db:"due_at"`
+	PrescribedSets   int          `json:"prescribed_sets" db:"prescribed_sets"`
+	LoggedSets       int          `json:"logged_sets" db:"logged_sets"`
+	Sessions         int          `json:"sessions" db:"sessions"`
+	FinishedSessions int          `json:"finished_sessions" db:"finished_sessions"`
+	LastActivityAt   *time.Time   `json:"last_activity_at" db:"last_activity_at"`
+	Completion       float64      `json:"completion" db:"completion"`
+	Completed        bool         `json:"completed" db:"completed"`
+	Missed           bool         `json:"missed" db:"missed"`
+	CreatedAt        time.Time    `json:"created_at" db:"created_at"`
+
+	UserJson types.JSONText `db:"user" json:"-"`
+}
+
+// AssignmentSummary aggregates every matching assignment, not only the listed page
+type AssignmentSummary struct {
+	Assignments int     `json:"assignments"`
+	Completed   int     `json:"completed"`
+	Missed      int     `json:"missed"`
+	Completion  float64 `json:"completion"`
+}
+
+// ExerciseVolume totals the logged sets of an exercise, Volume is reps times load in kilograms
+// with loads logged in LB converted and other units left out
+type ExerciseVolume struct {
+	ExerciseID      uuid.UUID     `json:"exercise_id" db:"exercise_id"`
+	Name            string        `json:"name" db:"name"`
+	Sessions        int           `json:"sessions" db:"sessions"`
+	Sets            int           `json:"sets" db:"sets"`
+	Reps            int           `json:"reps" db:"reps"`
+	Duration        time.Duration `json:"duration" db:"duration"`
+	Volume          float64       `json:"volume" db:"volume"`
+	LastPerformedAt time.Time     `json:"last_performed_at" db:"last_performed_at"`
+}
+
+type PlanProgress struct {
+	PlanID      uuid.UUID            `json:"plan_id"`
+	Summary     AssignmentSummary    `json:"summary"`
+	Assignments []AssignmentProgress `json:"assignments"`
+	Exercises   []ExerciseVolume     `json:"exercises"`
+}
+
+// UserStats streaks count consecutive days with a workout, the current streak survives until the end of the day after the last workout
+type UserStats struct {
+	Sessions         int                  `json:"sessions" db:"sessions"`
+	FinishedSessions int                  `json:"finished_sessions" db:"finished_sessions"`
+	Sets             int                  `json:"sets" db:"sets"`
+	Reps             int                  `json:"reps" db:"reps"`
+	Volume           float64              `json:"volume" db:"volume"`
+	CurrentStreak    int                  `json:"current_streak" db:"current_streak"`
+	LongestStreak    int                  `json:"longest_streak" db:"longest_streak"`
+	LastWorkoutAt    *time.Time           `json:"last_workout_at" db:"last_workout_at"`
+	Summary          AssignmentSummary    `json:"summary" db:"-"`
+	Assignments      []AssignmentProgress `json:"assignments" db:"-"`
+	Exercises        []ExerciseVolume     `json:"exercises" db:"-"`
+}
+
+// GetPlanProgress reports the assignments of the plan, only the ones of userID when it's not nil,
+// with the volume of the exercises logged in their sessions
+func GetPlanProgress(planID uuid.UUID, userID *uuid.UUID, limit, offset int) (*PlanProgress, error) {
+	progress := &PlanProgress{PlanID: planID}
+	var err error
+	if progress.Assignments, progress.Summary, err = getAssignmentProgress(&planID, userID, limit, offset); err != nil {
+		return nil, err
+	}
+	if progress.Exercises, err = getExerciseVolumes(&planID, userID, limit); err != nil {
+		return nil, err
+	}
+	return progress, nil
+}
+
+// GetUserStats reports the workouts of the user with the plans assigned to them, limit applies to both lists
+func GetUserStats(userID uuid.UUID, limit int) (*UserStats, error) {
+	stats := new(UserStats)
+	if err := database.Get(stats, "stats/user", userID); err != nil {
+		return nil, err
+	}
+	var err error
+	if stats.Assignments, stats.Summary, err = getAssignmentProgress(nil, &userID, limit, 0); err != nil {
+		return nil, err
+	}
+	if stats.Exercises, err = getExerciseVolumes(nil, &userID, limit); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// getAssignmentProgress totals come from window aggregates of the query so they cover every page
+func getAssignmentProgress(planID, userID *uuid.UUID, limit, offset int) ([]AssignmentProgress, AssignmentSummary, error) {
+	rows := []struct {
+		AssignmentProgress
+		TotalCount        int     `db:"total_count"`
+		CompletedCount    int     `db:"completed_count"`
+		MissedCount       int     `db:"missed_count"`
+		AverageCompletion float64 `db:"average_completion"`
+	}{}
+	summary := AssignmentSummary{}
+	if err := database.QuerySelect("stats/assignments", &rows, planID, userID, limit, offset); err != nil {
+		return nil, summary, err
+	}
+	assignments := make([]AssignmentProgress, len(rows))
+	for i, r := range rows {
+		assignments[i] = r.AssignmentProgress
+		summary = AssignmentSummary{
+			Assignments: r.TotalCount,
+			Completed:   r.CompletedCount,
+			Missed:      r.MissedCount,
+			Completion:  r.AverageCompletion,
+		}
+	}
+	if err := database.UnmarshalJSONTextFields(&assignments); err != nil {
+		return nil, summary, err
+	}
+	return assignments, summary, nil
+}
+
+func getExerciseVolumes(planID, userID *uuid.UUID, limit int) ([]ExerciseVolume, error) {
+	volumes := []ExerciseVolume{}
+	if err := database.QuerySelect("stats/exercises", &volumes, planID, userID, limit); err != nil {
+		return nil, err
+	}
+	return volumes, nil
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Taxonomy mirrors the sports, muscle_groups, equipment_types and difficulties enums
+var Taxonomy = map[string][]string{
+	"sports": {"FITNESS", "CLIMBING", "THERAPEUTIC"},
+	"muscle_groups": {
+		"CHEST", "UPPER_BACK", "LATS", "LOWER_BACK", "SHOULDERS", "BICEPS", "TRICEPS", "FOREARMS", "FINGERS",
+		"CORE", "OBLIQUES", "GLUTES", "HIP_FLEXORS", "ADDUCTORS", "QUADRICEPS", "HAMSTRINGS", "CALVES", "NECK", "FULL_BODY",
+	},
+	"equipment": {
+		"BODYWEIGHT", "BARBELL", "DUMBBELL", "KETTLEBELL", "MACHINE", "CABLE", "BAND", "BENCH", "BOX", "PULL_UP_BAR",
+		"RINGS", "MEDICINE_BALL", "HANGBOARD", "CAMPUS_BOARD", "SYSTEM_BOARD", "ROPE", "ROWER", "BIKE", "TREADMILL",
+		"FOAM_ROLLER", "OTHER",
+	},
+	"difficulties": {"BEGINNER", "INTERMEDIATE", "ADVANCED"},
+}
+
+// NormalizeTerms upper cases the terms and checks them against the kind of the taxonomy, nil stays nil
+func NormalizeTerms(kind string, terms []string) ([]string, error) {
+	if terms == nil {
+		return nil, nil
+	}
+	normalized := make([]string, 0, len(terms))
+	for _, t := range terms {
+		term := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(t), " ", "_"))
+		if term == "" {
+			continue
+		}
+		if !isTerm(kind, term) {
+			return nil, fmt.Errorf("unknown %s %q", kind, t)
+		}
+		normalized = append(normalized, term)
+	}
+	return normalized, nil
+}
+
+func isTerm(kind, term string) bool {
+	for _, t := range Taxonomy[kind] {
+		if t == term {
+			return true
+		}
+	}
+	return false
+}
@@ -2,17 +2,21 @@ package models
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	database "github.com/socious-io/pkg_database"
 
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx/types"
+	"github.com/lib/pq"
 )
 
 type User struct {
 	ID        uuid.UUID  `db:"id" json:"id"`
 	Username  string     `db:"username" json:"username"`
-	Email     string     `db:"email" json:"email"`
+	Email     *string    `db:"email" json:"email"`
 	Password  *string    `db:"password" json:"-"`
 	JobTitle  *string    `db:"job_title" json:"job_title"`
 	Bio       *string    `db:"bio" json:"-"`
@@ -24,10 +28,29 @@ type User struct {
 		Url      *string `db:"url" json:"url"`
 		Filename *string `db:"filename" json:"filename"`
 	} `db:"avatar" json:"avatar"`
-	Status          string    `db:"status" json:"status"`
-	PasswordExpired bool      `db:"password_expired" json:"password_expired"`
-	CreatedAt       time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt       time.Time `db:"updated_at" json:"updated_at"`
+	Status          string     `db:"status" json:"status"`
+	PasswordExpired bool       `db:"password_expired" json:"password_expired"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at" json:"updated_at"`
+	DeletedAt       *time.Time `db:"deleted_at" json:"deleted_at"`
+}
+
+var (
+	ErrEmailTaken = errors.New("email already taken")
+	ErrPhoneTaken = errors.New("phone already taken")
+)
+
+type UserExport struct {
+	Profile         types.JSONText `db:"profile" json:"profile"`
+	Exercises       types.JSONText `db:"exercises" json:"exercises"`
+	Plans           types.JSONText `db:"plans" json:"plans"`
+	AssignedPlans   types.JSONText `db:"assigned_plans" json:"assigned_plans"`
+	ParamLogs       types.JSONText `db:"param_logs" json:"param_logs"`
+	Media           types.JSONText `db:"media" json:"media"`
+	WorkoutSessions types.JSONText `db:"workout_sessions" json:"workout_sessions"`
+	PersonalRecords types.JSONText `db:"personal_records" json:"personal_records"`
+	Goals           types.JSONText `db:"goals" json:"goals"`
+	Comments        types.JSONText `db:"comments" json:"comments"`
 }
 
 func (User) TableName() string {
@@ -42,7 +65,7 @@ func (u *User) Create(ctx context.Context) error {
 	rows, err := database.Query(
 		ctx,
 		"users/register",
-		u.FirstName, u.LastName, u.Username, u.Email, u.Password,
+		u.FirstName, u.LastName, u.Username, u.Email, u.Password, u.Phone,
 	)
 	if err != nil {
 		return err
@@ -128,6 +151,139 @@ func (u *User) UpdateProfile(ctx context.Context) error {
 	return database.Fetch(u, u.ID)
 }
 
+func (u *User) UpdateEmail(ctx context.Context, email string) error {
+	rows, err := database.Query(
+		ctx,
+		"users/update_email",
+		u.ID, email,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrEmailTaken
+		}
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := rows.StructScan(u); err != nil {
+			return err
+		}
+	}
+	if isUniqueViolation(rows.Err()) {
+		return ErrEmailTaken
+	}
+	return rows.Err()
+}
+
+func (u *User) UpdatePhone(ctx context.Context, phone string) error {
+	rows, err := database.Query(
+		ctx,
+		"users/update_phone",
+		u.ID, phone,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrPhoneTaken
+		}
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := rows.StructScan(u); err != nil {
+			return err
+		}
+	}
+	if isUniqueViolation(rows.Err()) {
+		return ErrPhoneTaken
+	}
+	return rows.Err()
+}
+
+// Delete marks the account as deleted, the row is purged by PurgeDeletedUsers once the grace period is over
+func (u *User) Delete(ctx context.Context) error {
+	rows, err := database.Query(
+		ctx,
+		"users/delete",
+		u.ID,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := rows.StructScan(u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *User) Restore(ctx context.Context) error {
+	rows, err := database.Query(
+		ctx,
+		"users/restore",
+		u.ID,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := rows.StructScan(u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *User) Export() (*UserExport, error) {
+	e := new(UserExport)
+	if err := database.Get(e, "users/export", u.ID); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// PurgeDeletedUsers hard deletes accounts soft deleted longer than grace ago,
+// related rows are removed by the users foreign keys
+func PurgeDeletedUsers(ctx context.Context, grace time.Duration) ([]uuid.UUID, error) {
+	rows, err := database.Query(
+		ctx,
+		"users/purge",
+		fmt.Sprintf("%d seconds", int64(grace.Seconds())),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ids := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func SearchUsers(status, query *string, deleted *bool, limit, offset int) ([]User, int, error) {
+	rows := []struct {
+		User
+		TotalCount int `db:"total_count"`
+	}{}
+	if err := database.QuerySelect("admin/list_users", &rows, status, query, deleted, limit, offset); err != nil {
+		return nil, 0, err
+	}
+	users := make([]User, len(rows))
+	total := 0
+	for i, r := range rows {
+		users[i] = r.User
+		total = r.TotalCount
+	}
+	return users, total, nil
+}
+
 func GetUser(id uuid.UUID) (*User, error) {
 	u := new(User)
 	if err := database.Fetch(u, id.String()); err != nil {
@@ -144,6 +300,14 @@ func GetUserByEmail(email string) (*User, error) {
 	return u, nil
 }
 
+func GetUserByPhone(phone string) (*User, error) {
+	u := new(User)
+	if err := database.Get(u, "users/fetch_by_phone", phone); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
 func GetUserByUsername(username string) (*User, error) {
 	u := new(User)
 	if err := database.Get(u, "users/fetch_by_username", username); err != nil {
@@ -151,3 +315,8 @@ func GetUserByUsername(username string) (*User, error) {
 	}
 	return u, nil
 }
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
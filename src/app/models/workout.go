@@ -0,0 +1,277 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	database "github.com/socious-io/pkg_database"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx/types"
+)
+
+type WorkoutSession struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	UserID         uuid.UUID  `json:"user_id" db:"user_id"`
+	PlanID         *uuid.UUID `json:"plan_id" db:"plan_id"`
+	PlanAssigneeID *uuid.UUID `json:"plan_assignee_id" db:"plan_assignee_id"`
+	PlanRevision   *int       `json:"plan_revision" db:"plan_revision"`
+	Note           *string    `json:"note" db:"note"`
+	SetLogs        []SetLog   `json:"set_logs" db:"-"`
+	StartedAt      time.Time  `json:"started_at" db:"started_at"`
+	FinishedAt     *time.Time `json:"finished_at" db:"finished_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+
+	SetLogsJson types.JSONText `db:"set_logs" json:"-"`
+}
+
+// ErrSetMismatch is returned when a set log names a set of another exercise
+var ErrSetMismatch = errors.New("set does not belong to the exercise")
+
+type SetLog struct {
+	ID                 uuid.UUID      `json:"id" db:"id"`
+	SessionID          uuid.UUID      `json:"session_id" db:"session_id"`
+	ExerciseID         uuid.UUID      `json:"exercise_id" db:"exercise_id"`
+	SetID              *uuid.UUID     `json:"set_id" db:"set_id"`
+	SetNumber          int            `json:"set_number" db:"set_number"`
+	RepCount           *int           `json:"rep_count" db:"rep_count"`
+	Duration           *time.Duration `json:"duration" db:"duration"`
+	Load               *float64       `json:"load" db:"load"`
+	Unit               *string        `json:"unit" db:"unit"`
+	RPE                *float64       `json:"rpe" db:"rpe"`
+	PrescribedRepCount *int           `json:"prescribed_rep_count" db:"prescribed_rep_count"`
+	PrescribedDuration *time.Duration `json:"prescribed_duration" db:"prescribed_duration"`
+	PrescribedSetType  *string        `json:"prescribed_set_type" db:"prescribed_set_type"`
+	PrescribedRepMin   *int           `json:"prescribed_rep_min" db:"prescribed_rep_min"`
+	PrescribedRepMax   *int           `json:"prescribed_rep_max" db:"prescribed_rep_max"`
+	ExerciseRevision   *int           `json:"exercise_revision" db:"exercise_revision"`
+	Note               *string        `json:"note" db:"note"`
+	CreatedAt          time.Time      `json:"created_at" db:"created_at"`
+
+	// Achieved is nil when the set had no prescription
+	Achieved *bool `json:"achieved" db:"-"`
+	// Range is BELOW, WITHIN or ABOVE for sets prescribed with rep_min and rep_max
+	Range *string `json:"range" db:"-"`
+	// Records are the personal records set by this log, only filled when it's created
+	Records []PersonalRecord `json:"records,omitempty" db:"-"`
+	// Goals are the goals of the user this log achieved, only filled when it's created
+	Goals []Goal `json:"-" db:"-"`
+}
+
+type ExerciseHistory struct {
+	SessionID  uuid.UUID  `json:"session_id" db:"session_id"`
+	StartedAt  time.Time  `json:"started_at" db:"started_at"`
+	FinishedAt *time.Time `json:"finished_at" db:"finished_at"`
+	Sets       []SetLog   `json:"sets" db:"-"`
+
+	SetsJson types.JSONText `db:"sets" json:"-"`
+}
+
+func (*WorkoutSession) TableName() string {
+	return "workout_sessions"
+}
+
+func (*WorkoutSession) FetchQuery() string {
+	return "workouts/fetch"
+}
+
+func (ws *WorkoutSession) Create(ctx context.Context) error {
+	rows, err := database.Query(
+		ctx,
+		"workouts/create",
+		ws.UserID, ws.PlanID, ws.PlanAssigneeID, ws.Note,
+	)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		if err := rows.StructScan(ws); err != nil {
+			rows.Close()
+			return err
+		}
+	}
+	rows.Close()
+	return ws.fetch()
+}
+
+func (ws *WorkoutSession) Finish(ctx context.Context) error {
+	rows, err := database.Query(
+		ctx,
+		"workouts/finish",
+		ws.ID, ws.Note,
+	)
+	if err != nil {
+		return err
+	}
+	found := false
+	for rows.Next() {
+		found = true
+		if err := rows.StructScan(ws); err != nil {
+			rows.Close()
+			return err
+		}
+	}
+	rows.Close()
+	if !found {
+		return sql.ErrNoRows
+	}
+	return ws.fetch()
+}
+
+func (ws *WorkoutSession) fetch() error {
+	if err := database.Fetch(ws, ws.ID); err != nil {
+		return err
+	}
+	for i := range ws.SetLogs {
+		ws.SetLogs[i].Compare()
+	}
+	return nil
+}
+
+func (*SetLog) TableName() string {
+	return "set_logs"
+}
+
+func (*SetLog) FetchQuery() string {
+	return "workouts/fetch_set_logs"
+}
+
+// Create logs the set with the records it beats and evaluates the goals userID, who owns the session, has on the exercise
+func (l *SetLog) Create(ctx context.Context, userID uuid.UUID) error {
+	tx, err := database.GetDB().Beginx()
+	if err != nil {
+		return err
+	}
+	rows, err := database.TxQuery(
+		ctx,
+		tx,
+		"workouts/create_set_log",
+		l.SessionID, l.ExerciseID, l.SetID, l.setNumber(), l.RepCount, l.Duration, l.Load, l.Unit, l.RPE, l.Note,
+	)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	found := false
+	for rows.Next() {
+		found = true
+		if err := rows.StructScan(l); err != nil {
+			rows.Close()
+			tx.Rollback()
+			return err
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if !found {
+		tx.Rollback()
+		return ErrSetMismatch
+	}
+	l.Compare()
+	if err := l.detectRecords(ctx, tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	achieved, err := evaluateGoals(ctx, tx, userID, nil, nil, &l.ExerciseID, false)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	l.Goals, err = fetchByIDs[Goal](achieved...)
+	return err
+}
+
+func (l *SetLog) setNumber() *int {
+	if l.SetNumber < 1 {
+		return nil
+	}
+	return &l.SetNumber
+}
+
+// Compare sets Achieved when the performed set meets its prescription
+func (l *SetLog) Compare() {
+	l.Achieved = nil
+	l.Range = nil
+	if l.PrescribedRepMin != nil {
+		achieved := l.RepCount != nil && *l.RepCount >= *l.PrescribedRepMin
+		l.Achieved = &achieved
+		if l.PrescribedRepMax != nil && l.RepCount != nil {
+			r := "WITHIN"
+			if *l.RepCount < *l.PrescribedRepMin {
+				r = "BELOW"
+			} else if *l.RepCount > *l.PrescribedRepMax {
+				r = "ABOVE"
+			}
+			l.Range = &r
+		}
+	} else if l.PrescribedSetType != nil && *l.PrescribedSetType == "AMRAP" {
+		achieved := l.RepCount != nil && *l.RepCount >= 1
+		l.Achieved = &achieved
+	} else if l.PrescribedRepCount != nil {
+		achieved := l.RepCount != nil && *l.RepCount >= *l.PrescribedRepCount
+		l.Achieved = &achieved
+	} else if l.PrescribedDuration != nil {
+		achieved := l.Duration != nil && *l.Duration >= *l.PrescribedDuration
+		l.Achieved = &achieved
+	}
+}
+
+func GetWorkoutSession(id uuid.UUID) (*WorkoutSession, error) {
+	ws := new(WorkoutSession)
+	ws.ID = id
+	if err := ws.fetch(); err != nil {
+		return nil, err
+	}
+	return ws, nil
+}
+
+func GetWorkoutSessions(userID uuid.UUID, limit, offset int) ([]WorkoutSession, int, error) {
+	list := []database.FetchList{}
+	if err := database.QuerySelect("workouts/list", &list, userID, limit, offset); err != nil {
+		return nil, 0, err
+	}
+	sessions, total, err := orderByIDs(list, func(ws WorkoutSession) uuid.UUID { return ws.ID })
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range sessions {
+		for j := range sessions[i].SetLogs {
+			sessions[i].SetLogs[j].Compare()
+		}
+	}
+	return sessions, total, nil
+}
+
+func GetExerciseHistory(exerciseID, userID uuid.UUID, limit, offset int) ([]ExerciseHistory, int, error) {
+	rows := []struct {
+		ExerciseHistory
+		TotalCount int `db:"total_count"`
+	}{}
+	if err := database.QuerySelect("workouts/exercise_history", &rows, exerciseID, userID, limit, offset); err != nil {
+		return nil, 0, err
+	}
+	history := make([]ExerciseHistory, len(rows))
+	total := 0
+	for i, r := range rows {
+		history[i] = r.ExerciseHistory
+		total = r.TotalCount
+	}
+	if err := database.UnmarshalJSONTextFields(&history); err != nil {
+		return nil, 0, err
+	}
+	for i := range history {
+		for j := range history[i].Sets {
+			history[i].Sets[j].Compare()
+		}
+	}
+	return history, total, nil
+}
@@ -0,0 +1,135 @@
+package programmes
+
+import (
+	"coachwise/src/app/models"
+	"coachwise/src/utils"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Generate lays the programme days out from start and applies its progressions to a copy of each plan.
+// Week progressions step once per programme week, session progressions once per earlier session containing the exercise.
+func Generate(p *models.Programme, plans map[uuid.UUID]*models.Plan, start time.Time) ([]models.ProgrammeSession, error) {
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+
+	days := make([]models.ProgrammeDay, len(p.Days))
+	copy(days, p.Days)
+	sort.SliceStable(days, func(i, j int) bool {
+		if days[i].Week != days[j].Week {
+			return days[i].Week < days[j].Week
+		}
+		return days[i].Day < days[j].Day
+	})
+
+	sessions := make([]models.ProgrammeSession, 0, len(days))
+	performed := map[uuid.UUID]int{}
+	for _, d := range days {
+		source, ok := plans[d.PlanID]
+		if !ok {
+			return nil, fmt.Errorf("plan %s not found", d.PlanID)
+		}
+		plan := new(models.Plan)
+		if err := utils.Copy(source, plan); err != nil {
+			return nil, err
+		}
+
+		seen := map[uuid.UUID]bool{}
+		for gi := range plan.Groups {
+			for ei := range plan.Groups[gi].Exercises {
+				pe := &plan.Groups[gi].Exercises[ei]
+				if pe.Exercise == nil {
+					continue
+				}
+				for si := range pe.Exercise.Sets {
+					for _, pr := range p.Progressions {
+						if !matches(pr, plan.ID, pe.ExerciseID) {
+							continue
+						}
+						steps := d.Week - 1
+						if pr.Per == "SESSION" {
+							steps = performed[pe.ExerciseID]
+						}
+						progress(&pe.Exercise.Sets[si], pr, steps)
+					}
+				}
+				seen[pe.ExerciseID] = true
+			}
+		}
+		for id := range seen {
+			performed[id]++
+		}
+
+		planID := d.PlanID
+		sessions = append(sessions, models.ProgrammeSession{
+			PlanID:      &planID,
+			Week:        d.Week,
+			Day:         d.Day,
+			ScheduledAt: start.AddDate(0, 0, (d.Week-1)*7+d.Day-1),
+			Plan:        plan,
+		})
+	}
+	return sessions, nil
+}
+
+func matches(pr models.Progression, planID, exerciseID uuid.UUID) bool {
+	if pr.PlanID != nil && *pr.PlanID != planID {
+		return false
+	}
+	if pr.ExerciseID != nil && *pr.ExerciseID != exerciseID {
+		return false
+	}
+	return true
+}
+
+// progress only moves values the set already prescribes
+func progress(s *models.Set, pr models.Progression, steps int) {
+	if steps < 1 {
+		return
+	}
+	delta := pr.Amount * float64(steps)
+	switch pr.Target {
+	case "LOAD":
+		if s.Load != nil {
+			v := capped(*s.Load+delta, pr)
+			s.Load = &v
+		}
+	case "PERCENT_1RM":
+		if s.Percent1RM != nil {
+			v := capped(*s.Percent1RM+delta, pr)
+			s.Percent1RM = &v
+		}
+	case "REP_COUNT":
+		for _, reps := range []*int{s.RepCount, s.RepMin, s.RepMax} {
+			if reps != nil {
+				*reps = int(math.Round(capped(float64(*reps)+delta, pr)))
+			}
+		}
+	case "DURATION":
+		if s.Duration != nil {
+			v := time.Duration(capped(s.Duration.Seconds()+delta, pr) * float64(time.Second))
+			s.Duration = &v
+		}
+	case "DISTANCE":
+		if s.Distance != nil {
+			v := capped(*s.Distance+delta, pr)
+			s.Distance = &v
+		}
+	}
+}
+
+// capped keeps the value at or below Max for increases and at or above it for decreases, never below zero
+func capped(v float64, pr models.Progression) float64 {
+	if pr.Max != nil {
+		if pr.Amount > 0 && v > *pr.Max {
+			v = *pr.Max
+		}
+		if pr.Amount < 0 && v < *pr.Max {
+			v = *pr.Max
+		}
+	}
+	return math.Max(v, 0)
+}
@@ -0,0 +1,108 @@
+package sms
+
+import (
+	"coachwise/src/config"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+type SMSSender interface {
+	Send(ctx context.Context, to, body string) error
+}
+
+// Default is used by Send, selected from config by Init
+var Default SMSSender = NewFakeSender()
+
+func Init() {
+	switch config.Config.SMS.Provider {
+	case "twilio":
+		Default = &TwilioSender{
+			BaseURL:    config.Config.SMS.BaseURL,
+			AccountSID: config.Config.SMS.AccountSID,
+			AuthToken:  config.Config.SMS.AuthToken,
+			From:       config.Config.SMS.From,
+			Client:     &http.Client{Timeout: 10 * time.Second},
+		}
+	default:
+		Default = NewFakeSender()
+	}
+}
+
+func Send(ctx context.Context, to, body string) error {
+	return Default.Send(ctx, to, body)
+}
+
+// TwilioSender delivers messages through the Twilio messages REST API or any provider compatible with it
+type TwilioSender struct {
+	BaseURL    string
+	AccountSID string
+	AuthToken  string
+	From       string
+	Client     *http.Client
+}
+
+func (t *TwilioSender) Send(ctx context.Context, to, body string) error {
+	baseURL := t.BaseURL
+	if baseURL == "" {
+		baseURL = "https://api.twilio.com"
+	}
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", strings.TrimRight(baseURL, "/"), t.AccountSID)
+	form := url.Values{}
+	form.Set("To", to)
+	form.Set("From", t.From)
+	form.Set("Body", body)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(t.AccountSID, t.AuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	res, err := t.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("sms provider responded %d: %s", res.StatusCode, msg)
+	}
+	return nil
+}
+
+type Message struct {
+	To   string
+	Body string
+}
+
+// FakeSender keeps messages in memory instead of delivering them, used on development and tests
+type FakeSender struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewFakeSender() *FakeSender {
+	return &FakeSender{}
+}
+
+func (f *FakeSender) Send(ctx context.Context, to, body string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.messages = append(f.messages, Message{To: to, Body: body})
+	log.Printf("SMS to %s: %s\n", to, body)
+	return nil
+}
+
+func (f *FakeSender) Messages() []Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Message{}, f.messages...)
+}
@@ -0,0 +1,293 @@
+package views
+
+import (
+	"coachwise/src/app/auth"
+	"coachwise/src/app/mailer"
+	"coachwise/src/app/models"
+	"coachwise/src/app/sms"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	database "github.com/socious-io/pkg_database"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func adminGroup(router *gin.Engine) {
+	g := router.Group("admin")
+	g.Use(auth.LoginRequired())
+
+	g.GET("/roles", auth.RequirePermission("roles:manage"), func(c *gin.Context) {
+		roles, err := models.GetRoles()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, roles)
+	})
+
+	g.GET("/users/:id/roles", auth.RequirePermission("roles:manage"), func(c *gin.Context) {
+		id, ok := idParam(c, "id")
+		if !ok {
+			return
+		}
+		roles, err := models.GetUserRoles(id)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, roles)
+	})
+
+	g.POST("/users/:id/roles", auth.RequirePermission("roles:manage"), func(c *gin.Context) {
+		form := new(RoleGrantForm)
+		if err := c.ShouldBindJSON(form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		id, ok := idParam(c, "id")
+		if !ok {
+			return
+		}
+		target, err := models.GetUser(id)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		u, _ := c.Get("user")
+		ur := &models.UserRole{
+			UserID:         target.ID,
+			Role:           form.Role,
+			OrganizationID: form.OrganizationID,
+			GrantedBy:      &u.(*models.User).ID,
+		}
+		if !audit(c, "ROLE_GRANT", "users", target.ID, &ur.Role, ur.Create) {
+			return
+		}
+		c.JSON(http.StatusCreated, ur)
+	})
+
+	g.DELETE("/users/:id/roles/:role", auth.RequirePermission("roles:manage"), func(c *gin.Context) {
+		id, ok := idParam(c, "id")
+		if !ok {
+			return
+		}
+		orgID, ok := optionalID(c, "organization_id", queryValue(c, "organization_id"))
+		if !ok {
+			return
+		}
+		ur := &models.UserRole{
+			UserID:         id,
+			Role:           c.Param("role"),
+			OrganizationID: orgID,
+		}
+		if !audit(c, "ROLE_REVOKE", "users", ur.UserID, &ur.Role, ur.Delete) {
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "success"})
+	})
+
+	g.GET("/users", auth.RequirePermission("users:moderate"), paginate(), func(c *gin.Context) {
+		p := c.MustGet("paginate").(database.Paginate)
+		var deleted *bool
+		if value := filterValue(p, "deleted"); value != nil {
+			d := *value == "true"
+			deleted = &d
+		}
+		users, total, err := models.SearchUsers(filterValue(p, "status"), queryValue(c, "q"), deleted, p.Limit, p.Offet)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("X-Total-Count", strconv.Itoa(total))
+		c.JSON(http.StatusOK, users)
+	})
+
+	g.POST("/users/:id/verify", auth.RequirePermission("users:moderate"), func(c *gin.Context) {
+		setUserStatus(c, "ACTIVE", "USER_VERIFY")
+	})
+
+	g.POST("/users/:id/suspend", auth.RequirePermission("users:moderate"), func(c *gin.Context) {
+		setUserStatus(c, "SUSPENDED", "USER_SUSPEND")
+	})
+
+	g.POST("/users/:id/password/reset", auth.RequirePermission("users:moderate"), func(c *gin.Context) {
+		form := new(ModerationForm)
+		c.ShouldBindJSON(form)
+		id, ok := idParam(c, "id")
+		if !ok {
+			return
+		}
+		u, err := models.GetUser(id)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		// The user logs in with this code through /auth/otp/verify or /auth/phone/otp/verify then sets a new password directly
+		var otp *models.OTP
+		if !audit(c, "USER_PASSWORD_RESET", "users", u.ID, form.Reason, func(ctx context.Context) error {
+			if err := u.ExpirePassword(ctx); err != nil {
+				return err
+			}
+			otp, err = models.NewOTP(ctx, u.ID, "FORGET_PASSWORD")
+			return err
+		}) {
+			return
+		}
+		ctx, _ := c.Get("ctx")
+		if u.Email != nil {
+			if err := mailer.Send(*u.Email, "Your password has been reset", fmt.Sprintf("Your password has been reset by support, use code %d to login and choose a new one", otp.Code)); err != nil {
+				c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "message": "Couldn't send email"})
+				return
+			}
+		} else if u.Phone != nil {
+			if err := sms.Send(ctx.(context.Context), *u.Phone, fmt.Sprintf("Your coachwise password has been reset, use code %d to login", otp.Code)); err != nil {
+				c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "message": "Couldn't send SMS"})
+				return
+			}
+		}
+		c.JSON(http.StatusOK, u)
+	})
+
+	g.POST("/users/:id/impersonate", auth.RequirePermission("users:impersonate"), func(c *gin.Context) {
+		form := new(ImpersonateForm)
+		if err := c.ShouldBindJSON(form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if form.Reason == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "reason is required"})
+			return
+		}
+		id, ok := idParam(c, "id")
+		if !ok {
+			return
+		}
+		target, err := models.GetUser(id)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		u, _ := c.Get("user")
+		ttl := 15 * time.Minute
+		var token string
+		if !audit(c, "USER_IMPERSONATE", "users", target.ID, &form.Reason, func(context.Context) error {
+			token, err = auth.GenerateImpersonationToken(target.ID.String(), u.(*models.User).ID.String(), ttl)
+			return err
+		}) {
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"access_token": token,
+			"token_type":   "Bearer",
+			"expires_in":   int(ttl.Seconds()),
+		})
+	})
+
+	g.POST("/exercises/:id/unpublish", auth.RequirePermission("content:moderate"), func(c *gin.Context) {
+		form := new(ModerationForm)
+		c.ShouldBindJSON(form)
+		id, ok := idParam(c, "id")
+		if !ok {
+			return
+		}
+		ex := &models.Exercise{ID: id}
+		if !audit(c, "EXERCISE_UNPUBLISH", "exercises", ex.ID, form.Reason, ex.Unpublish) {
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "success"})
+	})
+
+	g.POST("/plans/:id/unpublish", auth.RequirePermission("content:moderate"), func(c *gin.Context) {
+		form := new(ModerationForm)
+		c.ShouldBindJSON(form)
+		id, ok := idParam(c, "id")
+		if !ok {
+			return
+		}
+		p := &models.Plan{ID: id}
+		if !audit(c, "PLAN_UNPUBLISH", "plans", p.ID, form.Reason, p.Unpublish) {
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "success"})
+	})
+
+	g.GET("/audit-logs", auth.RequirePermission("audit:read"), paginate(), func(c *gin.Context) {
+		p := c.MustGet("paginate").(database.Paginate)
+		targetID, ok := optionalID(c, "target_id", filterValue(p, "target_id"))
+		if !ok {
+			return
+		}
+		adminID, ok := optionalID(c, "admin_id", filterValue(p, "admin_id"))
+		if !ok {
+			return
+		}
+		logs, total, err := models.GetAuditLogs(targetID, adminID, p.Limit, p.Offet)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("X-Total-Count", strconv.Itoa(total))
+		c.JSON(http.StatusOK, logs)
+	})
+}
+
+func setUserStatus(c *gin.Context, status, action string) {
+	form := new(ModerationForm)
+	c.ShouldBindJSON(form)
+	id, ok := idParam(c, "id")
+	if !ok {
+		return
+	}
+	u, err := models.GetUser(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	u.Status = status
+	if !audit(c, action, "users", u.ID, form.Reason, u.Verify) {
+		return
+	}
+	c.JSON(http.StatusOK, u)
+}
+
+// audit applies an action of the current admin and records it with its outcome, a failed action is recorded
+// with its error and answered with 400. It writes the error response when the log can't be recorded.
+func audit(c *gin.Context, action, targetType string, targetID uuid.UUID, reason *string, apply func(context.Context) error) bool {
+	u, _ := c.Get("user")
+	ctx, _ := c.Get("ctx")
+	err := apply(ctx.(context.Context))
+	outcome := "SUCCEEDED"
+	var message *string
+	if err != nil {
+		outcome = "FAILED"
+		m := err.Error()
+		message = &m
+	}
+	l := &models.AdminAuditLog{
+		AdminID:    &u.(*models.User).ID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Reason:     reason,
+		Outcome:    &outcome,
+		Error:      message,
+	}
+	if lerr := l.Create(ctx.(context.Context)); lerr != nil {
+		log.Printf("Audit log %s on %s failed: %v", action, targetID, lerr)
+		if err == nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": lerr.Error(), "message": "Couldn't record the audit log"})
+			return false
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
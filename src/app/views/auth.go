@@ -3,12 +3,16 @@ package views
 import (
 	"coachwise/src/app/auth"
 	"coachwise/src/app/models"
+	"coachwise/src/app/sms"
 	"coachwise/src/utils"
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 func authGroup(router *gin.Engine) {
@@ -25,10 +29,77 @@ func authGroup(router *gin.Engine) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "email/password not match"})
 			return
 		}
+		// accounts registered by phone have no password until they set one
+		if u.Password == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "email/password not match"})
+			return
+		}
 		if err := auth.CheckPasswordHash(form.Password, *u.Password); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "email/password not match"})
 			return
 		}
+		if u.DeletedAt != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "account deleted",
+				"message": "Account is scheduled for deletion, restore it to login",
+			})
+			return
+		}
+		if suspended(c, u) {
+			return
+		}
+
+		tokens, err := auth.GenerateFullTokens(u.ID.String())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, tokens)
+	})
+
+	g.POST("/restore", func(c *gin.Context) {
+		form := new(auth.RestoreAccountForm)
+		if err := c.ShouldBindJSON(form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		var u *models.User
+		var err error
+		if form.Email != nil {
+			u, err = models.GetUserByEmail(*form.Email)
+		} else if form.Phone != nil {
+			phone, perr := auth.NormalizePhone(*form.Phone)
+			if perr != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": perr.Error()})
+				return
+			}
+			u, err = models.GetUserByPhone(phone)
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "email or phone is required"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "account/credentials not match"})
+			return
+		}
+
+		ctx, _ := c.Get("ctx")
+		if err := reauthenticate(ctx.(context.Context), u, form.Password, form.Code); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if u.DeletedAt == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "account is not deleted"})
+			return
+		}
+		if suspended(c, u) {
+			return
+		}
+		if err := u.Restore(ctx.(context.Context)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 
 		tokens, err := auth.GenerateFullTokens(u.ID.String())
 		if err != nil {
@@ -53,7 +124,7 @@ func authGroup(router *gin.Engine) {
 		}
 
 		if form.Username == nil {
-			u.Username = auth.GenerateUsername(u.Email)
+			u.Username = auth.GenerateUsername(form.Email)
 		}
 
 		ctx, _ := c.Get("ctx")
@@ -75,6 +146,156 @@ func authGroup(router *gin.Engine) {
 		c.JSON(http.StatusOK, gin.H{"message": "success"})
 	})
 
+	g.POST("/phone/register", func(c *gin.Context) {
+		form := new(auth.PhoneRegisterForm)
+		if err := c.ShouldBindJSON(form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		phone, err := auth.NormalizePhone(form.Phone)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		u := &models.User{
+			FirstName: form.FirstName,
+			LastName:  form.LastName,
+			Phone:     &phone,
+		}
+		if form.Username != nil {
+			u.Username = *form.Username
+		} else if form.FirstName != nil {
+			u.Username = auth.GenerateUsername(*form.FirstName)
+		} else {
+			u.Username = auth.GenerateUsername("athlete")
+		}
+
+		ctx, _ := c.Get("ctx")
+		if err := u.Create(ctx.(context.Context)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		otp, err := models.NewOTP(ctx.(context.Context), u.ID, "AUTH")
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   err.Error(),
+				"message": "Couldn't save OTP",
+			})
+			return
+		}
+		if err := sms.Send(ctx.(context.Context), phone, fmt.Sprintf("Your coachwise code is %d", otp.Code)); err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "message": "Couldn't send SMS"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "success"})
+	})
+
+	g.POST("/phone/otp", func(c *gin.Context) {
+		form := new(auth.PhoneOTPSendForm)
+		if err := c.ShouldBindJSON(form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		phone, err := auth.NormalizePhone(form.Phone)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		u, err := models.GetUserByPhone(phone)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   err.Error(),
+				"message": "User does not found",
+			})
+			return
+		}
+		if suspended(c, u) {
+			return
+		}
+
+		if otp, err := models.GetOTPByUserID(u.ID); err == nil {
+			if time.Now().Before(otp.ExpiresAt) && !otp.IsVerified {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error":   "Code exists",
+					"message": "Can't send code before expiration",
+				})
+				return
+			}
+		}
+
+		ctx, _ := c.Get("ctx")
+		otp, err := models.NewOTP(ctx.(context.Context), u.ID, "AUTH")
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   err.Error(),
+				"message": "Couldn't save OTP",
+			})
+			return
+		}
+		if err := sms.Send(ctx.(context.Context), phone, fmt.Sprintf("Your coachwise code is %d", otp.Code)); err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "message": "Couldn't send SMS"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "success"})
+	})
+
+	g.POST("/phone/otp/verify", func(c *gin.Context) {
+		form := new(auth.PhoneOTPConfirmForm)
+		if err := c.ShouldBindJSON(form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		phone, err := auth.NormalizePhone(form.Phone)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		u, err := models.GetUserByPhone(phone)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "phone/code not match"})
+			return
+		}
+		if suspended(c, u) {
+			return
+		}
+		if u.DeletedAt != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "account deleted",
+				"message": "Account is scheduled for deletion, restore it to login",
+			})
+			return
+		}
+
+		otp, ok := verifyCode(c, u, form.Code, "AUTH", "FORGET_PASSWORD")
+		if !ok {
+			return
+		}
+
+		if !activate(c, u) {
+			return
+		}
+
+		if otp.Perpose == "FORGET_PASSWORD" {
+			ctx, _ := c.Get("ctx")
+			if err := u.ExpirePassword(ctx.(context.Context)); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		tokens, err := auth.GenerateFullTokens(u.ID.String())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, tokens)
+	})
+
 	g.POST("/refresh", func(c *gin.Context) {
 		form := new(auth.RefreshTokenForm)
 		if err := c.ShouldBindJSON(form); err != nil {
@@ -88,6 +309,23 @@ func authGroup(router *gin.Engine) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		if claims.ImpersonatorID != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "impersonation tokens can not be refreshed"})
+			return
+		}
+		id, err := uuid.Parse(claims.ID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		u, err := models.GetUser(id)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if suspended(c, u) {
+			return
+		}
 
 		tb := models.TokenBlacklist{
 			Token: form.RefreshToken,
@@ -122,6 +360,9 @@ func authGroup(router *gin.Engine) {
 			})
 			return
 		}
+		if suspended(c, u) {
+			return
+		}
 
 		ctx, _ := c.Get("ctx")
 
@@ -159,38 +400,30 @@ func authGroup(router *gin.Engine) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "email/password not match"})
 			return
 		}
-
-		//Verifying OTP
-		ctx, _ := c.Get("ctx")
-		otp := models.OTP{
-			UserID: u.ID,
-			Code:   form.Code,
-		}
-
-		err = otp.Verify(ctx.(context.Context))
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":   err.Error(),
-				"message": "A problem occured when trying to verify the code",
-			})
+		if suspended(c, u) {
 			return
 		}
-		if !otp.IsVerified {
+		if u.DeletedAt != nil {
 			c.JSON(http.StatusBadRequest, gin.H{
-				"error":   nil,
-				"message": "Code does not found or it is wrong",
+				"error":   "account deleted",
+				"message": "Account is scheduled for deletion, restore it to login",
 			})
 			return
 		}
 
+		//Verifying OTP, codes sent to change the email or phone are not login codes
+		otp, ok := verifyCode(c, u, form.Code, "AUTH", "FORGET_PASSWORD")
+		if !ok {
+			return
+		}
+
 		//Verifying User
-		u.Status = "ACTIVE"
-		if err := u.Verify(ctx.(context.Context)); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if !activate(c, u) {
 			return
 		}
 
 		if otp.Perpose == "FORGET_PASSWORD" {
+			ctx, _ := c.Get("ctx")
 			if err := u.ExpirePassword(ctx.(context.Context)); err != nil {
 				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 				return
@@ -223,6 +456,9 @@ func authGroup(router *gin.Engine) {
 			})
 			return
 		}
+		if suspended(c, u) {
+			return
+		}
 
 		//Creating OTP
 		ctx, _ := c.Get("ctx")
@@ -319,3 +555,82 @@ func authGroup(router *gin.Engine) {
 	})
 
 }
+
+// suspended writes the error response when the account has been suspended by a moderator
+func suspended(c *gin.Context, u *models.User) bool {
+	if u.Status != "SUSPENDED" {
+		return false
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": "account has been suspended"})
+	return true
+}
+
+// activate marks an inactive account as verified, other statuses are left for moderators to change
+func activate(c *gin.Context, u *models.User) bool {
+	if u.Status != "INACTIVE" {
+		return true
+	}
+	u.Status = "ACTIVE"
+	ctx, _ := c.Get("ctx")
+	if err := u.Verify(ctx.(context.Context)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// verifyCode redeems an unexpired code of the user issued for one of perposes and writes the error response otherwise
+func verifyCode(c *gin.Context, u *models.User, code int, perposes ...string) (*models.OTP, bool) {
+	ctx, _ := c.Get("ctx")
+	for _, perpose := range perposes {
+		otp := &models.OTP{
+			UserID:  u.ID,
+			Code:    code,
+			Perpose: perpose,
+		}
+		if err := otp.VerifyPerpose(ctx.(context.Context)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   err.Error(),
+				"message": "A problem occured when trying to verify the code",
+			})
+			return nil, false
+		}
+		if otp.IsVerified {
+			return otp, true
+		}
+	}
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":   nil,
+		"message": "Code does not found or it is wrong",
+	})
+	return nil, false
+}
+
+// reauthenticate checks the password of the user, or an unexpired AUTH code for accounts without password.
+// Codes sent for other perposes, like the ones mailed to a new email, are not accepted.
+func reauthenticate(ctx context.Context, u *models.User, password *string, code *int) error {
+	if u.Password != nil {
+		if password == nil || auth.CheckPasswordHash(*password, *u.Password) != nil {
+			return errors.New("email/password not match")
+		}
+		return nil
+	}
+	if code == nil {
+		if password != nil {
+			return errors.New("account has no password, code is required")
+		}
+		return errors.New("code is required")
+	}
+	otp := models.OTP{
+		UserID:  u.ID,
+		Code:    *code,
+		Perpose: "AUTH",
+	}
+	if err := otp.VerifyPerpose(ctx); err != nil {
+		return err
+	}
+	if !otp.IsVerified {
+		return errors.New("code does not found or it is wrong")
+	}
+	return nil
+}
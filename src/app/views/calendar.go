@@ -0,0 +1,132 @@
+package views
+
+import (
+	"coachwise/src/app/auth"
+	"coachwise/src/app/exchange"
+	"coachwise/src/app/models"
+	"coachwise/src/config"
+	"coachwise/src/utils"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// calendarHistory is how far back the feed keeps past workouts
+const calendarHistory = 90 * 24 * time.Hour
+
+func calendarGroup(router *gin.Engine) {
+	// Calendar apps can't log in, the feed is authenticated by the secret token in its url
+	router.GET("/users/me/calendar.ics", func(c *gin.Context) {
+		token := c.Query("token")
+		if token == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "token is required"})
+			return
+		}
+		t, err := models.GetCalendarTokenByToken(token)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			return
+		}
+		entries, err := models.GetCalendarEntries(t.UserID, time.Now().Add(-calendarHistory))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		appURL, err := config.AppURL()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		events := make([]utils.ICalEvent, len(entries))
+		for i, e := range entries {
+			events[i] = calendarEvent(e, appURL)
+		}
+		c.Data(http.StatusOK, "text/calendar; charset=utf-8", utils.ICal("Coachwise workouts", events))
+	})
+
+	g := router.Group("users/me/calendar")
+	g.Use(auth.LoginRequired())
+
+	g.GET("", func(c *gin.Context) {
+		u, _ := c.Get("user")
+		ctx, _ := c.Get("ctx")
+		t, err := models.GetCalendarToken(ctx.(context.Context), u.(*models.User).ID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		feed, err := calendarFeed(t)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, feed)
+	})
+
+	// Replaces the token when the feed url leaked, existing subscriptions stop updating
+	g.POST("/token", func(c *gin.Context) {
+		u, _ := c.Get("user")
+		ctx, _ := c.Get("ctx")
+		t, err := models.RegenerateCalendarToken(ctx.(context.Context), u.(*models.User).ID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		feed, err := calendarFeed(t)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, feed)
+	})
+}
+
+func calendarFeed(t *models.CalendarToken) (gin.H, error) {
+	appURL, err := config.AppURL()
+	if err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("%s/users/me/calendar.ics?token=%s", appURL, t.Token)
+	return gin.H{
+		"token":      t.Token,
+		"url":        url,
+		"webcal_url": "webcal://" + strings.SplitN(url, "://", 2)[1],
+		"created_at": t.CreatedAt,
+	}, nil
+}
+
+// calendarEvent turns the entry into a VEVENT, dates without a time of day become all day events
+func calendarEvent(e models.CalendarEntry, appURL string) utils.ICalEvent {
+	event := utils.ICalEvent{
+		UID:      fmt.Sprintf("%s-%s@coachwise", strings.ToLower(e.Kind), e.ID),
+		Summary:  e.Title,
+		Start:    e.ScheduledAt,
+		Duration: time.Hour,
+		AllDay:   e.ScheduledAt.Hour() == 0 && e.ScheduledAt.Minute() == 0 && e.ScheduledAt.Second() == 0,
+		Stamp:    e.UpdatedAt,
+	}
+	description := []string{}
+	if e.Kind == "PROGRAMME" && e.Week != nil && e.Day != nil {
+		description = append(description, fmt.Sprintf("%s, week %d day %d", e.Title, *e.Week, *e.Day))
+	}
+	if e.Plan != nil {
+		if e.Kind == "PROGRAMME" {
+			event.Summary = fmt.Sprintf("%s: %s", e.Title, e.Plan.Name)
+		}
+		if e.Plan.Description != nil && *e.Plan.Description != "" {
+			description = append(description, *e.Plan.Description)
+		}
+		if summary := exchange.Summary(e.Plan); summary != "" {
+			description = append(description, summary)
+		}
+	}
+	if e.PlanID != nil {
+		event.URL = fmt.Sprintf("%s/plans/%s", appURL, *e.PlanID)
+	}
+	event.Description = strings.Join(description, "\n\n")
+	return event
+}
@@ -0,0 +1,202 @@
+package views
+
+import (
+	"coachwise/src/app/auth"
+	"coachwise/src/app/mailer"
+	"coachwise/src/app/models"
+	"context"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+
+	database "github.com/socious-io/pkg_database"
+
+	"github.com/gin-gonic/gin"
+)
+
+func coachingGroup(router *gin.Engine) {
+	// The coach side, invites and the roster of athletes
+	g := router.Group("athletes")
+	g.Use(auth.LoginRequired())
+
+	g.POST("/invites", auth.RequirePermission("plans:assign"), func(c *gin.Context) {
+		form := new(AthleteInviteForm)
+		if err := c.ShouldBindJSON(form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		email := strings.ToLower(strings.TrimSpace(form.Email))
+		if _, err := mail.ParseAddress(email); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid email"})
+			return
+		}
+		u, _ := c.Get("user")
+		user := u.(*models.User)
+		if user.Email != nil && email == *user.Email {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "you can not coach yourself"})
+			return
+		}
+		ctx, _ := c.Get("ctx")
+		invite, err := models.InviteAthlete(ctx.(context.Context), user.ID, email)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		// the code only travels by mail, inviting again sends a new one
+		if err := mailer.Send(email, "You have been invited to coachwise", fmt.Sprintf(
+			"%s invited you to train with them on coachwise, sign in with this email and accept the invite with the code %d",
+			user.Username, invite.Code,
+		)); err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "message": "Couldn't send email"})
+			return
+		}
+		c.JSON(http.StatusCreated, invite)
+	})
+
+	g.GET("/invites", auth.RequirePermission("plans:assign"), paginate(), func(c *gin.Context) {
+		p := c.MustGet("paginate").(database.Paginate)
+		u, _ := c.Get("user")
+		list, total, err := models.GetSentInvites(u.(*models.User).ID, p.Limit, p.Offet)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("X-Total-Count", strconv.Itoa(total))
+		c.JSON(http.StatusOK, list)
+	})
+
+	// Roster of accepted athletes with the plans assigned to them and their last workout
+	g.GET("", auth.RequirePermission("athletes:read"), paginate(), func(c *gin.Context) {
+		p := c.MustGet("paginate").(database.Paginate)
+		u, _ := c.Get("user")
+		list, total, err := models.GetRoster(u.(*models.User).ID, p.Limit, p.Offet)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("X-Total-Count", strconv.Itoa(total))
+		c.JSON(http.StatusOK, list)
+	})
+
+	// Revokes an athlete or cancels a pending invite, :id is the relationship id
+	g.DELETE("/:id", func(c *gin.Context) {
+		ca, ok := coachAthlete(c, func(ca *models.CoachAthlete, user *models.User) bool {
+			return ca.CoachID == user.ID
+		})
+		if !ok {
+			return
+		}
+		revokeCoachAthlete(c, ca)
+	})
+
+	// The athlete side, answering invites and leaving coaches
+	a := router.Group("coaches")
+	a.Use(auth.LoginRequired())
+
+	a.GET("", paginate(), func(c *gin.Context) {
+		p := c.MustGet("paginate").(database.Paginate)
+		u, _ := c.Get("user")
+		list, total, err := models.GetCoaches(u.(*models.User).ID, p.Limit, p.Offet)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("X-Total-Count", strconv.Itoa(total))
+		c.JSON(http.StatusOK, list)
+	})
+
+	a.GET("/invites", paginate(), func(c *gin.Context) {
+		p := c.MustGet("paginate").(database.Paginate)
+		u, _ := c.Get("user")
+		list, total, err := models.GetReceivedInvites(u.(*models.User), p.Limit, p.Offet)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("X-Total-Count", strconv.Itoa(total))
+		c.JSON(http.StatusOK, list)
+	})
+
+	a.POST("/invites/:id/accept", func(c *gin.Context) {
+		form := new(InviteAcceptForm)
+		if err := c.ShouldBindJSON(form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ca, ok := coachAthlete(c, invitedAthlete)
+		if !ok {
+			return
+		}
+		ctx, _ := c.Get("ctx")
+		u, _ := c.Get("user")
+		if err := ca.Accept(ctx.(context.Context), u.(*models.User), form.Code); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, ca)
+	})
+
+	a.POST("/invites/:id/decline", func(c *gin.Context) {
+		ca, ok := coachAthlete(c, invitedAthlete)
+		if !ok {
+			return
+		}
+		ctx, _ := c.Get("ctx")
+		u, _ := c.Get("user")
+		if err := ca.Decline(ctx.(context.Context), u.(*models.User)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, ca)
+	})
+
+	// Leaves the coach, :id is the relationship id
+	a.DELETE("/:id", func(c *gin.Context) {
+		ca, ok := coachAthlete(c, func(ca *models.CoachAthlete, user *models.User) bool {
+			return ca.AthleteID != nil && *ca.AthleteID == user.ID
+		})
+		if !ok {
+			return
+		}
+		revokeCoachAthlete(c, ca)
+	})
+}
+
+// coachAthlete loads the :id relationship and writes the error response when it's missing or allowed returns false
+func coachAthlete(c *gin.Context, allowed func(*models.CoachAthlete, *models.User) bool) (*models.CoachAthlete, bool) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return nil, false
+	}
+	ca, err := models.GetCoachAthlete(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	u, _ := c.Get("user")
+	if !allowed(ca, u.(*models.User)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "permission denied"})
+		return nil, false
+	}
+	return ca, true
+}
+
+// invitedAthlete allows the user the invite is addressed to, by account or by email
+func invitedAthlete(ca *models.CoachAthlete, user *models.User) bool {
+	if ca.AthleteID != nil {
+		return *ca.AthleteID == user.ID
+	}
+	return user.Email != nil && *user.Email == ca.Email
+}
+
+func revokeCoachAthlete(c *gin.Context, ca *models.CoachAthlete) {
+	ctx, _ := c.Get("ctx")
+	u, _ := c.Get("user")
+	if err := ca.Revoke(ctx.(context.Context), u.(*models.User).ID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ca)
+}
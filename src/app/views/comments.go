@@ -0,0 +1,304 @@
+package views
+
+import (
+	"coachwise/src/app/auth"
+	"coachwise/src/app/events"
+	"coachwise/src/app/mailer"
+	"coachwise/src/app/models"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	database "github.com/socious-io/pkg_database"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxCommentLength is the longest comment body in characters
+const maxCommentLength = 5000
+
+// mentionExcerptLength is the part of the body quoted in mention mails
+const mentionExcerptLength = 200
+
+func commentGroup(router *gin.Engine) {
+	g := router.Group("comments")
+	g.Use(auth.LoginRequired())
+
+	// Comments on the target_type and target_id queries, thread by thread with every reply after the comment it answers
+	g.GET("", paginate(), func(c *gin.Context) {
+		targetType := queryValue(c, "target_type")
+		targetID := queryValue(c, "target_id")
+		if targetType == nil || targetID == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "target_type and target_id are required"})
+			return
+		}
+		id, err := uuid.Parse(*targetID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "target_id must be a uuid"})
+			return
+		}
+		target, ok := commentTarget(c, strings.ToUpper(*targetType), id)
+		if !ok {
+			return
+		}
+		page, _ := c.Get("paginate")
+		p := page.(database.Paginate)
+		comments, total, err := models.GetComments(target.Type, target.ID, p.Limit, p.Offet)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("X-Total-Count", strconv.Itoa(total))
+		c.JSON(http.StatusOK, comments)
+	})
+
+	// Replies are left on the target of the comment they answer, users taking part in the target
+	// mentioned as @username are notified
+	g.POST("", func(c *gin.Context) {
+		form := new(CommentForm)
+		if err := c.ShouldBindJSON(form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		body, ok := commentBody(c, form.Body)
+		if !ok {
+			return
+		}
+		target, ok := commentTarget(c, strings.ToUpper(form.TargetType), form.TargetID)
+		if !ok {
+			return
+		}
+		if form.ParentID != nil {
+			parent, err := models.GetComment(*form.ParentID)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			if parent.TargetType != target.Type || parent.TargetID != target.ID {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "parent_id must be a comment on the same target"})
+				return
+			}
+			if parent.DeletedAt != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "comment is deleted"})
+				return
+			}
+		}
+		u, _ := c.Get("user")
+		user := u.(*models.User)
+		comment := &models.Comment{
+			TargetType: target.Type,
+			TargetID:   target.ID,
+			ParentID:   form.ParentID,
+			UserID:     user.ID,
+			Body:       &body,
+		}
+		mentions, ok := mentionedUsers(c, body, target, user)
+		if !ok {
+			return
+		}
+		ctx, _ := c.Get("ctx")
+		added, err := comment.Create(ctx.(context.Context), mentions)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		notifyMentions(comment, user, added)
+		c.JSON(http.StatusCreated, comment)
+	})
+
+	g.GET("/:id", func(c *gin.Context) {
+		comment, _, ok := visibleComment(c)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, comment)
+	})
+
+	// Only the author edits the comment, the previous body is kept in its revisions
+	g.PUT("/:id", func(c *gin.Context) {
+		comment, target, ok := ownComment(c)
+		if !ok {
+			return
+		}
+		form := new(CommentUpdateForm)
+		if err := c.ShouldBindJSON(form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		body, ok := commentBody(c, form.Body)
+		if !ok {
+			return
+		}
+		u, _ := c.Get("user")
+		user := u.(*models.User)
+		mentions, ok := mentionedUsers(c, body, target, user)
+		if !ok {
+			return
+		}
+		ctx, _ := c.Get("ctx")
+		added, err := comment.Update(ctx.(context.Context), body, mentions)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		notifyMentions(comment, user, added)
+		c.JSON(http.StatusOK, comment)
+	})
+
+	g.DELETE("/:id", func(c *gin.Context) {
+		comment, _, ok := ownComment(c)
+		if !ok {
+			return
+		}
+		ctx, _ := c.Get("ctx")
+		if err := comment.Delete(ctx.(context.Context)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, comment)
+	})
+
+	// Previous bodies of the comment, latest first
+	g.GET("/:id/revisions", paginate(), func(c *gin.Context) {
+		comment, _, ok := visibleComment(c)
+		if !ok {
+			return
+		}
+		if comment.DeletedAt != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "comment is deleted"})
+			return
+		}
+		page, _ := c.Get("paginate")
+		p := page.(database.Paginate)
+		revisions, total, err := comment.GetRevisions(p.Limit, p.Offet)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("X-Total-Count", strconv.Itoa(total))
+		c.JSON(http.StatusOK, revisions)
+	})
+}
+
+// commentTarget loads the target and writes the error response when it's missing or the user takes no part in it
+func commentTarget(c *gin.Context, targetType string, id uuid.UUID) (*models.CommentTarget, bool) {
+	if !slices.Contains(models.CommentTargets, targetType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "target_type must be " + strings.Join(models.CommentTargets, ", ")})
+		return nil, false
+	}
+	target, err := models.GetCommentTarget(targetType, id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	u, _ := c.Get("user")
+	ok, err := target.CanAccess(u.(*models.User).ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "permission denied"})
+		return nil, false
+	}
+	return target, true
+}
+
+// visibleComment loads the :id comment with its target and writes the error response when the user takes no part in it
+func visibleComment(c *gin.Context) (*models.Comment, *models.CommentTarget, bool) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return nil, nil, false
+	}
+	comment, err := models.GetComment(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, nil, false
+	}
+	target, ok := commentTarget(c, comment.TargetType, comment.TargetID)
+	if !ok {
+		return nil, nil, false
+	}
+	return comment, target, true
+}
+
+// ownComment loads the :id comment and writes the error response when the user is not its author or it is deleted
+func ownComment(c *gin.Context) (*models.Comment, *models.CommentTarget, bool) {
+	comment, target, ok := visibleComment(c)
+	if !ok {
+		return nil, nil, false
+	}
+	u, _ := c.Get("user")
+	if comment.UserID != u.(*models.User).ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "permission denied"})
+		return nil, nil, false
+	}
+	if comment.DeletedAt != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "comment is deleted"})
+		return nil, nil, false
+	}
+	return comment, target, true
+}
+
+// commentBody trims the body and writes the error response when it's empty or too long
+func commentBody(c *gin.Context, body string) (string, bool) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "body is required"})
+		return "", false
+	}
+	if len([]rune(body)) > maxCommentLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("body can not be longer than %d characters", maxCommentLength)})
+		return "", false
+	}
+	return body, true
+}
+
+// mentionedUsers are the users the body mentions from the ones taking part in the target
+func mentionedUsers(c *gin.Context, body string, target *models.CommentTarget, author *models.User) ([]uuid.UUID, bool) {
+	userIDs, err := target.MentionedUsers(body, author.ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return userIDs, true
+}
+
+// notifyMentions notifies the users the comment did not mention before
+func notifyMentions(comment *models.Comment, author *models.User, added []uuid.UUID) {
+	for _, id := range added {
+		events.Publish(events.CommentMention, id, comment)
+		user, err := models.GetUser(id)
+		if err != nil || user.Email == nil {
+			continue
+		}
+		if err := mailer.Send(
+			*user.Email,
+			fmt.Sprintf("%s mentioned you in a comment", author.Username),
+			fmt.Sprintf("%s mentioned you in a comment: \"%s\"", author.Username, mentionExcerpt(*comment.Body)),
+		); err != nil {
+			log.Printf("Couldn't send mention notice to %s: %v\n", *user.Email, err)
+		}
+	}
+}
+
+// mentionExcerpt puts the body on one line without control characters and cuts it to mentionExcerptLength characters
+func mentionExcerpt(body string) string {
+	body = strings.Map(func(r rune) rune {
+		if unicode.IsPrint(r) || unicode.IsSpace(r) {
+			return r
+		}
+		return -1
+	}, body)
+	excerpt := []rune(strings.Join(strings.Fields(body), " "))
+	if len(excerpt) <= mentionExcerptLength {
+		return string(excerpt)
+	}
+	return string(excerpt[:mentionExcerptLength]) + "..."
+}
@@ -5,17 +5,20 @@ import (
 	"coachwise/src/app/models"
 	"coachwise/src/utils"
 	"context"
+	"fmt"
 	"net/http"
+	"strconv"
+
+	database "github.com/socious-io/pkg_database"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 )
 
 func exerciseGroup(router *gin.Engine) {
 	g := router.Group("exercises")
 	g.Use(auth.LoginRequired())
 
-	g.POST("", func(c *gin.Context) {
+	g.POST("", auth.RequirePermission("exercises:create"), func(c *gin.Context) {
 		form := new(ExerciseForm)
 		if err := c.ShouldBindJSON(form); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -23,10 +26,24 @@ func exerciseGroup(router *gin.Engine) {
 		}
 		ex := new(models.Exercise)
 		utils.Copy(form, ex)
+		if err := ex.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		visibility, organizationID, ok := bindVisibility(c, form.Visibility, form.Public, form.OrganizationID, "", nil)
+		if !ok {
+			return
+		}
+		ex.Visibility = visibility
+		ex.OrganizationID = organizationID
 		user, _ := c.Get("user")
 		ex.UserID = &user.(*models.User).ID
 		for i := range ex.Sets {
 			ex.Sets[i].SetNumber = i + 1
+			if err := ex.Sets[i].Validate(); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("set %d: %s", i+1, err.Error())})
+				return
+			}
 		}
 		ctx, _ := c.Get("ctx")
 		if err := ex.Create(ctx.(context.Context)); err != nil {
@@ -36,27 +53,202 @@ func exerciseGroup(router *gin.Engine) {
 		c.JSON(http.StatusCreated, ex)
 	})
 
-	g.GET("/:id", func(c *gin.Context) {
-		ex, err := models.GetExrcise(uuid.MustParse(c.Param("id")))
+	g.GET("", paginate(), func(c *gin.Context) {
+		p := c.MustGet("paginate").(database.Paginate)
+		f, err := exerciseFilter(c, p)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		u, _ := c.Get("user")
+		exercises, total, err := models.GetExercises(u.(*models.User).ID, f, p.Limit, p.Offet)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("X-Total-Count", strconv.Itoa(total))
+		c.JSON(http.StatusOK, exercises)
+	})
+
+	g.GET("/taxonomy", func(c *gin.Context) {
+		c.JSON(http.StatusOK, models.Taxonomy)
+	})
+
+	g.GET("/:id", func(c *gin.Context) {
+		ex, ok := visibleExercise(c)
+		if !ok {
+			return
+		}
 		c.JSON(http.StatusOK, ex)
 	})
 
-	g.PUT("/:id", func(c *gin.Context) {
-		ex, err := models.GetExrcise(uuid.MustParse(c.Param("id")))
+	g.GET("/:id/history", paginate(), func(c *gin.Context) {
+		id, ok := idParam(c, "id")
+		if !ok {
+			return
+		}
+		p := c.MustGet("paginate").(database.Paginate)
+		u, _ := c.Get("user")
+		history, total, err := models.GetExerciseHistory(id, u.(*models.User).ID, p.Limit, p.Offet)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("X-Total-Count", strconv.Itoa(total))
+		c.JSON(http.StatusOK, history)
+	})
+
+	// Personal records of the user on the exercise, latest first
+	g.GET("/:id/records", paginate(), func(c *gin.Context) {
+		id, ok := idParam(c, "id")
+		if !ok {
+			return
+		}
+		p := c.MustGet("paginate").(database.Paginate)
+		kind, ok := recordKind(c)
+		if !ok {
+			return
+		}
+		u, _ := c.Get("user")
+		records, total, err := models.GetRecordHistory(u.(*models.User).ID, id, kind, p.Limit, p.Offet)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("X-Total-Count", strconv.Itoa(total))
+		c.JSON(http.StatusOK, records)
+	})
+
+	// Copies a visible exercise with its sets into a private exercise of the user
+	g.POST("/:id/fork", auth.RequirePermission("exercises:create"), func(c *gin.Context) {
+		ex, ok := visibleExercise(c)
+		if !ok {
+			return
+		}
+		u, _ := c.Get("user")
+		ctx, _ := c.Get("ctx")
+		fork, err := ex.Fork(ctx.(context.Context), u.(*models.User).ID)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		c.JSON(http.StatusCreated, fork)
+	})
+
+	g.GET("/:id/revisions", paginate(), func(c *gin.Context) {
+		ex, ok := visibleExercise(c)
+		if !ok {
+			return
+		}
+		p := c.MustGet("paginate").(database.Paginate)
+		revisions, total, err := models.GetExerciseRevisions(ex.ID, p.Limit, p.Offet)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("X-Total-Count", strconv.Itoa(total))
+		c.JSON(http.StatusOK, revisions)
+	})
+
+	// Compares the from revision with the to revision, both given as revision numbers
+	g.GET("/:id/revisions/diff", func(c *gin.Context) {
+		ex, ok := visibleExercise(c)
+		if !ok {
+			return
+		}
+		from, ok := revisionQuery(c, "from")
+		if !ok {
+			return
+		}
+		to, ok := revisionQuery(c, "to")
+		if !ok {
+			return
+		}
+		a, err := models.GetExerciseRevision(ex.ID, from)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		b, err := models.GetExerciseRevision(ex.ID, to)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		changes, err := models.DiffSnapshots(a.SnapshotJson, b.SnapshotJson)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"from": from, "to": to, "changes": changes})
+	})
+
+	g.GET("/:id/revisions/:revision", func(c *gin.Context) {
+		ex, ok := visibleExercise(c)
+		if !ok {
+			return
+		}
+		revision, ok := revisionParam(c)
+		if !ok {
+			return
+		}
+		r, err := models.GetExerciseRevision(ex.ID, revision)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, r)
+	})
+
+	g.POST("/:id/revisions/:revision/restore", auth.RequirePermission("exercises:update"), func(c *gin.Context) {
+		ex, ok := ownExercise(c)
+		if !ok {
+			return
+		}
+		revision, ok := revisionParam(c)
+		if !ok {
+			return
+		}
+		r, err := models.GetExerciseRevision(ex.ID, revision)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx, _ := c.Get("ctx")
+		if err := ex.Restore(ctx.(context.Context), r); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, ex)
+	})
+
+	g.PUT("/:id", auth.RequirePermission("exercises:update"), func(c *gin.Context) {
+		ex, ok := ownExercise(c)
+		if !ok {
+			return
+		}
 		form := new(ExerciseForm)
 		if err := c.ShouldBindJSON(form); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		currentVisibility, currentOrganizationID := ex.Visibility, ex.OrganizationID
 		utils.Copy(form, ex)
+		if err := ex.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		visibility, organizationID, ok := bindVisibility(c, form.Visibility, form.Public, form.OrganizationID, currentVisibility, currentOrganizationID)
+		if !ok {
+			return
+		}
+		ex.Visibility = visibility
+		ex.OrganizationID = organizationID
+		for i := range ex.Sets {
+			if err := ex.Sets[i].Validate(); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("set %d: %s", i+1, err.Error())})
+				return
+			}
+		}
 		ctx, _ := c.Get("ctx")
 		if err := ex.Update(ctx.(context.Context)); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -79,14 +271,71 @@ func exerciseGroup(router *gin.Engine) {
 				}
 				c.JSON(http.StatusOK, gin.H{"message": "exercise deleted"})
 			})
-
-			g.GET("", func(c *gin.Context) {
-				exs, err := models.GetExercises()
-				if err != nil {
-					c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-					return
-				}
-				c.JSON(http.StatusOK, exs)
-			})
 	*/
 }
+
+// visibleExercise loads the :id exercise and writes the error response when it's missing or hidden from the user
+func visibleExercise(c *gin.Context) (*models.Exercise, bool) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return nil, false
+	}
+	ex, err := models.GetExrcise(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	u, _ := c.Get("user")
+	if !allowed(c, models.CanView, u.(*models.User).ID, ex.UserID, ex.OrganizationID, ex.Visibility) {
+		return nil, false
+	}
+	return ex, true
+}
+
+// ownExercise loads the :id exercise and writes the error response when it's missing or the user is neither
+// its owner nor allowed to update the library of its organization
+func ownExercise(c *gin.Context) (*models.Exercise, bool) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return nil, false
+	}
+	ex, err := models.GetExrcise(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	u, _ := c.Get("user")
+	if !allowed(c, models.CanEdit, u.(*models.User).ID, ex.UserID, ex.OrganizationID, ex.Visibility) {
+		return nil, false
+	}
+	return ex, true
+}
+
+// exerciseFilter reads the q (or name) full text query, the visibility and organization_id queries and the filter.sport,
+// filter.muscle, filter.primary_muscle, filter.equipment and filter.difficulty params, each one a comma separated list of terms
+func exerciseFilter(c *gin.Context, p database.Paginate) (models.ExerciseFilter, error) {
+	f := models.ExerciseFilter{Query: queryValue(c, "q")}
+	if f.Query == nil {
+		f.Query = queryValue(c, "name")
+	}
+	var err error
+	if f.Visibility, f.OrganizationID, err = visibilityQuery(c); err != nil {
+		return f, err
+	}
+	if f.Sports, err = models.NormalizeTerms("sports", filterValues(p, "sport")); err != nil {
+		return f, err
+	}
+	if f.Muscles, err = models.NormalizeTerms("muscle_groups", filterValues(p, "muscle")); err != nil {
+		return f, err
+	}
+	if f.PrimaryMuscles, err = models.NormalizeTerms("muscle_groups", filterValues(p, "primary_muscle")); err != nil {
+		return f, err
+	}
+	if f.Equipment, err = models.NormalizeTerms("equipment", filterValues(p, "equipment")); err != nil {
+		return f, err
+	}
+	if f.Difficulties, err = models.NormalizeTerms("difficulties", filterValues(p, "difficulty")); err != nil {
+		return f, err
+	}
+	return f, nil
+}
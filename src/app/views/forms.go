@@ -2,16 +2,191 @@ package views
 
 import (
 	"time"
+
+	"github.com/google/uuid"
 )
 
+// ExerciseForm Public is the boolean visibility of older clients, it is only read when Visibility is empty
 type ExerciseForm struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Public      bool   `json:"public"`
-	Sets        []struct {
-		Name     string         `json:"name"`
-		RestTime time.Duration  `json:"rest_time"`
-		RepCount *int           `json:"rep_count"`
-		Duration *time.Duration `json:"duration"`
+	Name           string     `json:"name"`
+	Description    string     `json:"description"`
+	Public         *bool      `json:"public"`
+	Visibility     string     `json:"visibility"`
+	OrganizationID *uuid.UUID `json:"organization_id"`
+	Sets           []struct {
+		Name         string         `json:"name"`
+		SetType      string         `json:"set_type"`
+		RestTime     time.Duration  `json:"rest_time"`
+		RepCount     *int           `json:"rep_count"`
+		Duration     *time.Duration `json:"duration"`
+		Load         *float64       `json:"load"`
+		LoadUnit     *string        `json:"load_unit"`
+		Percent1RM   *float64       `json:"percent_1rm"`
+		RPE          *float64       `json:"rpe"`
+		RIR          *int           `json:"rir"`
+		Tempo        *string        `json:"tempo"`
+		Distance     *float64       `json:"distance"`
+		DistanceUnit *string        `json:"distance_unit"`
+		Grade        *string        `json:"grade"`
+		Side         *string        `json:"side"`
+		RepMin       *int           `json:"rep_min"`
+		RepMax       *int           `json:"rep_max"`
+		Interval     *time.Duration `json:"interval"`
+		Rounds       *int           `json:"rounds"`
+		Drops        *int           `json:"drops"`
+		DropPercent  *float64       `json:"drop_percent"`
 	} `json:"sets"`
+	Sports           []string `json:"sports"`
+	PrimaryMuscles   []string `json:"primary_muscles"`
+	SecondaryMuscles []string `json:"secondary_muscles"`
+	Equipment        []string `json:"equipment"`
+	Difficulty       *string  `json:"difficulty"`
+	Language         *string  `json:"language"`
+}
+
+type RoleGrantForm struct {
+	Role           string     `json:"role" validate:"required"`
+	OrganizationID *uuid.UUID `json:"organization_id"`
+}
+
+type ModerationForm struct {
+	Reason *string `json:"reason"`
+}
+
+type ImpersonateForm struct {
+	Reason string `json:"reason" validate:"required"`
+}
+
+type WorkoutSessionForm struct {
+	PlanID         *uuid.UUID `json:"plan_id"`
+	PlanAssigneeID *uuid.UUID `json:"plan_assignee_id"`
+	Note           *string    `json:"note"`
+}
+
+type WorkoutFinishForm struct {
+	Note *string `json:"note"`
+}
+
+type SetLogForm struct {
+	ExerciseID uuid.UUID      `json:"exercise_id" validate:"required"`
+	SetID      *uuid.UUID     `json:"set_id"`
+	SetNumber  int            `json:"set_number"`
+	RepCount   *int           `json:"rep_count"`
+	Duration   *time.Duration `json:"duration"`
+	Load       *float64       `json:"load"`
+	Unit       *string        `json:"unit"`
+	RPE        *float64       `json:"rpe"`
+	Note       *string        `json:"note"`
+}
+
+// PlanForm Public is the boolean visibility of older clients, it is only read when Visibility is empty
+type PlanForm struct {
+	Name           string     `json:"name" validate:"required"`
+	Description    *string    `json:"description"`
+	Public         *bool      `json:"public"`
+	Visibility     string     `json:"visibility"`
+	OrganizationID *uuid.UUID `json:"organization_id"`
+	Language       *string    `json:"language"`
+}
+
+type PlanGroupForm struct {
+	GroupType  string        `json:"group_type"`
+	GroupOrder int           `json:"group_order"`
+	Name       *string       `json:"name"`
+	Rounds     int           `json:"rounds"`
+	RestTime   time.Duration `json:"rest_time"`
+	Exercises  []struct {
+		ExerciseID uuid.UUID     `json:"exercise_id" validate:"required"`
+		RestTime   time.Duration `json:"rest_time"`
+		Notes      *string       `json:"notes"`
+	} `json:"exercises"`
+}
+
+type PlanExerciseForm struct {
+	ExerciseID    uuid.UUID     `json:"exercise_id" validate:"required"`
+	GroupID       *uuid.UUID    `json:"group_id"`
+	ExerciseOrder int           `json:"exercise_order"`
+	RestTime      time.Duration `json:"rest_time"`
+	Notes         *string       `json:"notes"`
+}
+
+// ProgrammeForm Public is the boolean visibility of older clients, it is only read when Visibility is empty
+type ProgrammeForm struct {
+	Name           string     `json:"name" validate:"required"`
+	Description    *string    `json:"description"`
+	Public         *bool      `json:"public"`
+	Visibility     string     `json:"visibility"`
+	OrganizationID *uuid.UUID `json:"organization_id"`
+	Weeks          int        `json:"weeks" validate:"required"`
+	Days           []struct {
+		Week   int       `json:"week"`
+		Day    int       `json:"day"`
+		PlanID uuid.UUID `json:"plan_id" validate:"required"`
+		Note   *string   `json:"note"`
+	} `json:"days"`
+	Progressions []struct {
+		PlanID     *uuid.UUID `json:"plan_id"`
+		ExerciseID *uuid.UUID `json:"exercise_id"`
+		Target     string     `json:"target"`
+		Amount     float64    `json:"amount"`
+		Per        string     `json:"per"`
+		Max        *float64   `json:"max"`
+	} `json:"progressions"`
+}
+
+type ProgrammeAssignForm struct {
+	UserID    *uuid.UUID `json:"user_id"`
+	StartDate string     `json:"start_date" validate:"required"`
+}
+
+type AthleteInviteForm struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type InviteAcceptForm struct {
+	Code int `json:"code" validate:"required"`
+}
+
+type OrganizationForm struct {
+	Name        string  `json:"name" validate:"required"`
+	Description *string `json:"description"`
+}
+
+type OrganizationMemberForm struct {
+	Email string `json:"email"`
+	Role  string `json:"role" validate:"required"`
+}
+
+type ParamLogForm struct {
+	Value *float64 `json:"value" validate:"required"`
+	Side  *string  `json:"side"`
+	Note  *string  `json:"note"`
+}
+
+type GoalForm struct {
+	ParamID     *uuid.UUID `json:"param_id"`
+	ExerciseID  *uuid.UUID `json:"exercise_id"`
+	Side        *string    `json:"side"`
+	Metric      *string    `json:"metric"`
+	TargetValue *float64   `json:"target_value" validate:"required"`
+	Unit        *string    `json:"unit"`
+	Deadline    *string    `json:"deadline"`
+	Note        *string    `json:"note"`
+}
+
+type GoalUpdateForm struct {
+	TargetValue *float64 `json:"target_value" validate:"required"`
+	Deadline    *string  `json:"deadline"`
+	Note        *string  `json:"note"`
+}
+
+type CommentForm struct {
+	TargetType string     `json:"target_type" validate:"required"`
+	TargetID   uuid.UUID  `json:"target_id" validate:"required"`
+	ParentID   *uuid.UUID `json:"parent_id"`
+	Body       string     `json:"body" validate:"required"`
+}
+
+type CommentUpdateForm struct {
+	Body string `json:"body" validate:"required"`
 }
@@ -0,0 +1,278 @@
+package views
+
+import (
+	"coachwise/src/app/auth"
+	"coachwise/src/app/events"
+	"coachwise/src/app/mailer"
+	"coachwise/src/app/models"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	database "github.com/socious-io/pkg_database"
+
+	"github.com/gin-gonic/gin"
+)
+
+func goalGroup(router *gin.Engine) {
+	g := router.Group("goals")
+	g.Use(auth.LoginRequired())
+
+	// Goals target either a param_id with an optional side, or an exercise_id with the metric of its personal records
+	g.POST("", func(c *gin.Context) {
+		form := new(GoalForm)
+		if err := c.ShouldBindJSON(form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if form.TargetValue == nil || *form.TargetValue < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "target_value is required and can not be negative"})
+			return
+		}
+		if (form.ParamID == nil) == (form.ExerciseID == nil) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "one of param_id or exercise_id is required"})
+			return
+		}
+		deadline, ok := goalDeadline(c, form.Deadline)
+		if !ok {
+			return
+		}
+		u, _ := c.Get("user")
+		goal := &models.Goal{
+			UserID:      u.(*models.User).ID,
+			ParamID:     form.ParamID,
+			ExerciseID:  form.ExerciseID,
+			Side:        "GENERAL",
+			TargetValue: *form.TargetValue,
+			Deadline:    deadline,
+			Note:        form.Note,
+		}
+		if form.ParamID != nil {
+			if !paramGoal(c, goal, form) {
+				return
+			}
+		} else if !exerciseGoal(c, goal, form) {
+			return
+		}
+		ctx, _ := c.Get("ctx")
+		if err := goal.Create(ctx.(context.Context)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if goal.Status == "ACHIEVED" {
+			notifyGoals(u.(*models.User), []models.Goal{*goal})
+		}
+		c.JSON(http.StatusCreated, goal)
+	})
+
+	g.GET("", paginate(), func(c *gin.Context) {
+		status := queryValue(c, "status")
+		if status != nil {
+			value := strings.ToUpper(*status)
+			if !slices.Contains(models.GoalStatuses, value) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "status must be " + strings.Join(models.GoalStatuses, ", ")})
+				return
+			}
+			status = &value
+		}
+		u, _ := c.Get("user")
+		ctx, _ := c.Get("ctx")
+		page, _ := c.Get("paginate")
+		p := page.(database.Paginate)
+		goals, total, err := models.GetGoals(ctx.(context.Context), u.(*models.User).ID, status, p.Limit, p.Offet)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("X-Total-Count", strconv.Itoa(total))
+		c.JSON(http.StatusOK, goals)
+	})
+
+	g.GET("/:id", func(c *gin.Context) {
+		goal, ok := ownGoal(c)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, goal)
+	})
+
+	// Updating the target or deadline reopens an achieved or missed goal and evaluates it again
+	g.PUT("/:id", func(c *gin.Context) {
+		goal, ok := ownGoal(c)
+		if !ok {
+			return
+		}
+		form := new(GoalUpdateForm)
+		if err := c.ShouldBindJSON(form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if form.TargetValue == nil || *form.TargetValue < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "target_value is required and can not be negative"})
+			return
+		}
+		if goal.Status == "CANCELLED" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "goal is cancelled"})
+			return
+		}
+		deadline, ok := goalDeadline(c, form.Deadline)
+		if !ok {
+			return
+		}
+		goal.TargetValue = *form.TargetValue
+		goal.Deadline = deadline
+		goal.Note = form.Note
+		ctx, _ := c.Get("ctx")
+		if err := goal.Update(ctx.(context.Context)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if goal.Status == "ACHIEVED" {
+			u, _ := c.Get("user")
+			notifyGoals(u.(*models.User), []models.Goal{*goal})
+		}
+		c.JSON(http.StatusOK, goal)
+	})
+
+	// Cancelled goals are kept in the history of the user but no longer evaluated
+	g.DELETE("/:id", func(c *gin.Context) {
+		goal, ok := ownGoal(c)
+		if !ok {
+			return
+		}
+		if goal.Status != "ACTIVE" && goal.Status != "MISSED" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("goal is %s", strings.ToLower(goal.Status))})
+			return
+		}
+		ctx, _ := c.Get("ctx")
+		if err := goal.Cancel(ctx.(context.Context)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, goal)
+	})
+}
+
+// ownGoal loads the :id goal and writes the error response when it's missing or belongs to another user
+func ownGoal(c *gin.Context) (*models.Goal, bool) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return nil, false
+	}
+	ctx, _ := c.Get("ctx")
+	goal, err := models.GetGoal(ctx.(context.Context), id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	u, _ := c.Get("user")
+	if goal.UserID != u.(*models.User).ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "permission denied"})
+		return nil, false
+	}
+	return goal, true
+}
+
+// goalDeadline parses the optional deadline date, which can not be in the past
+func goalDeadline(c *gin.Context, value *string) (*time.Time, bool) {
+	if value == nil {
+		return nil, true
+	}
+	deadline, err := time.Parse(time.DateOnly, *value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "deadline must be formatted as YYYY-MM-DD"})
+		return nil, false
+	}
+	if deadline.Before(time.Now().Truncate(24 * time.Hour)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "deadline can not be in the past"})
+		return nil, false
+	}
+	return &deadline, true
+}
+
+// paramGoal sets the side and unit of a param goal, the unit must be the one the param is logged in
+func paramGoal(c *gin.Context, goal *models.Goal, form *GoalForm) bool {
+	param, err := models.GetParam(*form.ParamID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	if form.Metric != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "metric is only used by exercise goals"})
+		return false
+	}
+	if form.Side != nil {
+		if *form.Side != "LEFT" && *form.Side != "RIGHT" && *form.Side != "GENERAL" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "side must be LEFT, RIGHT or GENERAL"})
+			return false
+		}
+		goal.Side = *form.Side
+	}
+	if form.Unit != nil && *form.Unit != param.Unit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unit of %s must be %s", param.Name, param.Unit)})
+		return false
+	}
+	goal.Unit = param.Unit
+	return true
+}
+
+// exerciseGoal sets the metric and unit of an exercise goal, loads are in KG unless LB is given
+func exerciseGoal(c *gin.Context, goal *models.Goal, form *GoalForm) bool {
+	ex, err := models.GetExrcise(*form.ExerciseID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	if !allowed(c, models.CanView, goal.UserID, ex.UserID, ex.OrganizationID, ex.Visibility) {
+		return false
+	}
+	if form.Side != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "side is only used by param goals"})
+		return false
+	}
+	if form.Metric == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "metric is required for exercise goals"})
+		return false
+	}
+	metric := strings.ToUpper(*form.Metric)
+	if !slices.Contains(models.RecordKinds, metric) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "metric must be " + strings.Join(models.RecordKinds, ", ")})
+		return false
+	}
+	units := map[string][]string{
+		"MAX_LOAD":     {"KG", "LB"},
+		"E1RM":         {"KG", "LB"},
+		"MAX_REPS":     {"COUNT"},
+		"MAX_DURATION": {"SECOND"},
+	}[metric]
+	goal.Metric = &metric
+	goal.Unit = units[0]
+	if form.Unit != nil {
+		if !slices.Contains(units, *form.Unit) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unit of " + metric + " must be " + strings.Join(units, ", ")})
+			return false
+		}
+		goal.Unit = *form.Unit
+	}
+	return true
+}
+
+// notifyGoals publishes an event and mails the user for every goal they just achieved
+func notifyGoals(user *models.User, goals []models.Goal) {
+	for _, g := range goals {
+		events.Publish(events.GoalAchieved, user.ID, g)
+		if user.Email == nil {
+			continue
+		}
+		if err := mailer.Send(*user.Email, "You reached your goal", fmt.Sprintf(
+			"Congratulations, you reached your goal of %v %s on %s", g.TargetValue, g.Unit, g.Name(),
+		)); err != nil {
+			log.Printf("Couldn't send goal notice to %s: %v\n", *user.Email, err)
+		}
+	}
+}
@@ -1,12 +1,14 @@
 package views
 
 import (
+	"net/http"
 	"strconv"
 	"strings"
 
 	database "github.com/socious-io/pkg_database"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 func paginate() gin.HandlerFunc {
@@ -48,3 +50,77 @@ func paginate() gin.HandlerFunc {
 
 	}
 }
+
+func filterValue(p database.Paginate, key string) *string {
+	for _, f := range p.Filters {
+		if f.Key == key {
+			value := f.Value
+			return &value
+		}
+	}
+	return nil
+}
+
+// filterValues splits a comma separated filter, nil when the filter is missing
+func filterValues(p database.Paginate, key string) []string {
+	value := filterValue(p, key)
+	if value == nil {
+		return nil
+	}
+	return strings.Split(*value, ",")
+}
+
+func queryValue(c *gin.Context, key string) *string {
+	if value, ok := c.GetQuery(key); ok && value != "" {
+		return &value
+	}
+	return nil
+}
+
+// idParam reads a uuid path param and writes the error response when it's malformed
+func idParam(c *gin.Context, key string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param(key))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": key + " must be a uuid"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// optionalID parses an optional uuid value and writes the error response when it's malformed
+func optionalID(c *gin.Context, key string, value *string) (*uuid.UUID, bool) {
+	if value == nil {
+		return nil, true
+	}
+	id, err := uuid.Parse(*value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": key + " must be a uuid"})
+		return nil, false
+	}
+	return &id, true
+}
+
+// revisionParam reads the :revision number and writes the error response when it's not a positive number
+func revisionParam(c *gin.Context) (int, bool) {
+	revision, err := strconv.Atoi(c.Param("revision"))
+	if err != nil || revision < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "revision must be a positive number"})
+		return 0, false
+	}
+	return revision, true
+}
+
+// revisionQuery reads a required revision number from the query and writes the error response when it's missing or invalid
+func revisionQuery(c *gin.Context, key string) (int, bool) {
+	value := queryValue(c, key)
+	if value == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": key + " is required"})
+		return 0, false
+	}
+	revision, err := strconv.Atoi(*value)
+	if err != nil || revision < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": key + " must be a positive number"})
+		return 0, false
+	}
+	return revision, true
+}
@@ -0,0 +1,442 @@
+package views
+
+import (
+	"coachwise/src/app/auth"
+	"coachwise/src/app/mailer"
+	"coachwise/src/app/models"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"slices"
+	"strconv"
+	"strings"
+
+	database "github.com/socious-io/pkg_database"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func organizationGroup(router *gin.Engine) {
+	g := router.Group("organizations")
+	g.Use(auth.LoginRequired())
+
+	g.POST("", func(c *gin.Context) {
+		form := new(OrganizationForm)
+		if err := c.ShouldBindJSON(form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		form.Name = strings.TrimSpace(form.Name)
+		if form.Name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+			return
+		}
+		u, _ := c.Get("user")
+		o := &models.Organization{
+			Name:        form.Name,
+			Description: form.Description,
+			UserID:      &u.(*models.User).ID,
+		}
+		ctx, _ := c.Get("ctx")
+		if err := o.Create(ctx.(context.Context)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, o)
+	})
+
+	g.GET("", paginate(), func(c *gin.Context) {
+		p := c.MustGet("paginate").(database.Paginate)
+		u, _ := c.Get("user")
+		list, total, err := models.GetOrganizations(u.(*models.User).ID, p.Limit, p.Offet)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("X-Total-Count", strconv.Itoa(total))
+		c.JSON(http.StatusOK, list)
+	})
+
+	g.GET("/:id", func(c *gin.Context) {
+		o, ok := memberOrganization(c)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, o)
+	})
+
+	g.PUT("/:id", auth.RequireOrganizationPermission("organizations:manage", "id"), func(c *gin.Context) {
+		o, ok := memberOrganization(c)
+		if !ok {
+			return
+		}
+		form := new(OrganizationForm)
+		if err := c.ShouldBindJSON(form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		o.Name = strings.TrimSpace(form.Name)
+		if o.Name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+			return
+		}
+		o.Description = form.Description
+		ctx, _ := c.Get("ctx")
+		if err := o.Update(ctx.(context.Context)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, o)
+	})
+
+	g.DELETE("/:id", auth.RequireOrganizationPermission("organizations:manage", "id"), func(c *gin.Context) {
+		o, ok := memberOrganization(c)
+		if !ok {
+			return
+		}
+		ctx, _ := c.Get("ctx")
+		if err := o.Delete(ctx.(context.Context)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "success"})
+	})
+
+	g.GET("/:id/members", paginate(), func(c *gin.Context) {
+		o, ok := memberOrganization(c)
+		if !ok {
+			return
+		}
+		p := c.MustGet("paginate").(database.Paginate)
+		members, total, err := o.GetMembers(p.Limit, p.Offet)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("X-Total-Count", strconv.Itoa(total))
+		c.JSON(http.StatusOK, members)
+	})
+
+	// Invites an email to join, the user becomes a member once they accept. The response is the same
+	// whether the email is registered or not.
+	g.POST("/:id/members", auth.RequireOrganizationPermission("organizations:manage", "id"), func(c *gin.Context) {
+		o, ok := memberOrganization(c)
+		if !ok {
+			return
+		}
+		form := new(OrganizationMemberForm)
+		if err := c.ShouldBindJSON(form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		email := strings.ToLower(strings.TrimSpace(form.Email))
+		if _, err := mail.ParseAddress(email); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid email"})
+			return
+		}
+		role, ok := memberRole(c, form.Role)
+		if !ok {
+			return
+		}
+		u, _ := c.Get("user")
+		user := u.(*models.User)
+		ctx, _ := c.Get("ctx")
+		invite, err := o.Invite(ctx.(context.Context), email, role, user.ID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := mailer.Send(email, fmt.Sprintf("You have been invited to %s", o.Name), fmt.Sprintf(
+			"%s invited you to join %s on coachwise as %s, sign in with this email to accept the invite",
+			user.Username, o.Name, role,
+		)); err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "message": "Couldn't send email"})
+			return
+		}
+		c.JSON(http.StatusCreated, invite)
+	})
+
+	g.PUT("/:id/members/:user_id", auth.RequireOrganizationPermission("organizations:manage", "id"), func(c *gin.Context) {
+		o, ok := memberOrganization(c)
+		if !ok {
+			return
+		}
+		form := new(OrganizationMemberForm)
+		if err := c.ShouldBindJSON(form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		memberID, ok := idParam(c, "user_id")
+		if !ok {
+			return
+		}
+		setMemberRole(c, o, memberID, form.Role)
+	})
+
+	// Managers remove members, members may also leave on their own
+	g.DELETE("/:id/members/:user_id", func(c *gin.Context) {
+		o, ok := memberOrganization(c)
+		if !ok {
+			return
+		}
+		memberID, ok := idParam(c, "user_id")
+		if !ok {
+			return
+		}
+		u, _ := c.Get("user")
+		if memberID != u.(*models.User).ID {
+			allowed, err := models.HasOrganizationPermission(u.(*models.User).ID, o.ID, "organizations:manage")
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if !allowed {
+				c.JSON(http.StatusForbidden, gin.H{"error": "permission denied"})
+				return
+			}
+		}
+		if err := keepManager(o, memberID, ""); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx, _ := c.Get("ctx")
+		if err := o.RemoveMember(ctx.(context.Context), memberID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "success"})
+	})
+
+	// Invites addressed to the email of the user
+	g.GET("/invites", paginate(), func(c *gin.Context) {
+		p := c.MustGet("paginate").(database.Paginate)
+		u, _ := c.Get("user")
+		invites := []models.OrganizationInvite{}
+		total := 0
+		if email := u.(*models.User).Email; email != nil {
+			var err error
+			invites, total, err = models.GetReceivedOrganizationInvites(*email, p.Limit, p.Offet)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		c.Header("X-Total-Count", strconv.Itoa(total))
+		c.JSON(http.StatusOK, invites)
+	})
+
+	g.POST("/invites/:id/accept", func(c *gin.Context) {
+		i, ok := receivedInvite(c)
+		if !ok {
+			return
+		}
+		ctx, _ := c.Get("ctx")
+		u, _ := c.Get("user")
+		if err := i.Accept(ctx.(context.Context), u.(*models.User)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, i)
+	})
+
+	g.POST("/invites/:id/decline", func(c *gin.Context) {
+		i, ok := receivedInvite(c)
+		if !ok {
+			return
+		}
+		ctx, _ := c.Get("ctx")
+		u, _ := c.Get("user")
+		if err := i.Decline(ctx.(context.Context), u.(*models.User)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, i)
+	})
+}
+
+// receivedInvite loads the :id invite and writes the error response when it's missing or addressed to another email
+func receivedInvite(c *gin.Context) (*models.OrganizationInvite, bool) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return nil, false
+	}
+	i, err := models.GetOrganizationInvite(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	u, _ := c.Get("user")
+	if email := u.(*models.User).Email; email == nil || *email != i.Email {
+		c.JSON(http.StatusForbidden, gin.H{"error": "permission denied"})
+		return nil, false
+	}
+	return i, true
+}
+
+// memberOrganization loads the :id organization and writes the error response when it's missing or the user is not a member,
+// platform admins see every organization
+func memberOrganization(c *gin.Context) (*models.Organization, bool) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return nil, false
+	}
+	o, err := models.GetOrganization(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	u, _ := c.Get("user")
+	userID := u.(*models.User).ID
+	member, err := models.IsOrganizationMember(userID, o.ID)
+	if err == nil && !member {
+		member, err = models.HasPermission(userID, nil, "organizations:manage")
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if !member {
+		c.JSON(http.StatusForbidden, gin.H{"error": "permission denied"})
+		return nil, false
+	}
+	return o, true
+}
+
+// memberRole normalizes the role and writes the error response when it's not one of the organization roles
+func memberRole(c *gin.Context, role string) (string, bool) {
+	role = strings.ToLower(strings.TrimSpace(role))
+	if !slices.Contains(models.OrganizationRoles, role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role must be " + strings.Join(models.OrganizationRoles, ", ")})
+		return "", false
+	}
+	return role, true
+}
+
+func setMemberRole(c *gin.Context, o *models.Organization, memberID uuid.UUID, role string) {
+	role, ok := memberRole(c, role)
+	if !ok {
+		return
+	}
+	if err := keepManager(o, memberID, role); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	u, _ := c.Get("user")
+	ctx, _ := c.Get("ctx")
+	if err := o.SetMemberRole(ctx.(context.Context), memberID, role, u.(*models.User).ID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "success"})
+}
+
+// keepManager refuses to take the manager role from the last manager, role is the new role of the member or empty when removed
+func keepManager(o *models.Organization, memberID uuid.UUID, role string) error {
+	if role == "manager" {
+		return nil
+	}
+	last, err := o.IsLastManager(memberID)
+	if err != nil {
+		return err
+	}
+	if last {
+		return errors.New("organization needs at least one manager")
+	}
+	return nil
+}
+
+// bindVisibility resolves the visibility and organization of new or updated content and checks the user may share it with
+// the organization. currentVisibility and currentOrganizationID are the values of the content being updated, empty and nil
+// on create. Without visibility older clients keep the current sharing, their public flag only switches PUBLIC on and off.
+func bindVisibility(c *gin.Context, visibility string, public *bool, organizationID *uuid.UUID, currentVisibility string, currentOrganizationID *uuid.UUID) (string, *uuid.UUID, bool) {
+	visibility = strings.ToUpper(strings.TrimSpace(visibility))
+	if visibility == "" {
+		switch {
+		case currentVisibility == "":
+			visibility = models.VisibilityPrivate
+			if public != nil && *public {
+				visibility = models.VisibilityPublic
+			}
+		case public != nil && *public:
+			visibility = models.VisibilityPublic
+		case public != nil && currentVisibility == models.VisibilityPublic:
+			visibility = models.VisibilityPrivate
+		default:
+			visibility = currentVisibility
+		}
+		if organizationID == nil {
+			organizationID = currentOrganizationID
+		}
+	}
+	if !models.IsVisibility(visibility) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "visibility must be PRIVATE, ORG or PUBLIC"})
+		return "", nil, false
+	}
+	if visibility == models.VisibilityOrg && organizationID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "organization_id is required for ORG visibility"})
+		return "", nil, false
+	}
+	if organizationID == nil || (currentOrganizationID != nil && *currentOrganizationID == *organizationID) {
+		return visibility, organizationID, true
+	}
+	u, _ := c.Get("user")
+	allowed, err := models.HasOrganizationPermission(u.(*models.User).ID, *organizationID, "library:update")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return "", nil, false
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "permission denied"})
+		return "", nil, false
+	}
+	return visibility, organizationID, true
+}
+
+// visibilityQuery reads the visibility and organization_id filters of library listings,
+// the public=true|false filter of older clients is read as PUBLIC or PRIVATE
+func visibilityQuery(c *gin.Context) (*string, *uuid.UUID, error) {
+	visibility := queryValue(c, "visibility")
+	if visibility != nil {
+		value := strings.ToUpper(*visibility)
+		if !models.IsVisibility(value) {
+			return nil, nil, errors.New("visibility must be PRIVATE, ORG or PUBLIC")
+		}
+		visibility = &value
+	} else if value := queryValue(c, "public"); value != nil {
+		public, err := strconv.ParseBool(*value)
+		if err != nil {
+			return nil, nil, errors.New("public must be true or false")
+		}
+		value := models.VisibilityPrivate
+		if public {
+			value = models.VisibilityPublic
+		}
+		visibility = &value
+	}
+	var organizationID *uuid.UUID
+	if value := queryValue(c, "organization_id"); value != nil {
+		id, err := uuid.Parse(*value)
+		if err != nil {
+			return nil, nil, errors.New("organization_id must be a uuid")
+		}
+		organizationID = &id
+	}
+	return visibility, organizationID, nil
+}
+
+// allowed runs the CanView or CanEdit check and writes the error response when it fails
+func allowed(c *gin.Context, check func(uuid.UUID, *uuid.UUID, *uuid.UUID, string) (bool, error), userID uuid.UUID, ownerID, organizationID *uuid.UUID, visibility string) bool {
+	ok, err := check(userID, ownerID, organizationID, visibility)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "permission denied"})
+		return false
+	}
+	return true
+}
@@ -1 +1,157 @@
 package views
+
+import (
+	"coachwise/src/app/auth"
+	"coachwise/src/app/models"
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func paramGroup(router *gin.Engine) {
+	g := router.Group("params")
+	g.Use(auth.LoginRequired())
+
+	// Logs a value of the param for the user, side defaults to the one of the param. Goals on the param are evaluated
+	// right away and the user is notified of those the value achieved.
+	g.POST("/:id/logs", auth.RequirePermission("params:log"), func(c *gin.Context) {
+		id, ok := idParam(c, "id")
+		if !ok {
+			return
+		}
+		param, err := models.GetParam(id)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		form := new(ParamLogForm)
+		if err := c.ShouldBindJSON(form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if form.Value == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "value is required"})
+			return
+		}
+		side := param.Side
+		if form.Side != nil {
+			side = *form.Side
+		}
+		if side != "LEFT" && side != "RIGHT" && side != "GENERAL" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "side must be LEFT, RIGHT or GENERAL"})
+			return
+		}
+		u, _ := c.Get("user")
+		user := u.(*models.User)
+		l := &models.ParamLog{
+			UserID:  user.ID,
+			ParamID: param.ID,
+			Side:    side,
+			Value:   *form.Value,
+			Note:    form.Note,
+		}
+		ctx, _ := c.Get("ctx")
+		if err := l.Create(ctx.(context.Context)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		notifyGoals(user, l.Goals)
+		c.JSON(http.StatusCreated, l)
+	})
+
+	// Trend of the param logs of the user, or of an athlete of the coach given as user_id. from and to are dates
+	// defaulting to the last 90 days, bucket is day, week (default) or month and window the buckets averaged (default 4).
+	g.GET("/:id/trends", func(c *gin.Context) {
+		id, ok := idParam(c, "id")
+		if !ok {
+			return
+		}
+		param, err := models.GetParam(id)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		userID, ok := trendUser(c)
+		if !ok {
+			return
+		}
+
+		bucket := "week"
+		if value := queryValue(c, "bucket"); value != nil {
+			bucket = strings.ToLower(*value)
+		}
+		if !slices.Contains(models.TrendBuckets, bucket) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bucket must be " + strings.Join(models.TrendBuckets, ", ")})
+			return
+		}
+		window := 4
+		if value := queryValue(c, "window"); value != nil {
+			if window, err = strconv.Atoi(*value); err != nil || window < 1 || window > 52 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "window must be between 1 and 52"})
+				return
+			}
+		}
+
+		// to is inclusive, the query reads logs before the start of the next day
+		to := time.Now().Truncate(24 * time.Hour)
+		if value := queryValue(c, "to"); value != nil {
+			if to, err = time.Parse(time.DateOnly, *value); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "to must be a date as 2006-01-02"})
+				return
+			}
+		}
+		from := to.AddDate(0, 0, -90)
+		if value := queryValue(c, "from"); value != nil {
+			if from, err = time.Parse(time.DateOnly, *value); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "from must be a date as 2006-01-02"})
+				return
+			}
+		}
+		if from.After(to) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be before to"})
+			return
+		}
+
+		trend, err := models.GetParamTrend(param, userID, bucket, window, from, to.AddDate(0, 0, 1))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		trend.To = to
+		c.JSON(http.StatusOK, trend)
+	})
+}
+
+// trendUser reads the user_id query, coaches read the logs of the athletes who accepted them
+func trendUser(c *gin.Context) (uuid.UUID, bool) {
+	u, _ := c.Get("user")
+	user := u.(*models.User)
+	value := queryValue(c, "user_id")
+	if value == nil {
+		return user.ID, true
+	}
+	athleteID, err := uuid.Parse(*value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id must be a uuid"})
+		return uuid.Nil, false
+	}
+	if athleteID == user.ID {
+		return athleteID, true
+	}
+	coach, err := models.IsCoachOf(user.ID, athleteID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return uuid.Nil, false
+	}
+	if !coach {
+		c.JSON(http.StatusForbidden, gin.H{"error": "permission denied"})
+		return uuid.Nil, false
+	}
+	return athleteID, true
+}
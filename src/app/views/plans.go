@@ -0,0 +1,669 @@
+package views
+
+import (
+	"coachwise/src/app/auth"
+	"coachwise/src/app/exchange"
+	"coachwise/src/app/models"
+	"coachwise/src/config"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	database "github.com/socious-io/pkg_database"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxImportSize bounds the spreadsheets accepted by plan imports
+const maxImportSize = 5 << 20
+
+func planGroup(router *gin.Engine) {
+	g := router.Group("plans")
+	g.Use(auth.LoginRequired())
+
+	g.POST("", auth.RequirePermission("plans:create"), func(c *gin.Context) {
+		form := new(PlanForm)
+		if err := c.ShouldBindJSON(form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		visibility, organizationID, ok := bindVisibility(c, form.Visibility, form.Public, form.OrganizationID, "", nil)
+		if !ok {
+			return
+		}
+		u, _ := c.Get("user")
+		p := &models.Plan{
+			UserID:         u.(*models.User).ID,
+			Name:           form.Name,
+			Description:    form.Description,
+			Visibility:     visibility,
+			OrganizationID: organizationID,
+		}
+		if form.Language != nil {
+			p.Language = *form.Language
+		}
+		if err := p.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx, _ := c.Get("ctx")
+		if err := p.Create(ctx.(context.Context)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, p)
+	})
+
+	// Creates a plan from a CSV or XLSX programme, dry_run only reports how the exercises matched the library
+	g.POST("/import", auth.RequirePermission("plans:create"), func(c *gin.Context) {
+		file, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+			return
+		}
+		if file.Size > maxImportSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file is too large"})
+			return
+		}
+		f, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		data, err := io.ReadAll(io.LimitReader(f, maxImportSize))
+		f.Close()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		dryRun := false
+		if value := queryValue(c, "dry_run"); value != nil {
+			if dryRun, err = strconv.ParseBool(*value); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "dry_run must be true or false"})
+				return
+			}
+		}
+
+		blocks, err := exchange.Parse(file.Filename, data)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		u, _ := c.Get("user")
+		p, report, err := exchange.Build(u.(*models.User).ID, blocks)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if dryRun {
+			c.JSON(http.StatusOK, gin.H{"report": report})
+			return
+		}
+		if len(report.Unmatched) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "some exercises did not match the library", "report": report})
+			return
+		}
+
+		p.Name = strings.TrimSpace(c.PostForm("name"))
+		if p.Name == "" {
+			p.Name = strings.TrimSuffix(filepath.Base(file.Filename), filepath.Ext(file.Filename))
+		}
+		if description := strings.TrimSpace(c.PostForm("description")); description != "" {
+			p.Description = &description
+		}
+		p.Language = "english"
+		ctx, _ := c.Get("ctx")
+		if err := p.Create(ctx.(context.Context)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"plan": p, "report": report})
+	})
+
+	g.GET("", paginate(), func(c *gin.Context) {
+		p := c.MustGet("paginate").(database.Paginate)
+		visibility, organizationID, err := visibilityQuery(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		u, _ := c.Get("user")
+		plans, total, err := models.GetPlans(u.(*models.User).ID, visibility, organizationID, p.Limit, p.Offet)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("X-Total-Count", strconv.Itoa(total))
+		c.JSON(http.StatusOK, plans)
+	})
+
+	g.GET("/:id", func(c *gin.Context) {
+		p, ok := visiblePlan(c)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, p)
+	})
+
+	g.GET("/:id/export", func(c *gin.Context) {
+		p, ok := visiblePlan(c)
+		if !ok {
+			return
+		}
+		format := "csv"
+		if value := queryValue(c, "format"); value != nil {
+			format = strings.ToLower(*value)
+		}
+		var (
+			data        []byte
+			contentType string
+			err         error
+		)
+		switch format {
+		case "csv":
+			data, err = exchange.CSV(p)
+			contentType = "text/csv"
+		case "json":
+			data, err = json.MarshalIndent(p, "", "  ")
+			contentType = "application/json"
+		case "xlsx":
+			data, err = exchange.XLSX(p)
+			contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+		case "pdf":
+			data = exchange.PDF(p)
+			contentType = "application/pdf"
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv, json, xlsx or pdf"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		filename := fmt.Sprintf("%s.%s", exportName(p.Name), format)
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		c.Data(http.StatusOK, contentType, data)
+	})
+
+	// Printable workout sheet with blank load and reps columns and a QR code back to the plan
+	g.GET("/:id/pdf", func(c *gin.Context) {
+		p, ok := visiblePlan(c)
+		if !ok {
+			return
+		}
+		appURL, err := config.AppURL()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		data, err := exchange.Sheet(p, fmt.Sprintf("%s/plans/%s", appURL, p.ID))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		filename := fmt.Sprintf("%s-sheet.pdf", exportName(p.Name))
+		c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", filename))
+		c.Data(http.StatusOK, "application/pdf", data)
+	})
+
+	g.PUT("/:id", auth.RequirePermission("plans:update"), func(c *gin.Context) {
+		p, ok := ownPlan(c)
+		if !ok {
+			return
+		}
+		form := new(PlanForm)
+		if err := c.ShouldBindJSON(form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		visibility, organizationID, ok := bindVisibility(c, form.Visibility, form.Public, form.OrganizationID, p.Visibility, p.OrganizationID)
+		if !ok {
+			return
+		}
+		p.Name = form.Name
+		p.Description = form.Description
+		p.Visibility = visibility
+		p.OrganizationID = organizationID
+		if form.Language != nil {
+			p.Language = *form.Language
+		}
+		if err := p.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx, _ := c.Get("ctx")
+		if err := p.Update(ctx.(context.Context)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, p)
+	})
+
+	g.DELETE("/:id", auth.RequirePermission("plans:delete"), func(c *gin.Context) {
+		p, ok := ownPlan(c)
+		if !ok {
+			return
+		}
+		ctx, _ := c.Get("ctx")
+		if err := p.Delete(ctx.(context.Context)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "success"})
+	})
+
+	// Copies a visible plan with its groups into a private plan of the user
+	g.POST("/:id/fork", auth.RequirePermission("plans:create"), func(c *gin.Context) {
+		p, ok := visiblePlan(c)
+		if !ok {
+			return
+		}
+		u, _ := c.Get("user")
+		ctx, _ := c.Get("ctx")
+		fork, err := p.Fork(ctx.(context.Context), u.(*models.User).ID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, fork)
+	})
+
+	g.GET("/:id/revisions", paginate(), func(c *gin.Context) {
+		p, ok := visiblePlan(c)
+		if !ok {
+			return
+		}
+		pg := c.MustGet("paginate").(database.Paginate)
+		revisions, total, err := models.GetPlanRevisions(p.ID, pg.Limit, pg.Offet)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("X-Total-Count", strconv.Itoa(total))
+		c.JSON(http.StatusOK, revisions)
+	})
+
+	// Adherence of the athletes assigned to the plan, users who can't edit the plan only see their own assignments
+	g.GET("/:id/progress", paginate(), func(c *gin.Context) {
+		id, ok := idParam(c, "id")
+		if !ok {
+			return
+		}
+		p, err := models.GetPlan(id)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		u, _ := c.Get("user")
+		user := u.(*models.User)
+		editor, err := models.CanEdit(user.ID, &p.UserID, p.OrganizationID, p.Visibility)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		var userID *uuid.UUID
+		if !editor {
+			assignee, err := models.IsPlanAssignee(p.ID, user.ID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if !assignee {
+				c.JSON(http.StatusForbidden, gin.H{"error": "permission denied"})
+				return
+			}
+			userID = &user.ID
+		}
+		pg := c.MustGet("paginate").(database.Paginate)
+		progress, err := models.GetPlanProgress(p.ID, userID, pg.Limit, pg.Offet)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("X-Total-Count", strconv.Itoa(progress.Summary.Assignments))
+		c.JSON(http.StatusOK, progress)
+	})
+
+	// Compares the from revision with the to revision, both given as revision numbers
+	g.GET("/:id/revisions/diff", func(c *gin.Context) {
+		p, ok := visiblePlan(c)
+		if !ok {
+			return
+		}
+		from, ok := revisionQuery(c, "from")
+		if !ok {
+			return
+		}
+		to, ok := revisionQuery(c, "to")
+		if !ok {
+			return
+		}
+		a, err := models.GetPlanRevision(p.ID, from)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		b, err := models.GetPlanRevision(p.ID, to)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		changes, err := models.DiffSnapshots(a.SnapshotJson, b.SnapshotJson)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"from": from, "to": to, "changes": changes})
+	})
+
+	g.GET("/:id/revisions/:revision", func(c *gin.Context) {
+		p, ok := visiblePlan(c)
+		if !ok {
+			return
+		}
+		revision, ok := revisionParam(c)
+		if !ok {
+			return
+		}
+		r, err := models.GetPlanRevision(p.ID, revision)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, r)
+	})
+
+	g.POST("/:id/revisions/:revision/restore", auth.RequirePermission("plans:update"), func(c *gin.Context) {
+		p, ok := ownPlan(c)
+		if !ok {
+			return
+		}
+		revision, ok := revisionParam(c)
+		if !ok {
+			return
+		}
+		r, err := models.GetPlanRevision(p.ID, revision)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx, _ := c.Get("ctx")
+		if err := p.Restore(ctx.(context.Context), r); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, p)
+	})
+
+	g.GET("/:id/exercises", func(c *gin.Context) {
+		p, ok := visiblePlan(c)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, p.Exercises())
+	})
+
+	// Adds a single exercise, as its own group or appended to an existing superset or circuit
+	g.POST("/:id/exercises", auth.RequirePermission("plans:update"), func(c *gin.Context) {
+		p, ok := ownPlan(c)
+		if !ok {
+			return
+		}
+		form := new(PlanExerciseForm)
+		if err := c.ShouldBindJSON(form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if form.RestTime < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "rest_time can not be negative"})
+			return
+		}
+		if !usableExercise(c, form.ExerciseID) {
+			return
+		}
+		ctx, _ := c.Get("ctx")
+		if form.GroupID != nil {
+			group := p.Group(*form.GroupID)
+			if group == nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "group not found in plan"})
+				return
+			}
+			if group.GroupType == "SINGLE" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "exercises can only be added to SUPERSET or CIRCUIT groups"})
+				return
+			}
+			pe := &models.PlanExercise{
+				PlanID:        p.ID,
+				GroupID:       group.ID,
+				ExerciseID:    form.ExerciseID,
+				ExerciseOrder: form.ExerciseOrder,
+				RestTime:      form.RestTime,
+				Notes:         form.Notes,
+			}
+			if err := pe.Create(ctx.(context.Context)); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		} else {
+			group := &models.PlanGroup{
+				PlanID:     p.ID,
+				GroupOrder: form.ExerciseOrder,
+				GroupType:  "SINGLE",
+				Rounds:     1,
+				Exercises: []models.PlanExercise{
+					{ExerciseID: form.ExerciseID, RestTime: form.RestTime, Notes: form.Notes},
+				},
+			}
+			if err := group.Create(ctx.(context.Context)); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		respondPlan(c, http.StatusCreated, p.ID)
+	})
+
+	g.DELETE("/:id/exercises/:exercise_id", auth.RequirePermission("plans:update"), func(c *gin.Context) {
+		p, ok := ownPlan(c)
+		if !ok {
+			return
+		}
+		exerciseID, ok := idParam(c, "exercise_id")
+		if !ok {
+			return
+		}
+		ctx, _ := c.Get("ctx")
+		if err := p.RemoveExercise(ctx.(context.Context), exerciseID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		respondPlan(c, http.StatusOK, p.ID)
+	})
+
+	g.POST("/:id/groups", auth.RequirePermission("plans:update"), func(c *gin.Context) {
+		p, ok := ownPlan(c)
+		if !ok {
+			return
+		}
+		group, ok := bindPlanGroup(c)
+		if !ok {
+			return
+		}
+		group.PlanID = p.ID
+		ctx, _ := c.Get("ctx")
+		if err := group.Create(ctx.(context.Context)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		respondPlan(c, http.StatusCreated, p.ID)
+	})
+
+	g.PUT("/:id/groups/:group_id", auth.RequirePermission("plans:update"), func(c *gin.Context) {
+		p, ok := ownPlan(c)
+		if !ok {
+			return
+		}
+		groupID, ok := idParam(c, "group_id")
+		if !ok {
+			return
+		}
+		current := p.Group(groupID)
+		if current == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "group not found in plan"})
+			return
+		}
+		group, ok := bindPlanGroup(c)
+		if !ok {
+			return
+		}
+		group.ID = current.ID
+		group.PlanID = p.ID
+		ctx, _ := c.Get("ctx")
+		if err := group.Update(ctx.(context.Context)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		respondPlan(c, http.StatusOK, p.ID)
+	})
+
+	g.DELETE("/:id/groups/:group_id", auth.RequirePermission("plans:update"), func(c *gin.Context) {
+		p, ok := ownPlan(c)
+		if !ok {
+			return
+		}
+		groupID, ok := idParam(c, "group_id")
+		if !ok {
+			return
+		}
+		group := p.Group(groupID)
+		if group == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "group not found in plan"})
+			return
+		}
+		ctx, _ := c.Get("ctx")
+		if err := group.Delete(ctx.(context.Context)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		respondPlan(c, http.StatusOK, p.ID)
+	})
+}
+
+// visiblePlan loads the :id plan and writes the error response when it's missing or hidden from the user
+func visiblePlan(c *gin.Context) (*models.Plan, bool) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return nil, false
+	}
+	p, err := models.GetPlan(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	u, _ := c.Get("user")
+	if !allowed(c, models.CanView, u.(*models.User).ID, &p.UserID, p.OrganizationID, p.Visibility) {
+		return nil, false
+	}
+	return p, true
+}
+
+// ownPlan loads the :id plan and writes the error response when it's missing or the user is neither
+// its owner nor allowed to update the library of its organization
+func ownPlan(c *gin.Context) (*models.Plan, bool) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return nil, false
+	}
+	p, err := models.GetPlan(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	u, _ := c.Get("user")
+	if !allowed(c, models.CanEdit, u.(*models.User).ID, &p.UserID, p.OrganizationID, p.Visibility) {
+		return nil, false
+	}
+	return p, true
+}
+
+// usablePlan writes the error response unless the plan is visible to the user
+func usablePlan(c *gin.Context, id uuid.UUID) bool {
+	p, err := models.GetPlan(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	u, _ := c.Get("user")
+	return allowed(c, models.CanView, u.(*models.User).ID, &p.UserID, p.OrganizationID, p.Visibility)
+}
+
+// usableExercise writes the error response unless the exercise is visible to the user
+func usableExercise(c *gin.Context, id uuid.UUID) bool {
+	ex, err := models.GetExrcise(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	u, _ := c.Get("user")
+	return allowed(c, models.CanView, u.(*models.User).ID, ex.UserID, ex.OrganizationID, ex.Visibility)
+}
+
+func bindPlanGroup(c *gin.Context) (*models.PlanGroup, bool) {
+	form := new(PlanGroupForm)
+	if err := c.ShouldBindJSON(form); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	group := &models.PlanGroup{
+		GroupType:  form.GroupType,
+		GroupOrder: form.GroupOrder,
+		Name:       form.Name,
+		Rounds:     form.Rounds,
+		RestTime:   form.RestTime,
+	}
+	for _, e := range form.Exercises {
+		group.Exercises = append(group.Exercises, models.PlanExercise{ExerciseID: e.ExerciseID, RestTime: e.RestTime, Notes: e.Notes})
+	}
+	if err := group.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	for _, e := range group.Exercises {
+		if !usableExercise(c, e.ExerciseID) {
+			return nil, false
+		}
+	}
+	return group, true
+}
+
+// exportName keeps the letters and digits of the plan name for the download file name
+func exportName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case b.Len() > 0 && !strings.HasSuffix(b.String(), "-"):
+			b.WriteRune('-')
+		}
+	}
+	if name := strings.Trim(b.String(), "-"); name != "" {
+		return name
+	}
+	return "plan"
+}
+
+// respondPlan writes the nested plan after a change to its groups
+func respondPlan(c *gin.Context, code int, id uuid.UUID) {
+	p, err := models.GetPlan(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(code, p)
+}
@@ -0,0 +1,296 @@
+package views
+
+import (
+	"coachwise/src/app/auth"
+	"coachwise/src/app/models"
+	"coachwise/src/app/programmes"
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	database "github.com/socious-io/pkg_database"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func programmeGroup(router *gin.Engine) {
+	g := router.Group("programmes")
+	g.Use(auth.LoginRequired())
+
+	g.POST("", auth.RequirePermission("plans:create"), func(c *gin.Context) {
+		p, ok := bindProgramme(c, nil)
+		if !ok {
+			return
+		}
+		u, _ := c.Get("user")
+		p.UserID = u.(*models.User).ID
+		ctx, _ := c.Get("ctx")
+		if err := p.Create(ctx.(context.Context)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, p)
+	})
+
+	g.GET("", paginate(), func(c *gin.Context) {
+		p := c.MustGet("paginate").(database.Paginate)
+		u, _ := c.Get("user")
+		list, total, err := models.GetProgrammes(u.(*models.User).ID, p.Limit, p.Offet)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("X-Total-Count", strconv.Itoa(total))
+		c.JSON(http.StatusOK, list)
+	})
+
+	g.GET("/:id", func(c *gin.Context) {
+		p, ok := visibleProgramme(c)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, p)
+	})
+
+	g.PUT("/:id", auth.RequirePermission("plans:update"), func(c *gin.Context) {
+		current, ok := ownProgramme(c)
+		if !ok {
+			return
+		}
+		p, ok := bindProgramme(c, current)
+		if !ok {
+			return
+		}
+		p.ID = current.ID
+		p.UserID = current.UserID
+		ctx, _ := c.Get("ctx")
+		if err := p.Update(ctx.(context.Context)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, p)
+	})
+
+	g.DELETE("/:id", auth.RequirePermission("plans:delete"), func(c *gin.Context) {
+		p, ok := ownProgramme(c)
+		if !ok {
+			return
+		}
+		ctx, _ := c.Get("ctx")
+		if err := p.Delete(ctx.(context.Context)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "success"})
+	})
+
+	// Previews the generated sessions without assigning, start defaults to today
+	g.GET("/:id/calendar", func(c *gin.Context) {
+		p, ok := visibleProgramme(c)
+		if !ok {
+			return
+		}
+		start := time.Now()
+		if value := queryValue(c, "start"); value != nil {
+			var err error
+			if start, err = time.Parse(time.DateOnly, *value); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "start must be formatted as YYYY-MM-DD"})
+				return
+			}
+		}
+		sessions, err := generateProgramme(p, start)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, sessions)
+	})
+
+	// Assigning to someone else needs plans:assign and an athlete who accepted the user as coach
+	g.POST("/:id/assign", func(c *gin.Context) {
+		p, ok := visibleProgramme(c)
+		if !ok {
+			return
+		}
+		form := new(ProgrammeAssignForm)
+		if err := c.ShouldBindJSON(form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		start, err := time.Parse(time.DateOnly, form.StartDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "start_date must be formatted as YYYY-MM-DD"})
+			return
+		}
+		u, _ := c.Get("user")
+		user := u.(*models.User)
+		a := &models.ProgrammeAssignment{
+			ProgrammeID: p.ID,
+			UserID:      user.ID,
+			AssignedBy:  &user.ID,
+			StartAt:     start,
+		}
+		if form.UserID != nil && *form.UserID != user.ID {
+			allowed, err := models.HasPermission(user.ID, nil, "plans:assign")
+			if err != nil || !allowed {
+				c.JSON(http.StatusForbidden, gin.H{"error": "permission denied"})
+				return
+			}
+			coach, err := models.IsCoachOf(user.ID, *form.UserID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if !coach {
+				c.JSON(http.StatusForbidden, gin.H{"error": "permission denied"})
+				return
+			}
+			a.UserID = *form.UserID
+		}
+		if a.Sessions, err = generateProgramme(p, start); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx, _ := c.Get("ctx")
+		if err := a.Create(ctx.(context.Context)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, a)
+	})
+
+	g.GET("/:id/assignments/:assignment_id", func(c *gin.Context) {
+		id, ok := idParam(c, "id")
+		if !ok {
+			return
+		}
+		assignmentID, ok := idParam(c, "assignment_id")
+		if !ok {
+			return
+		}
+		p, err := models.GetProgramme(id)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		a, err := models.GetProgrammeAssignment(assignmentID)
+		if err != nil || a.ProgrammeID != p.ID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "assignment not found"})
+			return
+		}
+		u, _ := c.Get("user")
+		userID := u.(*models.User).ID
+		if a.UserID != userID && p.UserID != userID && (a.AssignedBy == nil || *a.AssignedBy != userID) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "permission denied"})
+			return
+		}
+		c.JSON(http.StatusOK, a)
+	})
+}
+
+// visibleProgramme loads the :id programme and writes the error response when it's missing or hidden from the user
+func visibleProgramme(c *gin.Context) (*models.Programme, bool) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return nil, false
+	}
+	p, err := models.GetProgramme(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	u, _ := c.Get("user")
+	if !allowed(c, models.CanView, u.(*models.User).ID, &p.UserID, p.OrganizationID, p.Visibility) {
+		return nil, false
+	}
+	return p, true
+}
+
+// ownProgramme loads the :id programme and writes the error response when it's missing or the user is neither
+// its owner nor allowed to update the library of its organization
+func ownProgramme(c *gin.Context) (*models.Programme, bool) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return nil, false
+	}
+	p, err := models.GetProgramme(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	u, _ := c.Get("user")
+	if !allowed(c, models.CanEdit, u.(*models.User).ID, &p.UserID, p.OrganizationID, p.Visibility) {
+		return nil, false
+	}
+	return p, true
+}
+
+// bindProgramme validates the form and checks every referenced plan is visible to the user,
+// current is the programme being updated or nil on create
+func bindProgramme(c *gin.Context, current *models.Programme) (*models.Programme, bool) {
+	form := new(ProgrammeForm)
+	if err := c.ShouldBindJSON(form); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	var currentVisibility string
+	var currentOrganizationID *uuid.UUID
+	if current != nil {
+		currentVisibility, currentOrganizationID = current.Visibility, current.OrganizationID
+	}
+	visibility, organizationID, ok := bindVisibility(c, form.Visibility, form.Public, form.OrganizationID, currentVisibility, currentOrganizationID)
+	if !ok {
+		return nil, false
+	}
+	p := &models.Programme{
+		Name:           form.Name,
+		Description:    form.Description,
+		Visibility:     visibility,
+		OrganizationID: organizationID,
+		Weeks:          form.Weeks,
+	}
+	for _, d := range form.Days {
+		p.Days = append(p.Days, models.ProgrammeDay{Week: d.Week, Day: d.Day, PlanID: d.PlanID, Note: d.Note})
+	}
+	for _, pr := range form.Progressions {
+		p.Progressions = append(p.Progressions, models.Progression{
+			PlanID:     pr.PlanID,
+			ExerciseID: pr.ExerciseID,
+			Target:     pr.Target,
+			Amount:     pr.Amount,
+			Per:        pr.Per,
+			Max:        pr.Max,
+		})
+	}
+	if err := p.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	plans, err := models.GetPlansByID(p.PlanIDs()...)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	u, _ := c.Get("user")
+	for _, id := range p.PlanIDs() {
+		plan, ok := plans[id]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "plan not found"})
+			return nil, false
+		}
+		if !allowed(c, models.CanView, u.(*models.User).ID, &plan.UserID, plan.OrganizationID, plan.Visibility) {
+			return nil, false
+		}
+	}
+	return p, true
+}
+
+func generateProgramme(p *models.Programme, start time.Time) ([]models.ProgrammeSession, error) {
+	plans, err := models.GetPlansByID(p.PlanIDs()...)
+	if err != nil {
+		return nil, err
+	}
+	return programmes.Generate(p, plans, start)
+}
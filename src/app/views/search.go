@@ -0,0 +1,39 @@
+package views
+
+import (
+	"coachwise/src/app/auth"
+	"coachwise/src/app/models"
+	"net/http"
+	"strconv"
+
+	database "github.com/socious-io/pkg_database"
+
+	"github.com/gin-gonic/gin"
+)
+
+func searchGroup(router *gin.Engine) {
+	g := router.Group("search")
+	g.Use(auth.LoginRequired())
+
+	g.GET("", paginate(), func(c *gin.Context) {
+		p := c.MustGet("paginate").(database.Paginate)
+		q := queryValue(c, "q")
+		if q == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+			return
+		}
+		searchType := filterValue(p, "type")
+		if searchType != nil && *searchType != "exercise" && *searchType != "plan" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "type must be exercise or plan"})
+			return
+		}
+		u, _ := c.Get("user")
+		results, total, err := models.Search(u.(*models.User).ID, *q, searchType, p.Limit, p.Offet)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("X-Total-Count", strconv.Itoa(total))
+		c.JSON(http.StatusOK, results)
+	})
+}
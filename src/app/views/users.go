@@ -2,8 +2,23 @@ package views
 
 import (
 	"coachwise/src/app/auth"
+	"coachwise/src/app/mailer"
 	"coachwise/src/app/models"
+	"coachwise/src/app/sms"
+	"coachwise/src/config"
+	"coachwise/src/utils"
+	"context"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"net/mail"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	database "github.com/socious-io/pkg_database"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -13,7 +28,7 @@ func userGroup(router *gin.Engine) {
 	g := router.Group("users")
 	g.Use(auth.LoginRequired())
 
-	g.GET("/", func(c *gin.Context) {
+	profile := func(c *gin.Context) {
 		userID, _ := c.Get("user_id")
 		u, err := models.GetUser(uuid.MustParse(userID.(string)))
 		if err != nil {
@@ -21,5 +36,280 @@ func userGroup(router *gin.Engine) {
 			return
 		}
 		c.JSON(http.StatusOK, u)
+	}
+	g.GET("/", profile)
+	g.GET("/me", profile)
+
+	g.DELETE("/me", func(c *gin.Context) {
+		form := new(auth.DeleteAccountForm)
+		if err := c.ShouldBindJSON(form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx, _ := c.Get("ctx")
+		u, _ := c.Get("user")
+		user := u.(*models.User)
+		if err := reauthenticate(ctx.(context.Context), user, form.Password, form.Code); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := user.Delete(ctx.(context.Context)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{
+			"message":    "success",
+			"purge_at":   user.DeletedAt.Add(config.DeletionGracePeriod()),
+			"deleted_at": user.DeletedAt,
+		})
+	})
+
+	// Workout totals, streaks and progress on assigned plans, limit caps the assignments and exercises listed
+	g.GET("/me/stats", paginate(), func(c *gin.Context) {
+		p := c.MustGet("paginate").(database.Paginate)
+		u, _ := c.Get("user")
+		stats, err := models.GetUserStats(u.(*models.User).ID, p.Limit)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, stats)
+	})
+
+	// Current personal records, filtered by exercise_id and kind
+	g.GET("/me/records", paginate(), func(c *gin.Context) {
+		p := c.MustGet("paginate").(database.Paginate)
+		kind, ok := recordKind(c)
+		if !ok {
+			return
+		}
+		var exerciseID *uuid.UUID
+		if value := queryValue(c, "exercise_id"); value != nil {
+			id, err := uuid.Parse(*value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "exercise_id must be a uuid"})
+				return
+			}
+			exerciseID = &id
+		}
+		u, _ := c.Get("user")
+		records, total, err := models.GetPersonalRecords(u.(*models.User).ID, exerciseID, kind, p.Limit, p.Offet)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("X-Total-Count", strconv.Itoa(total))
+		c.JSON(http.StatusOK, records)
+	})
+
+	g.GET("/me/export", func(c *gin.Context) {
+		u, _ := c.Get("user")
+		user := u.(*models.User)
+		export, err := user.Export()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		archive, err := utils.Zip(
+			utils.ZipFile{Name: "profile.json", Body: export.Profile},
+			utils.ZipFile{Name: "exercises.json", Body: export.Exercises},
+			utils.ZipFile{Name: "plans.json", Body: export.Plans},
+			utils.ZipFile{Name: "assigned_plans.json", Body: export.AssignedPlans},
+			utils.ZipFile{Name: "param_logs.json", Body: export.ParamLogs},
+			utils.ZipFile{Name: "media.json", Body: export.Media},
+			utils.ZipFile{Name: "workout_sessions.json", Body: export.WorkoutSessions},
+			utils.ZipFile{Name: "personal_records.json", Body: export.PersonalRecords},
+			utils.ZipFile{Name: "goals.json", Body: export.Goals},
+			utils.ZipFile{Name: "comments.json", Body: export.Comments},
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		filename := fmt.Sprintf("coachwise-%s-%s.zip", user.Username, time.Now().Format("20060102"))
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		c.Data(http.StatusOK, "application/zip", archive)
+	})
+
+	g.POST("/me/email", func(c *gin.Context) {
+		form := new(auth.EmailChangeForm)
+		if err := c.ShouldBindJSON(form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		email := strings.ToLower(strings.TrimSpace(form.Email))
+		if _, err := mail.ParseAddress(email); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid email"})
+			return
+		}
+		ctx, _ := c.Get("ctx")
+		u, _ := c.Get("user")
+		user := u.(*models.User)
+		if err := reauthenticate(ctx.(context.Context), user, form.Password, form.Code); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if user.Email != nil && email == *user.Email {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "email is not changed"})
+			return
+		}
+		if _, err := models.GetUserByEmail(email); err == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": models.ErrEmailTaken.Error()})
+			return
+		}
+
+		otp, err := stageContactChange(ctx.(context.Context), user, "EMAIL_CHANGE", email)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := mailer.Send(email, "Confirm your new email", fmt.Sprintf("Your coachwise verification code is %d", otp.Code)); err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "message": "Couldn't send email"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "success"})
 	})
+
+	g.POST("/me/email/verify", func(c *gin.Context) {
+		form := new(auth.ChangeConfirmForm)
+		if err := c.ShouldBindJSON(form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx, _ := c.Get("ctx")
+		u, _ := c.Get("user")
+		user := u.(*models.User)
+		otp, err := confirmContactChange(ctx.(context.Context), user, "EMAIL_CHANGE", form.Code)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		oldEmail := user.Email
+		if err := user.UpdateEmail(ctx.(context.Context), *otp.Target); err != nil {
+			// Another account may have claimed the address after the code was sent
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		// the change is applied already, a failed notice is only logged
+		if oldEmail != nil {
+			if err := mailer.Send(*oldEmail, "Your email has been changed", fmt.Sprintf("The email of your coachwise account has been changed to %s", *user.Email)); err != nil {
+				log.Printf("Couldn't send email change notice to %s: %v\n", *oldEmail, err)
+			}
+		}
+		c.JSON(http.StatusOK, user)
+	})
+
+	g.POST("/me/phone", func(c *gin.Context) {
+		form := new(auth.PhoneChangeForm)
+		if err := c.ShouldBindJSON(form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		phone, err := auth.NormalizePhone(form.Phone)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx, _ := c.Get("ctx")
+		u, _ := c.Get("user")
+		user := u.(*models.User)
+		if err := reauthenticate(ctx.(context.Context), user, form.Password, form.Code); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if user.Phone != nil && phone == *user.Phone {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "phone is not changed"})
+			return
+		}
+		if _, err := models.GetUserByPhone(phone); err == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": models.ErrPhoneTaken.Error()})
+			return
+		}
+
+		otp, err := stageContactChange(ctx.(context.Context), user, "PHONE_CHANGE", phone)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := sms.Send(ctx.(context.Context), phone, fmt.Sprintf("Your coachwise verification code is %d", otp.Code)); err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "message": "Couldn't send SMS"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "success"})
+	})
+
+	g.POST("/me/phone/verify", func(c *gin.Context) {
+		form := new(auth.ChangeConfirmForm)
+		if err := c.ShouldBindJSON(form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx, _ := c.Get("ctx")
+		u, _ := c.Get("user")
+		user := u.(*models.User)
+		otp, err := confirmContactChange(ctx.(context.Context), user, "PHONE_CHANGE", form.Code)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		oldPhone := user.Phone
+		if err := user.UpdatePhone(ctx.(context.Context), *otp.Target); err != nil {
+			// Another account may have claimed the number after the code was sent
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		notice := fmt.Sprintf("The phone of your coachwise account has been changed to %s", *user.Phone)
+		// the change is applied already, a failed notice is only logged
+		if oldPhone != nil {
+			if err := sms.Send(ctx.(context.Context), *oldPhone, notice); err != nil {
+				log.Printf("Couldn't send phone change notice to %s: %v\n", *oldPhone, err)
+			}
+		} else if user.Email != nil {
+			if err := mailer.Send(*user.Email, "Your phone has been changed", notice); err != nil {
+				log.Printf("Couldn't send phone change notice to %s: %v\n", *user.Email, err)
+			}
+		}
+		c.JSON(http.StatusOK, user)
+	})
+}
+
+// stageContactChange sends a code to the new address, the change is applied by confirmContactChange
+func stageContactChange(ctx context.Context, user *models.User, perpose, target string) (*models.OTP, error) {
+	if otp, err := models.GetOTPByUserID(user.ID); err == nil {
+		if otp.Perpose == perpose && !otp.IsVerified && time.Now().Before(otp.ExpiresAt) {
+			return nil, errors.New("can't send code before expiration")
+		}
+	}
+	return models.NewTargetOTP(ctx, user.ID, perpose, target)
+}
+
+func confirmContactChange(ctx context.Context, user *models.User, perpose string, code int) (*models.OTP, error) {
+	otp := &models.OTP{
+		UserID:  user.ID,
+		Code:    code,
+		Perpose: perpose,
+	}
+	if err := otp.VerifyPerpose(ctx); err != nil {
+		return nil, err
+	}
+	if !otp.IsVerified || otp.Target == nil {
+		return nil, errors.New("code does not found or it is wrong")
+	}
+	return otp, nil
+}
+
+// recordKind reads the kind query of personal record listings
+func recordKind(c *gin.Context) (*string, bool) {
+	kind := queryValue(c, "kind")
+	if kind == nil {
+		return nil, true
+	}
+	value := strings.ToUpper(*kind)
+	if !slices.Contains(models.RecordKinds, value) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be " + strings.Join(models.RecordKinds, ", ")})
+		return nil, false
+	}
+	return &value, true
 }
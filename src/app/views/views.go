@@ -5,6 +5,17 @@ import "github.com/gin-gonic/gin"
 func Init(r *gin.Engine) {
 	authGroup(r)
 	userGroup(r)
+	calendarGroup(r)
+	coachingGroup(r)
+	organizationGroup(r)
+	paramGroup(r)
+	goalGroup(r)
+	commentGroup(r)
 	rootGroup(r)
 	exerciseGroup(r)
+	adminGroup(r)
+	workoutGroup(r)
+	planGroup(r)
+	programmeGroup(r)
+	searchGroup(r)
 }
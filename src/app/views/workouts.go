@@ -0,0 +1,184 @@
+package views
+
+import (
+	"coachwise/src/app/auth"
+	"coachwise/src/app/events"
+	"coachwise/src/app/models"
+	"coachwise/src/utils"
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	database "github.com/socious-io/pkg_database"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func workoutGroup(router *gin.Engine) {
+	g := router.Group("workouts")
+	g.Use(auth.LoginRequired())
+
+	g.POST("", func(c *gin.Context) {
+		form := new(WorkoutSessionForm)
+		if err := c.ShouldBindJSON(form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		u, _ := c.Get("user")
+		ws := &models.WorkoutSession{
+			UserID: u.(*models.User).ID,
+			PlanID: form.PlanID,
+			Note:   form.Note,
+		}
+		if form.PlanAssigneeID != nil {
+			pa, err := models.GetPlanAssignee(*form.PlanAssigneeID)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			if pa.UserID != ws.UserID {
+				c.JSON(http.StatusForbidden, gin.H{"error": "plan is not assigned to you"})
+				return
+			}
+			ws.PlanAssigneeID = &pa.ID
+			ws.PlanID = &pa.PlanID
+		} else if ws.PlanID != nil && !usablePlan(c, *ws.PlanID) {
+			return
+		}
+		ctx, _ := c.Get("ctx")
+		if err := ws.Create(ctx.(context.Context)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, ws)
+	})
+
+	g.GET("", paginate(), func(c *gin.Context) {
+		p := c.MustGet("paginate").(database.Paginate)
+		u, _ := c.Get("user")
+		sessions, total, err := models.GetWorkoutSessions(u.(*models.User).ID, p.Limit, p.Offet)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("X-Total-Count", strconv.Itoa(total))
+		c.JSON(http.StatusOK, sessions)
+	})
+
+	g.GET("/:id", func(c *gin.Context) {
+		ws, ok := ownWorkoutSession(c)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, ws)
+	})
+
+	g.POST("/:id/sets", func(c *gin.Context) {
+		ws, ok := ownWorkoutSession(c)
+		if !ok {
+			return
+		}
+		if ws.FinishedAt != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "workout session is finished"})
+			return
+		}
+		form := new(SetLogForm)
+		if err := c.ShouldBindJSON(form); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if form.RepCount == nil && form.Duration == nil && form.Load == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "one of rep_count, duration or load is required"})
+			return
+		}
+		if (form.RepCount != nil && *form.RepCount < 0) || (form.Duration != nil && *form.Duration < 0) || (form.Load != nil && *form.Load < 0) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "rep_count, duration and load can not be negative"})
+			return
+		}
+		if form.RPE != nil && (*form.RPE < 1 || *form.RPE > 10) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "rpe must be between 1 and 10"})
+			return
+		}
+		if !loggableExercise(c, ws, form.ExerciseID) {
+			return
+		}
+		if form.Load != nil && form.Unit == nil {
+			unit := "KG"
+			form.Unit = &unit
+		}
+		l := new(models.SetLog)
+		utils.Copy(form, l)
+		l.SessionID = ws.ID
+		ctx, _ := c.Get("ctx")
+		if err := l.Create(ctx.(context.Context), ws.UserID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		for _, r := range l.Records {
+			events.Publish(events.PersonalRecord, ws.UserID, r)
+		}
+		u, _ := c.Get("user")
+		notifyGoals(u.(*models.User), l.Goals)
+		c.JSON(http.StatusCreated, l)
+	})
+
+	g.POST("/:id/finish", func(c *gin.Context) {
+		ws, ok := ownWorkoutSession(c)
+		if !ok {
+			return
+		}
+		form := new(WorkoutFinishForm)
+		c.ShouldBindJSON(form)
+		ws.Note = form.Note
+		ctx, _ := c.Get("ctx")
+		if err := ws.Finish(ctx.(context.Context)); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "workout session is already finished"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, ws)
+	})
+}
+
+// ownWorkoutSession loads the :id session and writes the error response when it's missing or not owned by the user
+func ownWorkoutSession(c *gin.Context) (*models.WorkoutSession, bool) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return nil, false
+	}
+	ws, err := models.GetWorkoutSession(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	u, _ := c.Get("user")
+	if ws.UserID != u.(*models.User).ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "permission denied"})
+		return nil, false
+	}
+	return ws, true
+}
+
+// loggableExercise writes the error response unless the exercise is visible to the user or part of
+// the plan assigned to them for this session
+func loggableExercise(c *gin.Context, ws *models.WorkoutSession, id uuid.UUID) bool {
+	if ws.PlanAssigneeID != nil && ws.PlanID != nil {
+		p, err := models.GetPlan(*ws.PlanID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return false
+		}
+		for _, e := range p.Exercises() {
+			if e.ExerciseID == id {
+				return true
+			}
+		}
+	}
+	return usableExercise(c, id)
+}
@@ -1,7 +1,10 @@
 package config
 
 import (
+	"errors"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -17,6 +20,19 @@ type ConfigType struct {
 		SqlDir     string `mapstructure:"sqldir"`
 		Migrations string `mapstructure:"migrations"`
 	} `mapstructure:"database"`
+	SMS struct {
+		Provider   string `mapstructure:"provider"`
+		BaseURL    string `mapstructure:"base_url"`
+		AccountSID string `mapstructure:"account_sid"`
+		AuthToken  string `mapstructure:"auth_token"`
+		From       string `mapstructure:"from"`
+	} `mapstructure:"sms"`
+	Accounts struct {
+		DeletionGraceDays int `mapstructure:"deletion_grace_days"`
+	} `mapstructure:"accounts"`
+	App struct {
+		URL string `mapstructure:"url"`
+	} `mapstructure:"app"`
 }
 
 func Init(configPath string) {
@@ -35,3 +51,20 @@ func Init(configPath string) {
 
 	log.Printf("Using config file: %s\n", viper.ConfigFileUsed())
 }
+
+// AppURL is the public address links in generated documents point at, without a trailing slash,
+// it has to be configured since the request host is set by the client
+func AppURL() (string, error) {
+	if Config.App.URL == "" {
+		return "", errors.New("app.url is not configured")
+	}
+	return strings.TrimSuffix(Config.App.URL, "/"), nil
+}
+
+func DeletionGracePeriod() time.Duration {
+	days := Config.Accounts.DeletionGraceDays
+	if days < 1 {
+		days = 30
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
@@ -0,0 +1,3 @@
+INSERT INTO admin_audit_logs (admin_id, action, target_type, target_id, reason, outcome, error)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *
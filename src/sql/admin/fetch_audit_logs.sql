@@ -0,0 +1 @@
+SELECT * FROM admin_audit_logs WHERE id IN (?)
\ No newline at end of file
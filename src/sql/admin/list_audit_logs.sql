@@ -0,0 +1,6 @@
+SELECT l.*, COUNT(*) OVER () AS total_count
+FROM admin_audit_logs l
+WHERE ($1::uuid IS NULL OR l.target_id=$1)
+  AND ($2::uuid IS NULL OR l.admin_id=$2)
+ORDER BY l.created_at DESC
+LIMIT $3 OFFSET $4
@@ -0,0 +1,8 @@
+SELECT u.*, COUNT(*) OVER () AS total_count
+FROM users u
+WHERE ($1::text IS NULL OR u.status::text=$1)
+  AND ($2::text IS NULL OR u.username ILIKE '%' || $2 || '%' OR u.email ILIKE '%' || $2 || '%'
+    OR u.phone ILIKE '%' || $2 || '%' OR u.first_name ILIKE '%' || $2 || '%' OR u.last_name ILIKE '%' || $2 || '%')
+  AND ($3::boolean IS NULL OR (u.deleted_at IS NOT NULL)=$3)
+ORDER BY u.created_at DESC
+LIMIT $4 OFFSET $5
@@ -0,0 +1,13 @@
+SELECT pa.id, 'PLAN' AS kind, pa.plan_id, p.name AS title, NULL::integer AS week, NULL::integer AS day,
+  pa.due_at AS scheduled_at, NULL::jsonb AS plan, pa.updated_at
+FROM plan_assignees pa
+JOIN plans p ON p.id=pa.plan_id
+WHERE pa.user_id=$1 AND pa.due_at IS NOT NULL AND pa.due_at >= $2
+UNION ALL
+SELECT ps.id, 'PROGRAMME' AS kind, ps.plan_id, pr.name AS title, ps.week, ps.day,
+  ps.scheduled_at, ps.plan, ps.created_at AS updated_at
+FROM programme_sessions ps
+JOIN programme_assignments a ON a.id=ps.assignment_id
+JOIN programmes pr ON pr.id=a.programme_id
+WHERE a.user_id=$1 AND ps.scheduled_at >= $2
+ORDER BY scheduled_at
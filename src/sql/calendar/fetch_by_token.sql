@@ -0,0 +1,3 @@
+SELECT ct.* FROM calendar_tokens ct
+JOIN users u ON u.id=ct.user_id
+WHERE ct.token=$1 AND u.deleted_at IS NULL AND u.status<>'SUSPENDED'
@@ -0,0 +1,4 @@
+INSERT INTO calendar_tokens (user_id, token)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE SET user_id=EXCLUDED.user_id
+RETURNING *
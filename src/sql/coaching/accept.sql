@@ -0,0 +1,8 @@
+UPDATE coach_athletes SET
+  status='ACCEPTED',
+  athlete_id=$2,
+  responded_at=NOW(),
+  updated_at=NOW()
+WHERE id=$1 AND code=$4 AND status='PENDING' AND expires_at > NOW()
+  AND (athlete_id=$2 OR email=$3)
+RETURNING *
@@ -0,0 +1,7 @@
+UPDATE coach_athletes SET
+  status='DECLINED',
+  athlete_id=$2,
+  responded_at=NOW(),
+  updated_at=NOW()
+WHERE id=$1 AND status='PENDING' AND (athlete_id=$2 OR email=$3)
+RETURNING *
@@ -0,0 +1,37 @@
+SELECT ca.*,
+  (SELECT json_build_object(
+      'id', u.id,
+      'username', u.username,
+      'first_name', u.first_name,
+      'last_name', u.last_name,
+      'email', u.email,
+      'avatar_id', u.avatar_id
+    ) FROM users u WHERE u.id=ca.coach_id
+  ) AS coach,
+  (SELECT json_build_object(
+      'id', u.id,
+      'username', u.username,
+      'first_name', u.first_name,
+      'last_name', u.last_name,
+      'email', u.email,
+      'avatar_id', u.avatar_id
+    ) FROM users u WHERE u.id=ca.athlete_id AND ca.status='ACCEPTED'
+  ) AS athlete,
+  (SELECT
+    jsonb_agg(json_build_object(
+        'id', pa.id,
+        'plan_id', pa.plan_id,
+        'name', p.name,
+        'due_at', pa.due_at,
+        'created_at', pa.created_at
+      ) ORDER BY pa.due_at NULLS LAST, pa.created_at)
+      FROM plan_assignees pa
+      JOIN plans p ON p.id=pa.plan_id
+      WHERE pa.user_id=ca.athlete_id AND p.user_id=ca.coach_id AND ca.status='ACCEPTED'
+  ) AS assigned_plans,
+  (SELECT MAX(COALESCE(ws.finished_at, ws.started_at))
+    FROM workout_sessions ws
+    WHERE ws.user_id=ca.athlete_id AND ca.status='ACCEPTED'
+  ) AS last_activity_at
+FROM coach_athletes ca
+WHERE ca.id IN (?)
@@ -0,0 +1,9 @@
+INSERT INTO coach_athletes (coach_id, email, code)
+VALUES ($1, $2, $3)
+ON CONFLICT (coach_id, email) WHERE status IN ('PENDING', 'ACCEPTED')
+DO UPDATE SET
+  code=EXCLUDED.code,
+  expires_at=EXCLUDED.expires_at,
+  updated_at=NOW()
+WHERE coach_athletes.status='PENDING'
+RETURNING *
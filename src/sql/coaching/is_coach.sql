@@ -0,0 +1,4 @@
+SELECT EXISTS (
+  SELECT 1 FROM coach_athletes
+  WHERE coach_id=$1 AND athlete_id=$2 AND status='ACCEPTED'
+) AS coach
@@ -0,0 +1,7 @@
+SELECT ca.id, COUNT(*) OVER () AS total_count
+FROM coach_athletes ca
+JOIN users u ON u.id=ca.coach_id
+WHERE ca.status='PENDING' AND ca.expires_at > NOW() AND (ca.athlete_id=$1 OR ca.email=$2)
+  AND u.deleted_at IS NULL
+ORDER BY ca.created_at DESC
+LIMIT $3 OFFSET $4
@@ -0,0 +1,5 @@
+UPDATE coach_athletes SET
+  status='REVOKED',
+  updated_at=NOW()
+WHERE id=$1 AND (coach_id=$2 OR athlete_id=$2) AND status IN ('PENDING', 'ACCEPTED')
+RETURNING *
@@ -0,0 +1,6 @@
+SELECT ca.id, COUNT(*) OVER () AS total_count
+FROM coach_athletes ca
+JOIN users u ON u.id=ca.athlete_id
+WHERE ca.coach_id=$1 AND ca.status='ACCEPTED' AND u.deleted_at IS NULL
+ORDER BY ca.responded_at DESC
+LIMIT $2 OFFSET $3
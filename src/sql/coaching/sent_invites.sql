@@ -0,0 +1,5 @@
+SELECT ca.id, COUNT(*) OVER () AS total_count
+FROM coach_athletes ca
+WHERE ca.coach_id=$1 AND ca.status='PENDING'
+ORDER BY ca.created_at DESC
+LIMIT $2 OFFSET $3
@@ -0,0 +1,3 @@
+INSERT INTO comments (target_type, target_id, parent_id, user_id, body, depth)
+VALUES ($1, $2, $3, $4, $5, COALESCE((SELECT depth + 1 FROM comments WHERE id=$3), 0))
+RETURNING id
@@ -0,0 +1,3 @@
+UPDATE comments SET deleted_at=NOW(), updated_at=NOW()
+WHERE id=$1 AND deleted_at IS NULL
+RETURNING id
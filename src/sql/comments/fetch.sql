@@ -0,0 +1,27 @@
+SELECT c.id, c.target_type, c.target_id, c.parent_id, c.user_id, c.depth,
+  CASE WHEN c.deleted_at IS NULL THEN c.body END AS body,
+  c.edited_at, c.deleted_at, c.created_at, c.updated_at,
+  (SELECT json_build_object(
+      'id', u.id,
+      'username', u.username,
+      'first_name', u.first_name,
+      'last_name', u.last_name,
+      'avatar_id', u.avatar_id
+    ) FROM users u WHERE u.id=c.user_id
+  ) AS author,
+  COALESCE((SELECT
+    jsonb_agg(json_build_object(
+        'id', u.id,
+        'username', u.username,
+        'first_name', u.first_name,
+        'last_name', u.last_name,
+        'avatar_id', u.avatar_id
+      ) ORDER BY u.username)
+      FROM comment_mentions cm
+      JOIN users u ON u.id=cm.user_id
+      WHERE cm.comment_id=c.id AND c.deleted_at IS NULL
+  ), '[]') AS mentions,
+  (SELECT COUNT(*) FROM comments r WHERE r.parent_id=c.id) AS replies,
+  (SELECT COUNT(*) FROM comment_revisions cr WHERE cr.comment_id=c.id) AS revisions
+FROM comments c
+WHERE c.id IN (?)
@@ -0,0 +1,13 @@
+WITH RECURSIVE thread AS (
+  SELECT id, ARRAY[to_char(created_at, 'YYYYMMDDHH24MISSUS') || id::text] AS path
+  FROM comments
+  WHERE target_type=$1 AND target_id=$2 AND parent_id IS NULL
+  UNION ALL
+  SELECT c.id, t.path || (to_char(c.created_at, 'YYYYMMDDHH24MISSUS') || c.id::text)
+  FROM comments c
+  JOIN thread t ON t.id=c.parent_id
+)
+SELECT id, COUNT(*) OVER () AS total_count
+FROM thread
+ORDER BY path
+LIMIT $3 OFFSET $4
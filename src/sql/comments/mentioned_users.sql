@@ -0,0 +1 @@
+SELECT id FROM users WHERE LOWER(username) = ANY($1)
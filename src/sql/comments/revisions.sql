@@ -0,0 +1,5 @@
+SELECT id, comment_id, body, written_at, replaced_at, COUNT(*) OVER () AS total_count
+FROM comment_revisions
+WHERE comment_id=$1
+ORDER BY replaced_at DESC
+LIMIT $2 OFFSET $3
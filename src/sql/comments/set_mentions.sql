@@ -0,0 +1,7 @@
+WITH removed AS (
+  DELETE FROM comment_mentions WHERE comment_id=$1 AND user_id <> ALL($2::uuid[])
+)
+INSERT INTO comment_mentions (comment_id, user_id)
+SELECT $1, unnest($2::uuid[])
+ON CONFLICT DO NOTHING
+RETURNING user_id
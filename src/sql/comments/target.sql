@@ -0,0 +1,9 @@
+SELECT NULL::uuid AS athlete_id, id AS plan_id, NULL::uuid AS coach_id FROM plans WHERE $1::text='PLAN' AND id=$2
+UNION ALL
+SELECT pa.user_id, pa.plan_id, p.user_id FROM plan_assignees pa
+JOIN plans p ON p.id=pa.plan_id
+WHERE $1::text='PLAN_ASSIGNMENT' AND pa.id=$2
+UNION ALL
+SELECT user_id, NULL, NULL FROM workout_sessions WHERE $1::text='WORKOUT_SESSION' AND id=$2
+UNION ALL
+SELECT user_id, NULL, NULL FROM param_logs WHERE $1::text='PARAM_LOG' AND id=$2
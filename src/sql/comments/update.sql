@@ -0,0 +1,9 @@
+WITH previous AS (
+  INSERT INTO comment_revisions (comment_id, body, written_at)
+  SELECT id, body, COALESCE(edited_at, created_at) FROM comments
+  WHERE id=$1 AND deleted_at IS NULL
+  RETURNING comment_id
+)
+UPDATE comments SET body=$2, edited_at=NOW(), updated_at=NOW()
+WHERE id IN (SELECT comment_id FROM previous)
+RETURNING id
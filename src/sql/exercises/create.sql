@@ -1 +1,12 @@
-INSERT INTO exercises (user_id, name, description, public) VALUES ($1, $2, $3, $4) RETURNING *
\ No newline at end of file
+INSERT INTO exercises (user_id, name, description, visibility, organization_id, sports, primary_muscles, secondary_muscles, equipment, difficulty, language, forked_from)
+VALUES (
+  $1, $2, $3, $4, $12,
+  COALESCE($5::text[]::sports[], '{}'),
+  COALESCE($6::text[]::muscle_groups[], '{}'),
+  COALESCE($7::text[]::muscle_groups[], '{}'),
+  COALESCE($8::text[]::equipment_types[], '{}'),
+  $9,
+  COALESCE($10::regconfig, 'english'),
+  $11
+)
+RETURNING *
@@ -0,0 +1,9 @@
+INSERT INTO exercise_revisions (exercise_id, revision, snapshot)
+SELECT e.id,
+  (SELECT COALESCE(MAX(revision), 0) + 1 FROM exercise_revisions WHERE exercise_id=e.id),
+  to_jsonb(e) - 'search_vector' || jsonb_build_object(
+    'sets', COALESCE((SELECT jsonb_agg(to_jsonb(s) ORDER BY s.set_number) FROM sets s WHERE s.exercise_id=e.id), '[]')
+  )
+FROM exercises e
+WHERE e.id=$1
+RETURNING id
@@ -1,2 +1,10 @@
-INSERT INTO  sets (exercise_id, name, set_number, rest_time, rep_count, duration) 
-VALUES (:exercise_id, :name, :set_number, :rest_time, :rep_count, :duration)
+INSERT INTO  sets (
+  exercise_id, name, set_number, set_type, rest_time, rep_count, duration,
+  rep_min, rep_max, interval, rounds, drops, drop_percent,
+  load, load_unit, percent_1rm, rpe, rir, tempo, distance, distance_unit, grade, side
+)
+VALUES (
+  :exercise_id, :name, :set_number, :set_type, :rest_time, :rep_count, :duration,
+  :rep_min, :rep_max, :interval, :rounds, :drops, :drop_percent,
+  :load, :load_unit, :percent_1rm, :rpe, :rir, :tempo, :distance, :distance_unit, :grade, :side
+)
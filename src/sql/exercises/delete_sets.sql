@@ -0,0 +1 @@
+DELETE FROM sets WHERE exercise_id=$1 AND set_number > $2
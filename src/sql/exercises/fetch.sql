@@ -1,4 +1,4 @@
-SELECT e.*,
+SELECT e.*, e.visibility='PUBLIC' AS public,
   (SELECT
     jsonb_agg(json_build_object(
         'id', s.id,
@@ -6,12 +6,30 @@ SELECT e.*,
         'duration', s.duration,
         'rep_count', s.rep_count,
         'rest_time', s.rest_time,
+        'set_type', s.set_type,
+        'rep_min', s.rep_min,
+        'rep_max', s.rep_max,
+        'interval', s.interval,
+        'rounds', s.rounds,
+        'drops', s.drops,
+        'drop_percent', s.drop_percent,
+        'load', s.load,
+        'load_unit', s.load_unit,
+        'percent_1rm', s.percent_1rm,
+        'rpe', s.rpe,
+        'rir', s.rir,
+        'tempo', s.tempo,
+        'distance', s.distance,
+        'distance_unit', s.distance_unit,
+        'grade', s.grade,
+        'side', s.side,
         'set_number', s.set_number,
         'created_at', s.created_at,
         'updated_at', s.updated_at
       ))
       FROM sets s
       WHERE s.exercise_id=e.id
-  ) AS sets
+  ) AS sets,
+  (SELECT COUNT(*) FROM exercises f WHERE f.forked_from=e.id) AS forks
 FROM exercises e
 WHERE id IN (?)
\ No newline at end of file
@@ -0,0 +1 @@
+SELECT * FROM exercise_revisions WHERE id IN (?)
@@ -0,0 +1,7 @@
+INSERT INTO exercises (
+  user_id, name, description, visibility, sports, primary_muscles, secondary_muscles, equipment, difficulty, language, forked_from
+)
+SELECT $2, name, description, 'PRIVATE', sports, primary_muscles, secondary_muscles, equipment, difficulty, language, id
+FROM exercises
+WHERE id=$1
+RETURNING *
@@ -0,0 +1,12 @@
+INSERT INTO sets (
+  exercise_id, name, set_number, set_type, rest_time, rep_count, duration,
+  rep_min, rep_max, interval, rounds, drops, drop_percent,
+  load, load_unit, percent_1rm, rpe, rir, tempo, distance, distance_unit, grade, side
+)
+SELECT
+  $2, name, set_number, set_type, rest_time, rep_count, duration,
+  rep_min, rep_max, interval, rounds, drops, drop_percent,
+  load, load_unit, percent_1rm, rpe, rir, tempo, distance, distance_unit, grade, side
+FROM sets
+WHERE exercise_id=$1
+ORDER BY set_number
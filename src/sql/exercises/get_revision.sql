@@ -0,0 +1 @@
+SELECT * FROM exercise_revisions WHERE exercise_id=$1 AND revision=$2
@@ -0,0 +1,21 @@
+WITH queries AS (
+  SELECT l.language, websearch_to_tsquery(l.language, immutable_unaccent($10)) AS query
+  FROM unnest($12::regconfig[]) AS l(language)
+  WHERE $10::text IS NOT NULL
+)
+SELECT e.id, COUNT(*) OVER () AS total_count
+FROM exercises e
+WHERE (e.visibility='PUBLIC' OR e.user_id=$1
+    OR (e.visibility='ORG' AND e.organization_id IN (SELECT ur.organization_id FROM user_roles ur WHERE ur.user_id=$1)))
+  AND ($2::text IS NULL OR e.visibility::text=$2)
+  AND ($3::text[] IS NULL OR e.sports && $3::text[]::sports[])
+  AND ($4::text[] IS NULL OR (e.primary_muscles || e.secondary_muscles) && $4::text[]::muscle_groups[])
+  AND ($5::text[] IS NULL OR e.primary_muscles && $5::text[]::muscle_groups[])
+  AND ($6::text[] IS NULL OR e.equipment && $6::text[]::equipment_types[])
+  AND ($7::text[] IS NULL OR e.difficulty = ANY($7::text[]::difficulties[]))
+  AND ($10::text IS NULL OR e.id IN (
+    SELECT m.id FROM queries q JOIN exercises m ON m.language=q.language AND m.search_vector @@ q.query
+  ))
+  AND ($11::uuid IS NULL OR e.organization_id=$11)
+ORDER BY e.created_at DESC
+LIMIT $8 OFFSET $9
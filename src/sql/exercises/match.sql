@@ -0,0 +1,8 @@
+SELECT e.id, e.name, e.user_id,
+  similarity(immutable_unaccent(lower(e.name)), immutable_unaccent(lower($2))) AS score
+FROM exercises e
+WHERE (e.visibility='PUBLIC' OR e.user_id=$1
+    OR (e.visibility='ORG' AND e.organization_id IN (SELECT ur.organization_id FROM user_roles ur WHERE ur.user_id=$1)))
+  AND similarity(immutable_unaccent(lower(e.name)), immutable_unaccent(lower($2))) >= $3
+ORDER BY score DESC, (e.user_id=$1) DESC NULLS LAST, e.created_at
+LIMIT 1
@@ -0,0 +1,27 @@
+INSERT INTO exercises (
+  user_id, visibility, seed_key, seed_version, name, description,
+  sports, primary_muscles, secondary_muscles, equipment, difficulty, language
+)
+VALUES (
+  NULL, 'PUBLIC', $1, $2, $3, $4,
+  COALESCE($5::text[]::sports[], '{}'),
+  COALESCE($6::text[]::muscle_groups[], '{}'),
+  COALESCE($7::text[]::muscle_groups[], '{}'),
+  COALESCE($8::text[]::equipment_types[], '{}'),
+  $9,
+  COALESCE($10::regconfig, 'english')
+)
+ON CONFLICT (seed_key) DO UPDATE SET
+    name=EXCLUDED.name,
+    description=EXCLUDED.description,
+    visibility='PUBLIC',
+    sports=EXCLUDED.sports,
+    primary_muscles=EXCLUDED.primary_muscles,
+    secondary_muscles=EXCLUDED.secondary_muscles,
+    equipment=EXCLUDED.equipment,
+    difficulty=EXCLUDED.difficulty,
+    language=EXCLUDED.language,
+    seed_version=EXCLUDED.seed_version,
+    updated_at=NOW()
+WHERE exercises.seed_version IS NULL OR exercises.seed_version < EXCLUDED.seed_version
+RETURNING *
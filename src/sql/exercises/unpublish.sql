@@ -0,0 +1,2 @@
+UPDATE exercises SET visibility=CASE WHEN organization_id IS NULL THEN 'PRIVATE' ELSE 'ORG' END::visibilities, updated_at=NOW()
+WHERE id=$1 AND visibility='PUBLIC' RETURNING id
\ No newline at end of file
@@ -1,5 +1,12 @@
 UPDATE exercises SET
     name=$2,
     description=$3,
-    public=$4
-WHERE id=$1
\ No newline at end of file
+    visibility=$4,
+    organization_id=$11,
+    sports=COALESCE($5::text[]::sports[], '{}'),
+    primary_muscles=COALESCE($6::text[]::muscle_groups[], '{}'),
+    secondary_muscles=COALESCE($7::text[]::muscle_groups[], '{}'),
+    equipment=COALESCE($8::text[]::equipment_types[], '{}'),
+    difficulty=$9,
+    language=COALESCE($10::regconfig, language)
+WHERE id=$1
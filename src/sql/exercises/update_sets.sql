@@ -1,7 +1,33 @@
-UPDATE sets SET 
-    name=:name,
-    set_number=:set_number,
-    rest_time=:rest_time,
-    rep_count=:rep_count,
-    duration=:duration
-WHERE id=:id
+INSERT INTO sets (
+  exercise_id, name, set_number, set_type, rest_time, rep_count, duration,
+  rep_min, rep_max, interval, rounds, drops, drop_percent,
+  load, load_unit, percent_1rm, rpe, rir, tempo, distance, distance_unit, grade, side
+)
+VALUES (
+  :exercise_id, :name, :set_number, :set_type, :rest_time, :rep_count, :duration,
+  :rep_min, :rep_max, :interval, :rounds, :drops, :drop_percent,
+  :load, :load_unit, :percent_1rm, :rpe, :rir, :tempo, :distance, :distance_unit, :grade, :side
+)
+ON CONFLICT (exercise_id, set_number) DO UPDATE SET
+    name=EXCLUDED.name,
+    set_type=EXCLUDED.set_type,
+    rest_time=EXCLUDED.rest_time,
+    rep_count=EXCLUDED.rep_count,
+    duration=EXCLUDED.duration,
+    rep_min=EXCLUDED.rep_min,
+    rep_max=EXCLUDED.rep_max,
+    interval=EXCLUDED.interval,
+    rounds=EXCLUDED.rounds,
+    drops=EXCLUDED.drops,
+    drop_percent=EXCLUDED.drop_percent,
+    load=EXCLUDED.load,
+    load_unit=EXCLUDED.load_unit,
+    percent_1rm=EXCLUDED.percent_1rm,
+    rpe=EXCLUDED.rpe,
+    rir=EXCLUDED.rir,
+    tempo=EXCLUDED.tempo,
+    distance=EXCLUDED.distance,
+    distance_unit=EXCLUDED.distance_unit,
+    grade=EXCLUDED.grade,
+    side=EXCLUDED.side,
+    updated_at=NOW()
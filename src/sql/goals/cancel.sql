@@ -0,0 +1,3 @@
+UPDATE goals SET status='CANCELLED', updated_at=NOW()
+WHERE id=$1 AND status IN ('ACTIVE', 'MISSED')
+RETURNING id
@@ -0,0 +1,3 @@
+INSERT INTO goals (user_id, param_id, exercise_id, side, metric, target_value, unit, deadline, note)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id
@@ -0,0 +1,47 @@
+WITH current AS (
+  SELECT g.id, g.target_value, g.deadline, g.start_value,
+    CASE
+      WHEN g.param_id IS NOT NULL THEN (
+        SELECT pl.value FROM param_logs pl
+        WHERE pl.user_id=g.user_id AND pl.param_id=g.param_id AND (g.side='GENERAL' OR pl.side=g.side)
+        ORDER BY pl.created_at DESC
+        LIMIT 1
+      )
+      ELSE (
+        SELECT ROUND(MAX(pr.value) / CASE g.unit WHEN 'LB' THEN 0.45359237 ELSE 1 END, 2)
+        FROM personal_records pr
+        WHERE pr.user_id=g.user_id AND pr.exercise_id=g.exercise_id AND pr.kind=g.metric
+      )
+    END AS value
+  FROM goals g
+  WHERE g.user_id=$1 AND g.status='ACTIVE'
+    AND ($2::uuid IS NULL OR g.id=$2)
+    AND ($3::uuid IS NULL OR g.param_id=$3)
+    AND ($4::uuid IS NULL OR g.exercise_id=$4)
+),
+baselined AS (
+  SELECT c.id, c.target_value, c.deadline, c.value,
+    CASE WHEN $5::boolean THEN c.value ELSE COALESCE(c.start_value, c.value) END AS start_value
+  FROM current c
+),
+evaluated AS (
+  SELECT b.id, b.value, b.start_value,
+    CASE
+      WHEN b.value IS NOT NULL AND CASE
+        WHEN b.start_value > b.target_value THEN b.value <= b.target_value
+        ELSE b.value >= b.target_value
+      END THEN 'ACHIEVED'
+      WHEN b.deadline < CURRENT_DATE THEN 'MISSED'
+      ELSE 'ACTIVE'
+    END::goal_statuses AS status
+  FROM baselined b
+)
+UPDATE goals g SET
+  current_value=e.value,
+  start_value=e.start_value,
+  status=e.status,
+  achieved_at=CASE WHEN e.status='ACHIEVED' THEN NOW() END,
+  updated_at=NOW()
+FROM evaluated e
+WHERE e.id=g.id
+RETURNING g.id, g.status
@@ -0,0 +1,2 @@
+UPDATE goals SET status='MISSED', updated_at=NOW()
+WHERE user_id=$1 AND status='ACTIVE' AND deadline < CURRENT_DATE
@@ -0,0 +1,14 @@
+SELECT g.*,
+  p.name AS param_name,
+  e.name AS exercise_name,
+  COALESCE(ROUND(CASE
+    WHEN g.status='ACHIEVED' THEN 100
+    WHEN g.current_value IS NULL THEN 0
+    WHEN g.start_value IS NULL OR g.start_value=g.target_value
+      THEN GREATEST(0, LEAST(100, g.current_value * 100 / NULLIF(g.target_value, 0)))
+    ELSE GREATEST(0, LEAST(100, (g.current_value - g.start_value) * 100 / (g.target_value - g.start_value)))
+  END, 1), 0)::float AS progress
+FROM goals g
+LEFT JOIN params p ON p.id=g.param_id
+LEFT JOIN exercises e ON e.id=g.exercise_id
+WHERE g.id IN (?)
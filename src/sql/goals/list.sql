@@ -0,0 +1,5 @@
+SELECT id, COUNT(*) OVER () AS total_count
+FROM goals
+WHERE user_id=$1 AND ($2::text IS NULL OR status::text=$2)
+ORDER BY status, deadline NULLS LAST, created_at DESC
+LIMIT $3 OFFSET $4
@@ -0,0 +1,9 @@
+UPDATE goals SET
+  target_value=$2,
+  deadline=$3,
+  note=$4,
+  status='ACTIVE',
+  achieved_at=NULL,
+  updated_at=NOW()
+WHERE id=$1 AND status <> 'CANCELLED'
+RETURNING id
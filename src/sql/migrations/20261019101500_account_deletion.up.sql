@@ -0,0 +1,8 @@
+ALTER TABLE users
+ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX idx_users_deleted_at ON users (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- owner_deleted_at marks the public exercises kept when their author was purged
+ALTER TABLE exercises
+ADD COLUMN owner_deleted_at TIMESTAMP;
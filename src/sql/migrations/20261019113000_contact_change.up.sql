@@ -0,0 +1,5 @@
+ALTER TYPE otp_perposes ADD VALUE 'EMAIL_CHANGE';
+ALTER TYPE otp_perposes ADD VALUE 'PHONE_CHANGE';
+
+ALTER TABLE otps
+ADD COLUMN target VARCHAR(128);
@@ -0,0 +1,5 @@
+ALTER TABLE users
+ALTER COLUMN email DROP NOT NULL;
+
+ALTER TABLE users
+ADD CONSTRAINT contact_check CHECK (email IS NOT NULL OR phone IS NOT NULL);
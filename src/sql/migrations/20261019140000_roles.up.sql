@@ -0,0 +1,51 @@
+CREATE TABLE roles (
+  name VARCHAR(32) NOT NULL PRIMARY KEY,
+  description TEXT,
+  created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE role_permissions (
+  role VARCHAR(32) NOT NULL,
+  permission VARCHAR(64) NOT NULL,
+  created_at TIMESTAMP DEFAULT NOW(),
+  PRIMARY KEY (role, permission),
+  CONSTRAINT fk_role FOREIGN KEY (role) REFERENCES roles(name) ON DELETE CASCADE
+);
+
+-- organization_id NULL means the role is granted globally
+CREATE TABLE user_roles (
+  id UUID NOT NULL DEFAULT public.uuid_generate_v4() PRIMARY KEY,
+  user_id UUID NOT NULL,
+  role VARCHAR(32) NOT NULL,
+  organization_id UUID,
+  granted_by UUID,
+  created_at TIMESTAMP DEFAULT NOW(),
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT fk_role FOREIGN KEY (role) REFERENCES roles(name) ON DELETE CASCADE,
+  CONSTRAINT fk_granted_by FOREIGN KEY (granted_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE UNIQUE INDEX idx_user_roles_unique ON user_roles (user_id, role, COALESCE(organization_id, '00000000-0000-0000-0000-000000000000'));
+
+INSERT INTO roles (name, description) VALUES
+  ('admin', 'Platform operators, has every permission'),
+  ('coach', 'Creates programmes and assigns them to athletes'),
+  ('athlete', 'Default role of every registered user');
+
+INSERT INTO role_permissions (role, permission) VALUES
+  ('admin', '*'),
+  ('coach', 'exercises:create'),
+  ('coach', 'exercises:update'),
+  ('coach', 'plans:create'),
+  ('coach', 'plans:update'),
+  ('coach', 'plans:assign'),
+  ('coach', 'athletes:read'),
+  ('coach', 'params:log'),
+  ('athlete', 'exercises:create'),
+  ('athlete', 'exercises:update'),
+  ('athlete', 'plans:create'),
+  ('athlete', 'plans:update'),
+  ('athlete', 'params:log');
+
+INSERT INTO user_roles (user_id, role)
+SELECT id, 'athlete' FROM users;
@@ -0,0 +1,12 @@
+CREATE TABLE admin_audit_logs (
+  id UUID NOT NULL DEFAULT public.uuid_generate_v4() PRIMARY KEY,
+  admin_id UUID,
+  action VARCHAR(64) NOT NULL,
+  target_type VARCHAR(64) NOT NULL,
+  target_id UUID NOT NULL,
+  reason TEXT,
+  created_at TIMESTAMP DEFAULT NOW(),
+  CONSTRAINT fk_admin FOREIGN KEY (admin_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_admin_audit_logs_target ON admin_audit_logs (target_type, target_id);
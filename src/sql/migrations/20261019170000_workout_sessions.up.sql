@@ -0,0 +1,41 @@
+CREATE TABLE workout_sessions (
+  id UUID NOT NULL DEFAULT public.uuid_generate_v4() PRIMARY KEY,
+  user_id UUID NOT NULL,
+  plan_id UUID,
+  plan_assignee_id UUID,
+  note TEXT,
+  started_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  finished_at TIMESTAMP,
+  created_at TIMESTAMP DEFAULT NOW(),
+  updated_at TIMESTAMP DEFAULT NOW(),
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT fk_plan FOREIGN KEY (plan_id) REFERENCES plans(id) ON DELETE SET NULL,
+  CONSTRAINT fk_plan_assignee FOREIGN KEY (plan_assignee_id) REFERENCES plan_assignees(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_workout_sessions_user ON workout_sessions (user_id, started_at DESC);
+
+-- prescribed_* keep the set prescription at the time of logging
+CREATE TABLE set_logs (
+  id UUID NOT NULL DEFAULT public.uuid_generate_v4() PRIMARY KEY,
+  session_id UUID NOT NULL,
+  exercise_id UUID NOT NULL,
+  set_id UUID,
+  set_number integer NOT NULL,
+  rep_count integer,
+  duration bigint,
+  load NUMERIC(8, 2),
+  unit units,
+  rpe NUMERIC(3, 1),
+  prescribed_rep_count integer,
+  prescribed_duration bigint,
+  note TEXT,
+  created_at TIMESTAMP DEFAULT NOW(),
+  CONSTRAINT fk_session FOREIGN KEY (session_id) REFERENCES workout_sessions(id) ON DELETE CASCADE,
+  CONSTRAINT fk_exercise FOREIGN KEY (exercise_id) REFERENCES exercises(id) ON DELETE CASCADE,
+  CONSTRAINT fk_set FOREIGN KEY (set_id) REFERENCES sets(id) ON DELETE SET NULL,
+  CONSTRAINT performed_check CHECK (rep_count IS NOT NULL OR duration IS NOT NULL OR load IS NOT NULL),
+  CONSTRAINT rpe_check CHECK (rpe IS NULL OR (rpe >= 1 AND rpe <= 10))
+);
+
+CREATE INDEX idx_set_logs_exercise ON set_logs (exercise_id, session_id);
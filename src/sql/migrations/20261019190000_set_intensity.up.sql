@@ -0,0 +1,31 @@
+ALTER TYPE units ADD VALUE 'LB';
+ALTER TYPE units ADD VALUE 'M';
+ALTER TYPE units ADD VALUE 'KM';
+
+ALTER TABLE sets
+ADD COLUMN load NUMERIC(8, 2),
+ADD COLUMN load_unit units,
+ADD COLUMN percent_1rm NUMERIC(5, 2),
+ADD COLUMN rpe NUMERIC(3, 1),
+ADD COLUMN rir integer,
+ADD COLUMN tempo VARCHAR(16),
+ADD COLUMN distance NUMERIC(10, 2),
+ADD COLUMN distance_unit units,
+ADD COLUMN grade VARCHAR(16),
+ADD COLUMN side sides;
+
+-- distance only sets (e.g. row 500m) have neither reps nor duration
+ALTER TABLE sets DROP CONSTRAINT reps_check;
+ALTER TABLE sets
+ADD CONSTRAINT reps_check CHECK (
+  NOT (rep_count IS NOT NULL AND duration IS NOT NULL)
+  AND (rep_count IS NOT NULL OR duration IS NOT NULL OR distance IS NOT NULL)
+),
+ADD CONSTRAINT load_check CHECK ((load IS NULL) = (load_unit IS NULL) AND NOT (load IS NOT NULL AND percent_1rm IS NOT NULL)),
+ADD CONSTRAINT distance_check CHECK ((distance IS NULL) = (distance_unit IS NULL)),
+ADD CONSTRAINT intensity_check CHECK (
+  NOT (rpe IS NOT NULL AND rir IS NOT NULL)
+  AND (rpe IS NULL OR (rpe >= 1 AND rpe <= 10))
+  AND (rir IS NULL OR rir >= 0)
+  AND (percent_1rm IS NULL OR (percent_1rm > 0 AND percent_1rm <= 120))
+);
@@ -0,0 +1,39 @@
+CREATE TYPE set_types AS ENUM (
+  'STANDARD',
+  'RANGE',
+  'AMRAP',
+  'EMOM',
+  'INTERVAL',
+  'DROP'
+);
+
+-- interval is the length of one EMOM/INTERVAL round, rounds the number of them
+ALTER TABLE sets
+ADD COLUMN set_type set_types NOT NULL DEFAULT 'STANDARD',
+ADD COLUMN rep_min integer,
+ADD COLUMN rep_max integer,
+ADD COLUMN interval bigint,
+ADD COLUMN rounds integer,
+ADD COLUMN drops integer,
+ADD COLUMN drop_percent NUMERIC(5, 2);
+
+ALTER TABLE sets DROP CONSTRAINT reps_check;
+ALTER TABLE sets
+ADD CONSTRAINT reps_check CHECK (
+  NOT (rep_count IS NOT NULL AND duration IS NOT NULL)
+  AND (set_type <> 'STANDARD' OR rep_count IS NOT NULL OR duration IS NOT NULL OR distance IS NOT NULL)
+),
+ADD CONSTRAINT range_check CHECK (
+  (rep_min IS NULL OR rep_min >= 1)
+  AND (rep_max IS NULL OR rep_min IS NULL OR rep_min <= rep_max)
+  AND (set_type <> 'RANGE' OR (rep_min IS NOT NULL AND rep_max IS NOT NULL))
+),
+ADD CONSTRAINT rounds_check CHECK (
+  (set_type NOT IN ('EMOM', 'INTERVAL') OR (rounds IS NOT NULL AND rounds >= 1))
+  AND (set_type <> 'DROP' OR (drops IS NOT NULL AND drops >= 1))
+);
+
+ALTER TABLE set_logs
+ADD COLUMN prescribed_set_type set_types,
+ADD COLUMN prescribed_rep_min integer,
+ADD COLUMN prescribed_rep_max integer;
@@ -0,0 +1,45 @@
+CREATE TYPE plan_group_types AS ENUM (
+  'SINGLE',
+  'SUPERSET',
+  'CIRCUIT'
+);
+
+-- rest_time on a group is the rest between rounds, on plan_exercises the rest after the exercise
+CREATE TABLE plan_groups (
+  id UUID NOT NULL DEFAULT public.uuid_generate_v4() PRIMARY KEY,
+  plan_id UUID NOT NULL,
+  group_order integer NOT NULL,
+  group_type plan_group_types NOT NULL DEFAULT 'SINGLE',
+  name VARCHAR(128),
+  rounds integer NOT NULL DEFAULT 1,
+  rest_time bigint NOT NULL DEFAULT 0,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  CONSTRAINT fk_plan FOREIGN KEY (plan_id) REFERENCES plans(id) ON DELETE CASCADE,
+  CONSTRAINT rounds_check CHECK (rounds >= 1 AND rest_time >= 0)
+);
+
+CREATE INDEX idx_plan_groups_plan ON plan_groups (plan_id, group_order);
+
+-- every existing plan exercise becomes a single group in its old position
+INSERT INTO plan_groups (id, plan_id, group_order, created_at, updated_at)
+SELECT id, plan_id, exercise_order, created_at, created_at FROM plan_exercises;
+
+ALTER TABLE plan_exercises ADD COLUMN group_id UUID;
+UPDATE plan_exercises SET group_id=id, exercise_order=1;
+ALTER TABLE plan_exercises
+ALTER COLUMN group_id SET NOT NULL,
+ADD CONSTRAINT fk_group FOREIGN KEY (group_id) REFERENCES plan_groups(id) ON DELETE CASCADE;
+
+-- assignees of missing plans are left for an operator to look at instead of being dropped silently
+DO $$
+DECLARE orphans integer;
+BEGIN
+  SELECT COUNT(*) INTO orphans FROM plan_assignees WHERE plan_id NOT IN (SELECT id FROM plans);
+  IF orphans > 0 THEN
+    RAISE EXCEPTION '% plan_assignees rows reference missing plans, fix them before migrating', orphans;
+  END IF;
+END $$;
+
+ALTER TABLE plan_assignees
+ADD CONSTRAINT fk_plan FOREIGN KEY (plan_id) REFERENCES plans(id) ON DELETE CASCADE;
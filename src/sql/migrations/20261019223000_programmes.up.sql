@@ -0,0 +1,83 @@
+CREATE TYPE progression_targets AS ENUM (
+  'LOAD',
+  'PERCENT_1RM',
+  'REP_COUNT',
+  'DURATION',
+  'DISTANCE'
+);
+
+CREATE TYPE progression_periods AS ENUM (
+  'WEEK',
+  'SESSION'
+);
+
+CREATE TABLE programmes (
+  id UUID NOT NULL DEFAULT public.uuid_generate_v4() PRIMARY KEY,
+  user_id UUID NOT NULL,
+  name VARCHAR(128) NOT NULL,
+  description TEXT,
+  public BOOLEAN NOT NULL DEFAULT false,
+  weeks integer NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT weeks_check CHECK (weeks BETWEEN 1 AND 52)
+);
+
+-- day is 1 to 7 counted from the start date of the week, not the weekday
+CREATE TABLE programme_days (
+  id UUID NOT NULL DEFAULT public.uuid_generate_v4() PRIMARY KEY,
+  programme_id UUID NOT NULL,
+  week integer NOT NULL,
+  day integer NOT NULL,
+  plan_id UUID NOT NULL,
+  note TEXT,
+  CONSTRAINT fk_programme FOREIGN KEY (programme_id) REFERENCES programmes(id) ON DELETE CASCADE,
+  CONSTRAINT fk_plan FOREIGN KEY (plan_id) REFERENCES plans(id) ON DELETE CASCADE,
+  CONSTRAINT day_check CHECK (week >= 1 AND day BETWEEN 1 AND 7)
+);
+
+CREATE UNIQUE INDEX idx_programme_days_unique ON programme_days (programme_id, week, day, plan_id);
+
+-- amount is added once per week or per session of the exercise, max caps the progressed value
+CREATE TABLE programme_progressions (
+  id UUID NOT NULL DEFAULT public.uuid_generate_v4() PRIMARY KEY,
+  programme_id UUID NOT NULL,
+  plan_id UUID,
+  exercise_id UUID,
+  target progression_targets NOT NULL,
+  amount NUMERIC(10, 2) NOT NULL,
+  per progression_periods NOT NULL DEFAULT 'WEEK',
+  max NUMERIC(10, 2),
+  CONSTRAINT fk_programme FOREIGN KEY (programme_id) REFERENCES programmes(id) ON DELETE CASCADE,
+  CONSTRAINT fk_plan FOREIGN KEY (plan_id) REFERENCES plans(id) ON DELETE CASCADE,
+  CONSTRAINT fk_exercise FOREIGN KEY (exercise_id) REFERENCES exercises(id) ON DELETE CASCADE
+);
+
+CREATE TABLE programme_assignments (
+  id UUID NOT NULL DEFAULT public.uuid_generate_v4() PRIMARY KEY,
+  programme_id UUID NOT NULL,
+  user_id UUID NOT NULL,
+  assigned_by UUID,
+  start_at TIMESTAMP NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  CONSTRAINT fk_programme FOREIGN KEY (programme_id) REFERENCES programmes(id) ON DELETE CASCADE,
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT fk_assigned_by FOREIGN KEY (assigned_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- plan is the generated snapshot with progressions applied
+CREATE TABLE programme_sessions (
+  id UUID NOT NULL DEFAULT public.uuid_generate_v4() PRIMARY KEY,
+  assignment_id UUID NOT NULL,
+  plan_id UUID,
+  week integer NOT NULL,
+  day integer NOT NULL,
+  scheduled_at TIMESTAMP NOT NULL,
+  plan jsonb NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  CONSTRAINT fk_assignment FOREIGN KEY (assignment_id) REFERENCES programme_assignments(id) ON DELETE CASCADE,
+  CONSTRAINT fk_plan FOREIGN KEY (plan_id) REFERENCES plans(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_programme_sessions_assignment ON programme_sessions (assignment_id, scheduled_at);
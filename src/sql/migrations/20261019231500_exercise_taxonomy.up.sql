@@ -0,0 +1,63 @@
+CREATE TYPE muscle_groups AS ENUM (
+  'CHEST',
+  'UPPER_BACK',
+  'LATS',
+  'LOWER_BACK',
+  'SHOULDERS',
+  'BICEPS',
+  'TRICEPS',
+  'FOREARMS',
+  'FINGERS',
+  'CORE',
+  'OBLIQUES',
+  'GLUTES',
+  'HIP_FLEXORS',
+  'ADDUCTORS',
+  'QUADRICEPS',
+  'HAMSTRINGS',
+  'CALVES',
+  'NECK',
+  'FULL_BODY'
+);
+
+CREATE TYPE equipment_types AS ENUM (
+  'BODYWEIGHT',
+  'BARBELL',
+  'DUMBBELL',
+  'KETTLEBELL',
+  'MACHINE',
+  'CABLE',
+  'BAND',
+  'BENCH',
+  'BOX',
+  'PULL_UP_BAR',
+  'RINGS',
+  'MEDICINE_BALL',
+  'HANGBOARD',
+  'CAMPUS_BOARD',
+  'SYSTEM_BOARD',
+  'ROPE',
+  'ROWER',
+  'BIKE',
+  'TREADMILL',
+  'FOAM_ROLLER',
+  'OTHER'
+);
+
+CREATE TYPE difficulties AS ENUM (
+  'BEGINNER',
+  'INTERMEDIATE',
+  'ADVANCED'
+);
+
+ALTER TABLE exercises
+ADD COLUMN sports sports[] NOT NULL DEFAULT '{}',
+ADD COLUMN primary_muscles muscle_groups[] NOT NULL DEFAULT '{}',
+ADD COLUMN secondary_muscles muscle_groups[] NOT NULL DEFAULT '{}',
+ADD COLUMN equipment equipment_types[] NOT NULL DEFAULT '{}',
+ADD COLUMN difficulty difficulties;
+
+CREATE INDEX idx_exercises_sports ON exercises USING GIN (sports);
+CREATE INDEX idx_exercises_primary_muscles ON exercises USING GIN (primary_muscles);
+CREATE INDEX idx_exercises_secondary_muscles ON exercises USING GIN (secondary_muscles);
+CREATE INDEX idx_exercises_equipment ON exercises USING GIN (equipment);
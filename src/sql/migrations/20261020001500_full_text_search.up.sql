@@ -0,0 +1,29 @@
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+-- unaccent is only stable, generated columns need an immutable wrapper bound to the dictionary
+CREATE OR REPLACE FUNCTION immutable_unaccent(text) RETURNS text
+LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT
+AS $$ SELECT public.unaccent('public.unaccent'::regdictionary, $1) $$;
+
+ALTER TABLE exercises
+ADD COLUMN language regconfig NOT NULL DEFAULT 'english';
+
+ALTER TABLE exercises
+ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+  setweight(to_tsvector(language, immutable_unaccent(COALESCE(name, ''))), 'A') ||
+  setweight(to_tsvector(language, immutable_unaccent(COALESCE(description, ''))), 'B')
+) STORED;
+
+CREATE INDEX idx_exercises_search ON exercises USING GIN (search_vector);
+
+ALTER TABLE plans
+ADD COLUMN description TEXT,
+ADD COLUMN language regconfig NOT NULL DEFAULT 'english';
+
+ALTER TABLE plans
+ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+  setweight(to_tsvector(language, immutable_unaccent(COALESCE(name, ''))), 'A') ||
+  setweight(to_tsvector(language, immutable_unaccent(COALESCE(description, ''))), 'B')
+) STORED;
+
+CREATE INDEX idx_plans_search ON plans USING GIN (search_vector);
@@ -0,0 +1,9 @@
+ALTER TABLE exercises
+ADD COLUMN forked_from UUID REFERENCES exercises(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_exercises_forked_from ON exercises (forked_from);
+
+ALTER TABLE plans
+ADD COLUMN forked_from UUID REFERENCES plans(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_plans_forked_from ON plans (forked_from);
@@ -0,0 +1,72 @@
+-- snapshot holds the exercise with its sets, or the plan with its groups, as they were after the change
+CREATE TABLE exercise_revisions (
+  id UUID NOT NULL DEFAULT public.uuid_generate_v4() PRIMARY KEY,
+  exercise_id UUID NOT NULL,
+  revision integer NOT NULL,
+  snapshot jsonb NOT NULL,
+  created_at TIMESTAMP DEFAULT NOW(),
+  CONSTRAINT fk_exercise FOREIGN KEY (exercise_id) REFERENCES exercises(id) ON DELETE CASCADE,
+  CONSTRAINT exercise_revision_unique UNIQUE (exercise_id, revision)
+);
+
+CREATE TABLE plan_revisions (
+  id UUID NOT NULL DEFAULT public.uuid_generate_v4() PRIMARY KEY,
+  plan_id UUID NOT NULL,
+  revision integer NOT NULL,
+  snapshot jsonb NOT NULL,
+  created_at TIMESTAMP DEFAULT NOW(),
+  CONSTRAINT fk_plan FOREIGN KEY (plan_id) REFERENCES plans(id) ON DELETE CASCADE,
+  CONSTRAINT plan_revision_unique UNIQUE (plan_id, revision)
+);
+
+-- sets updated with a subset or in another order could share a set_number, number them again
+-- in their order so the first revisions and the unique constraint below see one set per number
+UPDATE sets s SET set_number=n.set_number
+FROM (
+  SELECT id, ROW_NUMBER() OVER (PARTITION BY exercise_id ORDER BY set_number, created_at, id) AS set_number
+  FROM sets
+) n
+WHERE n.id=s.id AND s.set_number <> n.set_number;
+
+INSERT INTO exercise_revisions (exercise_id, revision, snapshot)
+SELECT e.id, 1,
+  to_jsonb(e) - 'search_vector' || jsonb_build_object(
+    'sets', COALESCE((SELECT jsonb_agg(to_jsonb(s) ORDER BY s.set_number) FROM sets s WHERE s.exercise_id=e.id), '[]')
+  )
+FROM exercises e;
+
+INSERT INTO plan_revisions (plan_id, revision, snapshot)
+SELECT p.id, 1,
+  to_jsonb(p) - 'search_vector' || jsonb_build_object(
+    'groups', COALESCE((SELECT
+      jsonb_agg(to_jsonb(g) || jsonb_build_object(
+        'exercises', (SELECT
+          jsonb_agg(to_jsonb(pe) || jsonb_build_object(
+            'exercise', jsonb_build_object(
+              'id', e.id,
+              'name', e.name,
+              'sets', (SELECT jsonb_agg(to_jsonb(s) ORDER BY s.set_number) FROM sets s WHERE s.exercise_id=e.id)
+            )
+          ) ORDER BY pe.exercise_order, pe.created_at)
+          FROM plan_exercises pe
+          JOIN exercises e ON e.id=pe.exercise_id
+          WHERE pe.group_id=g.id
+        )
+      ) ORDER BY g.group_order, g.created_at)
+      FROM plan_groups g
+      WHERE g.plan_id=p.id
+    ), '[]')
+  )
+FROM plans p;
+
+-- sets are replaced by set_number on update so their ids and the set_logs pointing to them survive
+ALTER TABLE sets
+ADD CONSTRAINT sets_number_unique UNIQUE (exercise_id, set_number);
+
+-- logs and sessions point to the revision they were performed against, NULL for the ones logged before revisions
+ALTER TABLE set_logs
+ADD COLUMN exercise_revision integer,
+ADD CONSTRAINT fk_exercise_revision FOREIGN KEY (exercise_id, exercise_revision) REFERENCES exercise_revisions(exercise_id, revision);
+
+ALTER TABLE workout_sessions
+ADD COLUMN plan_revision integer;
@@ -0,0 +1,4 @@
+-- seed_key identifies the system owned exercises loaded from the catalogue, seed_version is the catalogue version that wrote them
+ALTER TABLE exercises
+ADD COLUMN seed_key VARCHAR(128) UNIQUE,
+ADD COLUMN seed_version integer;
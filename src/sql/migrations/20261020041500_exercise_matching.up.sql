@@ -0,0 +1,4 @@
+-- trigram similarity on exercise names lets imported spreadsheets match the library despite typos and word order
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX idx_exercises_name_trgm ON exercises USING GIN (immutable_unaccent(lower(name)) gin_trgm_ops);
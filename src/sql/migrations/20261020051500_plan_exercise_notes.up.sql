@@ -0,0 +1,3 @@
+-- coaching cues for the exercise within the plan, printed on workout sheets
+ALTER TABLE plan_exercises
+ADD COLUMN notes TEXT;
@@ -0,0 +1,7 @@
+-- secret token of the personal calendar feed, calendar apps subscribe with it in the url instead of a login
+CREATE TABLE calendar_tokens (
+  user_id UUID NOT NULL PRIMARY KEY,
+  token VARCHAR(64) NOT NULL UNIQUE,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
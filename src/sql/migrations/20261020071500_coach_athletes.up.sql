@@ -0,0 +1,22 @@
+CREATE TYPE coach_athlete_status AS ENUM ('PENDING', 'ACCEPTED', 'DECLINED', 'REVOKED');
+
+-- invites are addressed to an email so athletes can be invited before they register,
+-- athlete_id is set once known and code is the one mailed with the invite
+CREATE TABLE coach_athletes (
+  id UUID NOT NULL DEFAULT public.uuid_generate_v4() PRIMARY KEY,
+  coach_id UUID NOT NULL,
+  athlete_id UUID,
+  email VARCHAR(128) NOT NULL,
+  code integer NOT NULL,
+  status coach_athlete_status NOT NULL DEFAULT 'PENDING',
+  expires_at TIMESTAMP NOT NULL DEFAULT (NOW() + INTERVAL '7 days'),
+  responded_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  CONSTRAINT fk_coach FOREIGN KEY (coach_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT fk_athlete FOREIGN KEY (athlete_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX idx_coach_athletes_open ON coach_athletes (coach_id, email) WHERE status IN ('PENDING', 'ACCEPTED');
+CREATE UNIQUE INDEX idx_coach_athletes_accepted ON coach_athletes (coach_id, athlete_id) WHERE status='ACCEPTED';
+CREATE INDEX idx_coach_athletes_athlete ON coach_athletes (athlete_id, status);
@@ -0,0 +1,59 @@
+CREATE TABLE organizations (
+  id UUID NOT NULL DEFAULT public.uuid_generate_v4() PRIMARY KEY,
+  name VARCHAR(128) NOT NULL,
+  description TEXT,
+  user_id UUID,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- members of an organization are the users with roles scoped to it,
+-- roles already scoped to an organization id get the organization created for them
+INSERT INTO organizations (id, name, user_id)
+SELECT ur.organization_id, 'Organization ' || LEFT(ur.organization_id::text, 8), MIN(ur.granted_by::text)::uuid
+FROM user_roles ur
+WHERE ur.organization_id IS NOT NULL
+GROUP BY ur.organization_id;
+ALTER TABLE user_roles
+ADD CONSTRAINT fk_organization FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_user_roles_organization ON user_roles (organization_id) WHERE organization_id IS NOT NULL;
+
+-- library:update only counts on roles scoped to the organization owning the content
+INSERT INTO roles (name, description) VALUES
+  ('manager', 'Manages the members and shared library of an organization');
+
+INSERT INTO role_permissions (role, permission) VALUES
+  ('manager', 'organizations:manage'),
+  ('manager', 'library:update'),
+  ('manager', 'exercises:create'),
+  ('manager', 'exercises:update'),
+  ('manager', 'plans:create'),
+  ('manager', 'plans:update'),
+  ('manager', 'plans:assign'),
+  ('manager', 'athletes:read'),
+  ('manager', 'params:log'),
+  ('coach', 'library:update');
+
+-- ORG content is shared with the members of organization_id, it falls back to its owner when the organization is deleted
+CREATE TYPE visibilities AS ENUM ('PRIVATE', 'ORG', 'PUBLIC');
+
+ALTER TABLE exercises
+ADD COLUMN organization_id UUID,
+ADD COLUMN visibility visibilities NOT NULL DEFAULT 'PRIVATE',
+ADD CONSTRAINT fk_organization FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE SET NULL;
+
+UPDATE exercises SET visibility='PUBLIC' WHERE public=true;
+ALTER TABLE exercises DROP COLUMN public;
+
+ALTER TABLE plans
+ADD COLUMN organization_id UUID,
+ADD COLUMN visibility visibilities NOT NULL DEFAULT 'PRIVATE',
+ADD CONSTRAINT fk_organization FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE SET NULL;
+
+UPDATE plans SET visibility='PUBLIC' WHERE public=true;
+ALTER TABLE plans DROP COLUMN public;
+
+CREATE INDEX idx_exercises_organization ON exercises (organization_id) WHERE organization_id IS NOT NULL;
+CREATE INDEX idx_plans_organization ON plans (organization_id) WHERE organization_id IS NOT NULL;
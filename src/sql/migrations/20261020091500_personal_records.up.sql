@@ -0,0 +1,27 @@
+CREATE TYPE record_kinds AS ENUM (
+  'MAX_LOAD',
+  'MAX_REPS',
+  'E1RM',
+  'MAX_DURATION'
+);
+
+-- a row is kept for every record set so older rows are the history of the exercise,
+-- load is the kilograms a MAX_REPS record was lifted at and null for bodyweight sets
+CREATE TABLE personal_records (
+  id UUID NOT NULL DEFAULT public.uuid_generate_v4() PRIMARY KEY,
+  user_id UUID NOT NULL,
+  exercise_id UUID NOT NULL,
+  set_log_id UUID,
+  kind record_kinds NOT NULL,
+  value NUMERIC(10, 2) NOT NULL,
+  unit units NOT NULL,
+  load NUMERIC(8, 2),
+  previous_value NUMERIC(10, 2),
+  achieved_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT fk_exercise FOREIGN KEY (exercise_id) REFERENCES exercises(id) ON DELETE CASCADE,
+  CONSTRAINT fk_set_log FOREIGN KEY (set_log_id) REFERENCES set_logs(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_personal_records_user ON personal_records (user_id, exercise_id, kind, achieved_at DESC);
@@ -0,0 +1,8 @@
+-- bilateral params log each side separately, older logs take the side of their param
+ALTER TABLE param_logs ADD COLUMN side sides;
+UPDATE param_logs pl SET side=p.side FROM params p WHERE p.id=pl.param_id;
+ALTER TABLE param_logs
+ALTER COLUMN side SET NOT NULL,
+ALTER COLUMN side SET DEFAULT 'GENERAL';
+
+CREATE INDEX idx_param_logs_user_param ON param_logs (user_id, param_id, created_at);
@@ -0,0 +1,36 @@
+CREATE TYPE goal_statuses AS ENUM (
+  'ACTIVE',
+  'ACHIEVED',
+  'MISSED',
+  'CANCELLED'
+);
+
+-- param goals track the latest log of side (any side for GENERAL), exercise goals the best personal record
+-- of metric. A goal whose target is below its start value is reached by going down.
+CREATE TABLE goals (
+  id UUID NOT NULL DEFAULT public.uuid_generate_v4() PRIMARY KEY,
+  user_id UUID NOT NULL,
+  param_id UUID,
+  exercise_id UUID,
+  side sides NOT NULL DEFAULT 'GENERAL',
+  metric record_kinds,
+  target_value NUMERIC(10, 2) NOT NULL,
+  unit units NOT NULL,
+  start_value NUMERIC(10, 2),
+  current_value NUMERIC(10, 2),
+  deadline DATE,
+  status goal_statuses NOT NULL DEFAULT 'ACTIVE',
+  note TEXT,
+  achieved_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT fk_param FOREIGN KEY (param_id) REFERENCES params(id) ON DELETE CASCADE,
+  CONSTRAINT fk_exercise FOREIGN KEY (exercise_id) REFERENCES exercises(id) ON DELETE CASCADE,
+  CONSTRAINT target_check CHECK (
+    (param_id IS NOT NULL AND exercise_id IS NULL AND metric IS NULL) OR
+    (param_id IS NULL AND exercise_id IS NOT NULL AND metric IS NOT NULL)
+  )
+);
+
+CREATE INDEX idx_goals_user ON goals (user_id, status);
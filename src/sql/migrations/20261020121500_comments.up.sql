@@ -0,0 +1,48 @@
+CREATE TYPE comment_targets AS ENUM (
+  'PLAN',
+  'PLAN_ASSIGNMENT',
+  'WORKOUT_SESSION',
+  'PARAM_LOG'
+);
+
+-- target_id points to the row of target_type, depth is 0 for comments opening a thread and grows with every reply.
+-- Deleted comments keep their row so replies stay in their thread.
+CREATE TABLE comments (
+  id UUID NOT NULL DEFAULT public.uuid_generate_v4() PRIMARY KEY,
+  target_type comment_targets NOT NULL,
+  target_id UUID NOT NULL,
+  parent_id UUID,
+  user_id UUID NOT NULL,
+  body TEXT NOT NULL,
+  depth INTEGER NOT NULL DEFAULT 0,
+  edited_at TIMESTAMP,
+  deleted_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  CONSTRAINT fk_parent FOREIGN KEY (parent_id) REFERENCES comments(id) ON DELETE CASCADE,
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_comments_target ON comments (target_type, target_id, created_at);
+CREATE INDEX idx_comments_parent ON comments (parent_id);
+
+-- previous bodies of edited comments, written_at is when the body was posted or last edited
+CREATE TABLE comment_revisions (
+  id UUID NOT NULL DEFAULT public.uuid_generate_v4() PRIMARY KEY,
+  comment_id UUID NOT NULL,
+  body TEXT NOT NULL,
+  written_at TIMESTAMP NOT NULL,
+  replaced_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  CONSTRAINT fk_comment FOREIGN KEY (comment_id) REFERENCES comments(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_comment_revisions_comment ON comment_revisions (comment_id, replaced_at);
+
+CREATE TABLE comment_mentions (
+  comment_id UUID NOT NULL,
+  user_id UUID NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (comment_id, user_id),
+  CONSTRAINT fk_comment FOREIGN KEY (comment_id) REFERENCES comments(id) ON DELETE CASCADE,
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,5 @@
+-- deleting plans and programmes is a permission of its own, granted to every role able to create them
+INSERT INTO role_permissions (role, permission) VALUES
+  ('coach', 'plans:delete'),
+  ('athlete', 'plans:delete'),
+  ('manager', 'plans:delete');
@@ -0,0 +1,10 @@
+-- programmes follow the PRIVATE, ORG and PUBLIC visibility of plans and exercises
+ALTER TABLE programmes
+ADD COLUMN organization_id UUID,
+ADD COLUMN visibility visibilities NOT NULL DEFAULT 'PRIVATE',
+ADD CONSTRAINT fk_organization FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE SET NULL;
+
+UPDATE programmes SET visibility='PUBLIC' WHERE public=true;
+ALTER TABLE programmes DROP COLUMN public;
+
+CREATE INDEX idx_programmes_organization ON programmes (organization_id) WHERE organization_id IS NOT NULL;
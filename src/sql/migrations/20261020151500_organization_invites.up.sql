@@ -0,0 +1,20 @@
+CREATE TYPE organization_invite_status AS ENUM ('PENDING', 'ACCEPTED', 'DECLINED');
+
+-- members join by accepting an invite addressed to their email, role is given to them on accept
+CREATE TABLE organization_invites (
+  id UUID NOT NULL DEFAULT public.uuid_generate_v4() PRIMARY KEY,
+  organization_id UUID NOT NULL,
+  email VARCHAR(128) NOT NULL,
+  role VARCHAR(32) NOT NULL,
+  invited_by UUID,
+  status organization_invite_status NOT NULL DEFAULT 'PENDING',
+  responded_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  CONSTRAINT fk_organization FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE,
+  CONSTRAINT fk_role FOREIGN KEY (role) REFERENCES roles(name) ON DELETE CASCADE,
+  CONSTRAINT fk_invited_by FOREIGN KEY (invited_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE UNIQUE INDEX idx_organization_invites_pending ON organization_invites (organization_id, email) WHERE status='PENDING';
+CREATE INDEX idx_organization_invites_email ON organization_invites (email, status);
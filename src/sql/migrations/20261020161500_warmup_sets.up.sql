@@ -0,0 +1,2 @@
+-- warmup sets are logged like standard ones but never set personal records
+ALTER TYPE set_types ADD VALUE 'WARMUP';
@@ -0,0 +1,11 @@
+-- comments are removed with their target from now on, clear those left on targets deleted before
+DELETE FROM comments c
+WHERE NOT EXISTS (
+  SELECT 1 FROM plans WHERE c.target_type='PLAN' AND id=c.target_id
+  UNION ALL
+  SELECT 1 FROM plan_assignees WHERE c.target_type='PLAN_ASSIGNMENT' AND id=c.target_id
+  UNION ALL
+  SELECT 1 FROM workout_sessions WHERE c.target_type='WORKOUT_SESSION' AND id=c.target_id
+  UNION ALL
+  SELECT 1 FROM param_logs WHERE c.target_type='PARAM_LOG' AND id=c.target_id
+);
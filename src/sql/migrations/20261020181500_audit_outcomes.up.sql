@@ -0,0 +1,5 @@
+-- outcome is SUCCEEDED or FAILED with the error of the action, it is NULL on impersonated requests
+-- recorded before they run and on actions recorded before outcomes were
+ALTER TABLE admin_audit_logs
+ADD COLUMN outcome VARCHAR(16),
+ADD COLUMN error TEXT;
@@ -0,0 +1,6 @@
+UPDATE organization_invites SET
+  status='ACCEPTED',
+  responded_at=NOW(),
+  updated_at=NOW()
+WHERE id=$1 AND email=$2 AND status='PENDING'
+RETURNING id
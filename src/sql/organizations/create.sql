@@ -0,0 +1,3 @@
+INSERT INTO organizations (name, description, user_id)
+VALUES ($1, $2, $3)
+RETURNING id
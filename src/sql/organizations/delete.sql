@@ -0,0 +1,10 @@
+WITH shared_exercises AS (
+  UPDATE exercises SET visibility='PRIVATE'
+  WHERE organization_id=$1 AND visibility='ORG'
+),
+shared_plans AS (
+  UPDATE plans SET visibility='PRIVATE'
+  WHERE organization_id=$1 AND visibility='ORG'
+)
+DELETE FROM organizations WHERE id=$1
+RETURNING id
@@ -0,0 +1,4 @@
+SELECT o.*,
+  (SELECT COUNT(DISTINCT ur.user_id) FROM user_roles ur WHERE ur.organization_id=o.id) AS members
+FROM organizations o
+WHERE o.id IN (?)
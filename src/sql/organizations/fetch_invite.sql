@@ -0,0 +1,4 @@
+SELECT oi.*, o.name AS organization_name
+FROM organization_invites oi
+JOIN organizations o ON o.id=oi.organization_id
+WHERE oi.id IN (?)
@@ -0,0 +1,8 @@
+INSERT INTO organization_invites (organization_id, email, role, invited_by)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (organization_id, email) WHERE status='PENDING'
+DO UPDATE SET
+  role=EXCLUDED.role,
+  invited_by=EXCLUDED.invited_by,
+  updated_at=NOW()
+RETURNING id
@@ -0,0 +1,3 @@
+SELECT EXISTS (
+  SELECT 1 FROM user_roles WHERE user_id=$1 AND organization_id=$2
+) AS member
@@ -0,0 +1,5 @@
+SELECT o.id, COUNT(*) OVER () AS total_count
+FROM organizations o
+WHERE o.id IN (SELECT ur.organization_id FROM user_roles ur WHERE ur.user_id=$1)
+ORDER BY o.name, o.created_at
+LIMIT $2 OFFSET $3
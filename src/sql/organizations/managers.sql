@@ -0,0 +1,5 @@
+SELECT
+  COALESCE(bool_or(user_id=$2), false) AS manager,
+  COUNT(DISTINCT user_id) FILTER (WHERE user_id<>$2) AS others
+FROM user_roles
+WHERE organization_id=$1 AND role='manager'
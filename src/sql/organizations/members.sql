@@ -0,0 +1,10 @@
+SELECT u.id, u.username, u.first_name, u.last_name, u.email, u.avatar_id,
+  array_agg(ur.role ORDER BY ur.role) AS roles,
+  MIN(ur.created_at) AS joined_at,
+  COUNT(*) OVER () AS total_count
+FROM user_roles ur
+JOIN users u ON u.id=ur.user_id
+WHERE ur.organization_id=$1 AND u.deleted_at IS NULL
+GROUP BY u.id
+ORDER BY joined_at, u.username
+LIMIT $2 OFFSET $3
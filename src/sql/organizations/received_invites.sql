@@ -0,0 +1,6 @@
+SELECT oi.*, o.name AS organization_name, COUNT(*) OVER () AS total_count
+FROM organization_invites oi
+JOIN organizations o ON o.id=oi.organization_id
+WHERE oi.email=$1 AND oi.status='PENDING'
+ORDER BY oi.created_at DESC
+LIMIT $2 OFFSET $3
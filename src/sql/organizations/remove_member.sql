@@ -0,0 +1,3 @@
+DELETE FROM user_roles
+WHERE user_id=$1 AND organization_id=$2
+RETURNING id
@@ -0,0 +1,9 @@
+WITH others AS (
+  DELETE FROM user_roles
+  WHERE user_id=$1 AND organization_id=$2 AND role<>$3
+)
+INSERT INTO user_roles (user_id, role, organization_id, granted_by)
+VALUES ($1, $3, $2, $4)
+ON CONFLICT (user_id, role, COALESCE(organization_id, '00000000-0000-0000-0000-000000000000')) DO UPDATE
+SET granted_by=EXCLUDED.granted_by
+RETURNING *
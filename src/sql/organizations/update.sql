@@ -0,0 +1,6 @@
+UPDATE organizations SET
+    name=$2,
+    description=$3,
+    updated_at=NOW()
+WHERE id=$1
+RETURNING id
@@ -1,3 +1,3 @@
-INSERT INTO otps(user_id, code, perpose)
-VALUES ($1, $2, $3)
+INSERT INTO otps(user_id, code, perpose, target)
+VALUES ($1, $2, $3, $4)
 RETURNING *
\ No newline at end of file
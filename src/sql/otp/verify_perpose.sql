@@ -0,0 +1,4 @@
+UPDATE otps
+SET is_verified=true
+WHERE user_id=$1 AND code=$2 AND perpose=$3 AND is_verified=false AND expired_at > NOW()
+RETURNING *
\ No newline at end of file
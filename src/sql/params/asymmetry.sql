@@ -0,0 +1,15 @@
+WITH buckets AS (
+  SELECT date_trunc($3, pl.created_at) AS bucket,
+    AVG(pl.value) FILTER (WHERE pl.side='LEFT')::float AS "left",
+    AVG(pl.value) FILTER (WHERE pl.side='RIGHT')::float AS "right"
+  FROM param_logs pl
+  WHERE pl.param_id=$1 AND pl.user_id=$2 AND pl.created_at >= $4 AND pl.created_at < $5
+    AND pl.side IN ('LEFT', 'RIGHT')
+  GROUP BY 1
+)
+SELECT bucket, "left", "right",
+  "left" - "right" AS difference,
+  COALESCE(("left" - "right") / NULLIF(GREATEST(ABS("left"), ABS("right")), 0) * 100, 0) AS asymmetry
+FROM buckets
+WHERE "left" IS NOT NULL AND "right" IS NOT NULL
+ORDER BY bucket
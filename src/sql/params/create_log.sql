@@ -0,0 +1,3 @@
+INSERT INTO param_logs (user_id, param_id, side, value, note)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *
@@ -0,0 +1 @@
+SELECT * FROM params WHERE id IN (?)
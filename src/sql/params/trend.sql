@@ -0,0 +1,20 @@
+WITH buckets AS (
+  SELECT date_trunc($3, pl.created_at) AS bucket, pl.side,
+    COUNT(*)::int AS count,
+    MIN(pl.value)::float AS min,
+    MAX(pl.value)::float AS max,
+    SUM(pl.value)::float AS total,
+    AVG(pl.value)::float AS average
+  FROM param_logs pl
+  WHERE pl.param_id=$1 AND pl.user_id=$2 AND pl.created_at >= $4 AND pl.created_at < $5
+  GROUP BY 1, 2
+)
+SELECT bucket, side, count, min, max, average,
+  SUM(total) OVER moving / SUM(count) OVER moving AS moving_average,
+  average - LAG(average) OVER previous AS change,
+  (average - LAG(average) OVER previous)
+    / (EXTRACT(EPOCH FROM bucket - LAG(bucket) OVER previous) / 86400)::float AS rate_per_day
+FROM buckets
+WINDOW moving AS (PARTITION BY side ORDER BY bucket RANGE BETWEEN $6::interval PRECEDING AND CURRENT ROW),
+  previous AS (PARTITION BY side ORDER BY bucket)
+ORDER BY bucket, side
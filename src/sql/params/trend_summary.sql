@@ -0,0 +1,12 @@
+SELECT pl.side,
+  COUNT(*)::int AS count,
+  MIN(pl.value)::float AS min,
+  MAX(pl.value)::float AS max,
+  AVG(pl.value)::float AS average,
+  (array_agg(pl.value ORDER BY pl.created_at))[1]::float AS first,
+  (array_agg(pl.value ORDER BY pl.created_at DESC))[1]::float AS last,
+  COALESCE(regr_slope(pl.value::float, EXTRACT(EPOCH FROM pl.created_at)::float) * 86400, 0) AS rate_per_day
+FROM param_logs pl
+WHERE pl.param_id=$1 AND pl.user_id=$2 AND pl.created_at >= $3 AND pl.created_at < $4
+GROUP BY pl.side
+ORDER BY pl.side
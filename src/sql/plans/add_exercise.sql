@@ -0,0 +1,7 @@
+INSERT INTO plan_exercises (plan_id, group_id, exercise_id, exercise_order, rest_time, notes)
+VALUES (
+  $1, $2, $3,
+  COALESCE($4, (SELECT COALESCE(MAX(exercise_order), 0) + 1 FROM plan_exercises WHERE group_id=$2)),
+  $5, $6
+)
+RETURNING *
@@ -0,0 +1,3 @@
+INSERT INTO plans (user_id, name, description, visibility, language, organization_id)
+VALUES ($1, $2, $3, $4, COALESCE($5::regconfig, 'english'), $6)
+RETURNING *
@@ -0,0 +1,2 @@
+INSERT INTO plan_exercises (plan_id, group_id, exercise_id, exercise_order, rest_time, notes)
+VALUES (:plan_id, :group_id, :exercise_id, :exercise_order, :rest_time, :notes)
@@ -0,0 +1,7 @@
+INSERT INTO plan_groups (plan_id, group_order, group_type, name, rounds, rest_time)
+VALUES (
+  $1,
+  COALESCE($2, (SELECT COALESCE(MAX(group_order), 0) + 1 FROM plan_groups WHERE plan_id=$1)),
+  $3, $4, $5, $6
+)
+RETURNING *
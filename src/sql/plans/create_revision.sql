@@ -0,0 +1,26 @@
+INSERT INTO plan_revisions (plan_id, revision, snapshot)
+SELECT p.id,
+  (SELECT COALESCE(MAX(revision), 0) + 1 FROM plan_revisions WHERE plan_id=p.id),
+  to_jsonb(p) - 'search_vector' || jsonb_build_object(
+    'groups', COALESCE((SELECT
+      jsonb_agg(to_jsonb(g) || jsonb_build_object(
+        'exercises', (SELECT
+          jsonb_agg(to_jsonb(pe) || jsonb_build_object(
+            'exercise', jsonb_build_object(
+              'id', e.id,
+              'name', e.name,
+              'sets', (SELECT jsonb_agg(to_jsonb(s) ORDER BY s.set_number) FROM sets s WHERE s.exercise_id=e.id)
+            )
+          ) ORDER BY pe.exercise_order, pe.created_at)
+          FROM plan_exercises pe
+          JOIN exercises e ON e.id=pe.exercise_id
+          WHERE pe.group_id=g.id
+        )
+      ) ORDER BY g.group_order, g.created_at)
+      FROM plan_groups g
+      WHERE g.plan_id=p.id
+    ), '[]')
+  )
+FROM plans p
+WHERE p.id=$1
+RETURNING id
@@ -0,0 +1,6 @@
+WITH plan_comments AS (
+  DELETE FROM comments
+  WHERE (target_type='PLAN' AND target_id=$1)
+    OR (target_type='PLAN_ASSIGNMENT' AND target_id IN (SELECT id FROM plan_assignees WHERE plan_id=$1))
+)
+DELETE FROM plans WHERE id=$1 RETURNING id
@@ -0,0 +1 @@
+DELETE FROM plan_groups WHERE id=$1 RETURNING id
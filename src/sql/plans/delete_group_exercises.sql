@@ -0,0 +1 @@
+DELETE FROM plan_exercises WHERE group_id=$1
@@ -0,0 +1 @@
+DELETE FROM plan_groups WHERE plan_id=$1
@@ -0,0 +1,43 @@
+SELECT p.*, p.visibility='PUBLIC' AS public,
+  (SELECT
+    jsonb_agg(json_build_object(
+        'id', g.id,
+        'plan_id', g.plan_id,
+        'group_order', g.group_order,
+        'group_type', g.group_type,
+        'name', g.name,
+        'rounds', g.rounds,
+        'rest_time', g.rest_time,
+        'created_at', g.created_at,
+        'updated_at', g.updated_at,
+        'exercises', (SELECT
+          jsonb_agg(json_build_object(
+              'id', pe.id,
+              'plan_id', pe.plan_id,
+              'group_id', pe.group_id,
+              'exercise_id', pe.exercise_id,
+              'exercise_order', pe.exercise_order,
+              'rest_time', pe.rest_time,
+              'notes', pe.notes,
+              'created_at', pe.created_at,
+              'exercise', json_build_object(
+                'id', e.id,
+                'user_id', e.user_id,
+                'name', e.name,
+                'description', e.description,
+                'visibility', e.visibility,
+                'organization_id', e.organization_id,
+                'sets', (SELECT jsonb_agg(to_jsonb(s) ORDER BY s.set_number) FROM sets s WHERE s.exercise_id=e.id)
+              )
+            ) ORDER BY pe.exercise_order, pe.created_at)
+            FROM plan_exercises pe
+            JOIN exercises e ON e.id=pe.exercise_id
+            WHERE pe.group_id=g.id
+        )
+      ) ORDER BY g.group_order, g.created_at)
+      FROM plan_groups g
+      WHERE g.plan_id=p.id
+  ) AS groups,
+  (SELECT COUNT(*) FROM plans f WHERE f.forked_from=p.id) AS forks
+FROM plans p
+WHERE p.id IN (?)
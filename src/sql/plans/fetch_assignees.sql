@@ -0,0 +1 @@
+SELECT * FROM plan_assignees WHERE id IN (?)
\ No newline at end of file
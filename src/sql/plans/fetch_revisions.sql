@@ -0,0 +1 @@
+SELECT * FROM plan_revisions WHERE id IN (?)
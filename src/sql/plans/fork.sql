@@ -0,0 +1,5 @@
+INSERT INTO plans (user_id, name, description, visibility, language, forked_from)
+SELECT $2, name, description, 'PRIVATE', language, id
+FROM plans
+WHERE id=$1
+RETURNING *
@@ -0,0 +1 @@
+SELECT * FROM plan_revisions WHERE plan_id=$1 AND revision=$2
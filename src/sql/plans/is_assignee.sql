@@ -0,0 +1,4 @@
+SELECT EXISTS (
+  SELECT 1 FROM plan_assignees
+  WHERE plan_id=$1 AND user_id=$2
+) AS assignee
@@ -0,0 +1,8 @@
+SELECT p.id, COUNT(*) OVER () AS total_count
+FROM plans p
+WHERE (p.user_id=$1 OR p.visibility='PUBLIC'
+    OR (p.visibility='ORG' AND p.organization_id IN (SELECT ur.organization_id FROM user_roles ur WHERE ur.user_id=$1)))
+  AND ($2::text IS NULL OR p.visibility::text=$2)
+  AND ($5::uuid IS NULL OR p.organization_id=$5)
+ORDER BY p.created_at DESC
+LIMIT $3 OFFSET $4
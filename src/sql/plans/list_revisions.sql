@@ -0,0 +1,5 @@
+SELECT id, COUNT(*) OVER () AS total_count
+FROM plan_revisions
+WHERE plan_id=$1
+ORDER BY revision DESC
+LIMIT $2 OFFSET $3
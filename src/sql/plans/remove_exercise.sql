@@ -0,0 +1,22 @@
+WITH removed AS (
+  DELETE FROM plan_exercises WHERE plan_id=$1 AND exercise_id=$2
+  RETURNING id, group_id
+), emptied AS (
+  DELETE FROM plan_groups g
+  WHERE g.id IN (SELECT group_id FROM removed)
+    AND NOT EXISTS (
+      SELECT 1 FROM plan_exercises pe
+      WHERE pe.group_id=g.id AND pe.id NOT IN (SELECT id FROM removed)
+    )
+  RETURNING g.id
+), singled AS (
+  UPDATE plan_groups g SET group_type='SINGLE', updated_at=NOW()
+  WHERE g.id IN (SELECT group_id FROM removed)
+    AND g.group_type <> 'SINGLE'
+    AND (
+      SELECT COUNT(*) FROM plan_exercises pe
+      WHERE pe.group_id=g.id AND pe.id NOT IN (SELECT id FROM removed)
+    )=1
+  RETURNING g.id
+)
+SELECT id FROM removed
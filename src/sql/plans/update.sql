@@ -0,0 +1,9 @@
+UPDATE plans SET
+    name=$2,
+    description=$3,
+    visibility=$4,
+    organization_id=$6,
+    language=COALESCE($5::regconfig, language),
+    updated_at=NOW()
+WHERE id=$1
+RETURNING *
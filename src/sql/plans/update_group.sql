@@ -0,0 +1,9 @@
+UPDATE plan_groups SET
+    group_order=COALESCE($2, group_order),
+    group_type=$3,
+    name=$4,
+    rounds=$5,
+    rest_time=$6,
+    updated_at=NOW()
+WHERE id=$1
+RETURNING *
@@ -0,0 +1,3 @@
+INSERT INTO programmes (user_id, name, description, visibility, organization_id, weeks)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *
@@ -0,0 +1,3 @@
+INSERT INTO programme_assignments (programme_id, user_id, assigned_by, start_at)
+VALUES ($1, $2, $3, $4)
+RETURNING *
@@ -0,0 +1,2 @@
+INSERT INTO programme_days (programme_id, week, day, plan_id, note)
+VALUES (:programme_id, :week, :day, :plan_id, :note)
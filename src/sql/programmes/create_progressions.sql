@@ -0,0 +1,2 @@
+INSERT INTO programme_progressions (programme_id, plan_id, exercise_id, target, amount, per, max)
+VALUES (:programme_id, :plan_id, :exercise_id, :target, :amount, :per, :max)
@@ -0,0 +1,2 @@
+INSERT INTO programme_sessions (assignment_id, plan_id, week, day, scheduled_at, plan)
+VALUES (:assignment_id, :plan_id, :week, :day, :scheduled_at, :plan)
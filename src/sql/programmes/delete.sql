@@ -0,0 +1 @@
+DELETE FROM programmes WHERE id=$1 RETURNING id
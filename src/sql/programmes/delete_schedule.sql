@@ -0,0 +1,4 @@
+WITH days AS (
+  DELETE FROM programme_days WHERE programme_id=$1
+)
+DELETE FROM programme_progressions WHERE programme_id=$1
@@ -0,0 +1,29 @@
+SELECT p.*, p.visibility='PUBLIC' AS public,
+  (SELECT
+    jsonb_agg(json_build_object(
+        'id', d.id,
+        'programme_id', d.programme_id,
+        'week', d.week,
+        'day', d.day,
+        'plan_id', d.plan_id,
+        'note', d.note
+      ) ORDER BY d.week, d.day)
+      FROM programme_days d
+      WHERE d.programme_id=p.id
+  ) AS days,
+  (SELECT
+    jsonb_agg(json_build_object(
+        'id', pp.id,
+        'programme_id', pp.programme_id,
+        'plan_id', pp.plan_id,
+        'exercise_id', pp.exercise_id,
+        'target', pp.target,
+        'amount', pp.amount,
+        'per', pp.per,
+        'max', pp.max
+      ))
+      FROM programme_progressions pp
+      WHERE pp.programme_id=p.id
+  ) AS progressions
+FROM programmes p
+WHERE p.id IN (?)
@@ -0,0 +1,17 @@
+SELECT pa.*,
+  (SELECT
+    jsonb_agg(json_build_object(
+        'id', ps.id,
+        'assignment_id', ps.assignment_id,
+        'plan_id', ps.plan_id,
+        'week', ps.week,
+        'day', ps.day,
+        'scheduled_at', ps.scheduled_at,
+        'plan', ps.plan,
+        'created_at', ps.created_at
+      ) ORDER BY ps.scheduled_at)
+      FROM programme_sessions ps
+      WHERE ps.assignment_id=pa.id
+  ) AS sessions
+FROM programme_assignments pa
+WHERE pa.id IN (?)
@@ -0,0 +1,6 @@
+SELECT p.id, COUNT(*) OVER () AS total_count
+FROM programmes p
+WHERE p.user_id=$1 OR p.visibility='PUBLIC'
+  OR (p.visibility='ORG' AND p.organization_id IN (SELECT ur.organization_id FROM user_roles ur WHERE ur.user_id=$1))
+ORDER BY p.created_at DESC
+LIMIT $2 OFFSET $3
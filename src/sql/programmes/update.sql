@@ -0,0 +1,9 @@
+UPDATE programmes SET
+    name=$2,
+    description=$3,
+    visibility=$4,
+    organization_id=$5,
+    weeks=$6,
+    updated_at=NOW()
+WHERE id=$1
+RETURNING *
@@ -0,0 +1,17 @@
+SELECT r.*, e.name AS exercise_name, COUNT(*) OVER () AS total_count
+FROM (
+  SELECT DISTINCT ON (pr.exercise_id, pr.kind, pr.load) pr.*
+  FROM personal_records pr
+  WHERE pr.user_id=$1
+    AND ($2::uuid IS NULL OR pr.exercise_id=$2)
+    AND ($3::text IS NULL OR pr.kind::text=$3)
+  ORDER BY pr.exercise_id, pr.kind, pr.load, pr.value DESC, pr.achieved_at
+) r
+JOIN exercises e ON e.id=r.exercise_id
+WHERE NOT EXISTS (
+  SELECT 1 FROM personal_records o
+  WHERE o.user_id=r.user_id AND o.exercise_id=r.exercise_id AND o.kind=r.kind
+    AND o.load > r.load AND o.value >= r.value
+)
+ORDER BY e.name, r.exercise_id, r.kind, r.load DESC NULLS LAST
+LIMIT $4 OFFSET $5
@@ -0,0 +1,43 @@
+WITH log AS (
+  SELECT sl.id, ws.user_id, sl.exercise_id, sl.rep_count, sl.duration, sl.created_at, sl.load,
+    sl.load * CASE sl.unit WHEN 'KG' THEN 1 WHEN 'LB' THEN 0.45359237 END AS load_kg
+  FROM set_logs sl
+  JOIN workout_sessions ws ON ws.id=sl.session_id
+  WHERE sl.id=$1 AND sl.prescribed_set_type IS DISTINCT FROM 'WARMUP'
+),
+candidates AS (
+  SELECT 'MAX_LOAD'::record_kinds AS kind, ROUND(load_kg, 2) AS value, 'KG'::units AS unit, NULL::numeric AS load
+  FROM log WHERE load_kg > 0
+  UNION ALL
+  SELECT 'MAX_REPS', rep_count, 'COUNT', ROUND(load_kg, 2)
+  FROM log WHERE rep_count > 0 AND (load IS NULL OR load_kg IS NOT NULL)
+  UNION ALL
+  SELECT 'E1RM', ROUND(CASE
+      WHEN rep_count=1 THEN load_kg
+      WHEN rep_count <= 10 THEN load_kg * 36 / (37 - rep_count)
+      ELSE load_kg * (1 + rep_count / 30.0)
+    END, 2), 'KG', NULL
+  FROM log WHERE load_kg > 0 AND rep_count > 0
+  UNION ALL
+  SELECT 'MAX_DURATION', ROUND(duration / 1e9, 2), 'SECOND', NULL
+  FROM log WHERE duration > 0
+),
+compared AS (
+  SELECT c.*,
+    (SELECT MAX(pr.value) FROM personal_records pr
+      WHERE pr.user_id=log.user_id AND pr.exercise_id=log.exercise_id AND pr.kind=c.kind
+        AND ((c.load IS NULL AND pr.load IS NULL) OR pr.load >= c.load)
+    ) AS previous_value
+  FROM candidates c, log
+),
+inserted AS (
+  INSERT INTO personal_records (user_id, exercise_id, set_log_id, kind, value, unit, load, previous_value, achieved_at)
+  SELECT log.user_id, log.exercise_id, log.id, c.kind, c.value, c.unit, c.load, c.previous_value, log.created_at
+  FROM compared c, log
+  WHERE c.previous_value IS NULL OR c.value > c.previous_value
+  RETURNING *
+)
+SELECT i.*, e.name AS exercise_name
+FROM inserted i
+JOIN exercises e ON e.id=i.exercise_id
+ORDER BY i.kind
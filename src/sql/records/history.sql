@@ -0,0 +1,7 @@
+SELECT pr.*, e.name AS exercise_name, COUNT(*) OVER () AS total_count
+FROM personal_records pr
+JOIN exercises e ON e.id=pr.exercise_id
+WHERE pr.user_id=$1 AND pr.exercise_id=$2
+  AND ($3::text IS NULL OR pr.kind::text=$3)
+ORDER BY pr.achieved_at DESC, pr.kind
+LIMIT $4 OFFSET $5
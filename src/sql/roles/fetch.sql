@@ -0,0 +1 @@
+SELECT * FROM roles WHERE name IN (?)
\ No newline at end of file
@@ -0,0 +1 @@
+SELECT * FROM user_roles WHERE user_id=$1 ORDER BY created_at
\ No newline at end of file
@@ -0,0 +1 @@
+SELECT * FROM user_roles WHERE id IN (?)
\ No newline at end of file
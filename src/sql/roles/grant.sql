@@ -0,0 +1,5 @@
+INSERT INTO user_roles (user_id, role, organization_id, granted_by)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, role, COALESCE(organization_id, '00000000-0000-0000-0000-000000000000')) DO UPDATE
+SET granted_by=EXCLUDED.granted_by
+RETURNING *
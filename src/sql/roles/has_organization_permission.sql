@@ -0,0 +1,7 @@
+SELECT EXISTS (
+  SELECT 1 FROM user_roles ur
+  JOIN role_permissions rp ON rp.role=ur.role
+  WHERE ur.user_id=$1
+    AND (ur.organization_id=$2 OR (ur.organization_id IS NULL AND rp.permission='*'))
+    AND (rp.permission=$3 OR rp.permission='*')
+) AS allowed
@@ -0,0 +1,7 @@
+SELECT r.*,
+  COALESCE(
+    (SELECT array_agg(rp.permission ORDER BY rp.permission) FROM role_permissions rp WHERE rp.role=r.name),
+    '{}'
+  ) AS permissions
+FROM roles r
+ORDER BY r.name
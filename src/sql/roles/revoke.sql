@@ -0,0 +1,3 @@
+DELETE FROM user_roles
+WHERE user_id=$1 AND role=$2 AND organization_id IS NOT DISTINCT FROM $3
+RETURNING *
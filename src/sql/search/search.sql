@@ -0,0 +1,40 @@
+WITH queries AS (
+  SELECT l.language, websearch_to_tsquery(l.language, immutable_unaccent($2)) AS query
+  FROM unnest($6::regconfig[]) AS l(language)
+)
+SELECT r.*, COUNT(*) OVER () AS total_count
+FROM (
+  SELECT
+    'exercise' AS type,
+    e.id,
+    e.user_id,
+    e.name,
+    e.visibility,
+    ts_rank_cd(e.search_vector, q.query) AS rank,
+    ts_headline(e.language, e.name, q.query, 'HighlightAll=true') AS headline,
+    ts_headline(e.language, e.description, q.query, 'MaxFragments=2, MaxWords=20, MinWords=5') AS snippet,
+    e.created_at
+  FROM queries q
+  JOIN exercises e ON e.language=q.language AND e.search_vector @@ q.query
+  WHERE (e.visibility='PUBLIC' OR e.user_id=$1
+    OR (e.visibility='ORG' AND e.organization_id IN (SELECT ur.organization_id FROM user_roles ur WHERE ur.user_id=$1)))
+    AND ($3::text IS NULL OR $3='exercise')
+  UNION ALL
+  SELECT
+    'plan' AS type,
+    p.id,
+    p.user_id,
+    p.name,
+    p.visibility,
+    ts_rank_cd(p.search_vector, q.query) AS rank,
+    ts_headline(p.language, p.name, q.query, 'HighlightAll=true') AS headline,
+    ts_headline(p.language, COALESCE(p.description, ''), q.query, 'MaxFragments=2, MaxWords=20, MinWords=5') AS snippet,
+    p.created_at
+  FROM queries q
+  JOIN plans p ON p.language=q.language AND p.search_vector @@ q.query
+  WHERE (p.visibility='PUBLIC' OR p.user_id=$1
+    OR (p.visibility='ORG' AND p.organization_id IN (SELECT ur.organization_id FROM user_roles ur WHERE ur.user_id=$1)))
+    AND ($3::text IS NULL OR $3='plan')
+) r
+ORDER BY r.rank DESC, r.created_at DESC
+LIMIT $4 OFFSET $5
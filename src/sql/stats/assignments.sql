@@ -0,0 +1,60 @@
+WITH prescribed AS (
+  SELECT pg.plan_id, SUM(pg.rounds * sc.sets)::int AS sets
+  FROM plan_groups pg
+  JOIN plan_exercises pe ON pe.group_id=pg.id
+  JOIN (SELECT exercise_id, COUNT(*) AS sets FROM sets GROUP BY exercise_id) sc ON sc.exercise_id=pe.exercise_id
+  GROUP BY pg.plan_id
+),
+progress AS (
+  SELECT pa.id, pa.plan_id, pa.user_id, pa.due_at, pa.created_at,
+    COALESCE(pr.sets, 0) AS prescribed_sets,
+    COUNT(sl.id)::int AS logged_sets,
+    COUNT(sl.id) FILTER (WHERE sl.created_at <= pa.due_at)::int AS logged_sets_by_due,
+    COUNT(DISTINCT ws.id)::int AS sessions,
+    COUNT(DISTINCT ws.id) FILTER (WHERE ws.finished_at IS NOT NULL)::int AS finished_sessions,
+    COUNT(DISTINCT ws.id) FILTER (WHERE ws.finished_at <= pa.due_at)::int AS finished_sessions_by_due,
+    MAX(COALESCE(ws.finished_at, ws.started_at)) AS last_activity_at
+  FROM plan_assignees pa
+  LEFT JOIN prescribed pr ON pr.plan_id=pa.plan_id
+  LEFT JOIN workout_sessions ws ON ws.plan_assignee_id=pa.id
+  LEFT JOIN set_logs sl ON sl.session_id=ws.id
+    AND sl.exercise_id IN (SELECT pe.exercise_id FROM plan_exercises pe WHERE pe.plan_id=pa.plan_id)
+  WHERE ($1::uuid IS NULL OR pa.plan_id=$1) AND ($2::uuid IS NULL OR pa.user_id=$2)
+  GROUP BY pa.id, pr.sets
+),
+assignments AS (
+  SELECT p.*,
+    CASE
+      WHEN p.prescribed_sets > 0 THEN ROUND(LEAST(p.logged_sets, p.prescribed_sets) * 100.0 / p.prescribed_sets, 1)
+      WHEN p.finished_sessions > 0 THEN 100
+      ELSE 0
+    END::float AS completion,
+    CASE
+      WHEN p.prescribed_sets > 0 THEN p.logged_sets >= p.prescribed_sets
+      ELSE p.finished_sessions > 0
+    END AS completed,
+    COALESCE(p.due_at < NOW(), false) AND NOT CASE
+      WHEN p.prescribed_sets > 0 THEN p.logged_sets_by_due >= p.prescribed_sets
+      ELSE p.finished_sessions_by_due > 0
+    END AS missed
+  FROM progress p
+)
+SELECT a.id, a.plan_id, pl.name AS plan_name, a.user_id,
+  json_build_object(
+    'id', u.id,
+    'username', u.username,
+    'first_name', u.first_name,
+    'last_name', u.last_name,
+    'avatar_id', u.avatar_id
+  ) AS "user",
+  a.due_at, a.created_at, a.prescribed_sets, a.logged_sets, a.sessions, a.finished_sessions,
+  a.last_activity_at, a.completion, a.completed, a.missed,
+  COUNT(*) OVER () AS total_count,
+  (COUNT(*) FILTER (WHERE a.completed) OVER ())::int AS completed_count,
+  (COUNT(*) FILTER (WHERE a.missed) OVER ())::int AS missed_count,
+  COALESCE(ROUND((AVG(a.completion) OVER ())::numeric, 1), 0)::float AS average_completion
+FROM assignments a
+JOIN plans pl ON pl.id=a.plan_id
+JOIN users u ON u.id=a.user_id
+ORDER BY a.due_at NULLS LAST, a.created_at
+LIMIT $3 OFFSET $4
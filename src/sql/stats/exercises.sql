@@ -0,0 +1,15 @@
+SELECT sl.exercise_id, e.name,
+  COUNT(DISTINCT ws.id)::int AS sessions,
+  COUNT(*)::int AS sets,
+  COALESCE(SUM(sl.rep_count), 0)::int AS reps,
+  COALESCE(SUM(sl.duration), 0)::bigint AS duration,
+  COALESCE(SUM(sl.rep_count * sl.load * CASE sl.unit WHEN 'KG' THEN 1 WHEN 'LB' THEN 0.45359237 END), 0)::float AS volume,
+  MAX(ws.started_at) AS last_performed_at
+FROM set_logs sl
+JOIN workout_sessions ws ON ws.id=sl.session_id
+JOIN exercises e ON e.id=sl.exercise_id
+LEFT JOIN plan_assignees pa ON pa.id=ws.plan_assignee_id
+WHERE ($1::uuid IS NULL OR pa.plan_id=$1) AND ($2::uuid IS NULL OR ws.user_id=$2)
+GROUP BY sl.exercise_id, e.name
+ORDER BY volume DESC, sets DESC, e.name
+LIMIT $3
@@ -0,0 +1,26 @@
+WITH days AS (
+  SELECT DISTINCT started_at::date AS day FROM workout_sessions WHERE user_id=$1
+),
+streaks AS (
+  SELECT MAX(day) AS ended, COUNT(*)::int AS length
+  FROM (SELECT day, day - (ROW_NUMBER() OVER (ORDER BY day))::int AS grp FROM days) d
+  GROUP BY grp
+),
+sessions AS (
+  SELECT COUNT(*)::int AS sessions,
+    (COUNT(*) FILTER (WHERE finished_at IS NOT NULL))::int AS finished_sessions,
+    MAX(started_at) AS last_workout_at
+  FROM workout_sessions WHERE user_id=$1
+),
+sets AS (
+  SELECT COUNT(*)::int AS sets,
+    COALESCE(SUM(sl.rep_count), 0)::int AS reps,
+    COALESCE(SUM(sl.rep_count * sl.load * CASE sl.unit WHEN 'KG' THEN 1 WHEN 'LB' THEN 0.45359237 END), 0)::float AS volume
+  FROM set_logs sl
+  JOIN workout_sessions ws ON ws.id=sl.session_id
+  WHERE ws.user_id=$1
+)
+SELECT s.sessions, s.finished_sessions, s.last_workout_at, st.sets, st.reps, st.volume,
+  COALESCE((SELECT MAX(length) FROM streaks), 0) AS longest_streak,
+  COALESCE((SELECT length FROM streaks WHERE ended >= CURRENT_DATE - 1), 0) AS current_streak
+FROM sessions s, sets st
@@ -0,0 +1,4 @@
+UPDATE users
+SET deleted_at=NOW()
+WHERE id=$1 AND deleted_at IS NULL
+RETURNING *
\ No newline at end of file
@@ -0,0 +1 @@
+UPDATE users SET password_expired=true WHERE id=$1 RETURNING *
\ No newline at end of file
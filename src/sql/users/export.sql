@@ -0,0 +1,38 @@
+SELECT
+  (SELECT to_jsonb(u) - 'password' FROM users u WHERE u.id=$1) AS profile,
+  (SELECT COALESCE(jsonb_agg(to_jsonb(e) - 'search_vector' || jsonb_build_object(
+      'sets', (SELECT COALESCE(jsonb_agg(to_jsonb(s) ORDER BY s.set_number), '[]'::jsonb) FROM sets s WHERE s.exercise_id=e.id)
+    ) ORDER BY e.created_at), '[]'::jsonb)
+    FROM exercises e WHERE e.user_id=$1
+  ) AS exercises,
+  (SELECT COALESCE(jsonb_agg(to_jsonb(p) - 'search_vector' || jsonb_build_object(
+      'exercises', (SELECT COALESCE(jsonb_agg(to_jsonb(pe) ORDER BY pe.exercise_order), '[]'::jsonb) FROM plan_exercises pe WHERE pe.plan_id=p.id),
+      'assignees', (SELECT COALESCE(jsonb_agg(to_jsonb(pa)), '[]'::jsonb) FROM plan_assignees pa WHERE pa.plan_id=p.id)
+    ) ORDER BY p.created_at), '[]'::jsonb)
+    FROM plans p WHERE p.user_id=$1
+  ) AS plans,
+  (SELECT COALESCE(jsonb_agg(to_jsonb(pa) ORDER BY pa.due_at), '[]'::jsonb)
+    FROM plan_assignees pa WHERE pa.user_id=$1
+  ) AS assigned_plans,
+  (SELECT COALESCE(jsonb_agg(to_jsonb(pl) || jsonb_build_object('param', p.name, 'unit', p.unit, 'side', p.side) ORDER BY pl.created_at), '[]'::jsonb)
+    FROM param_logs pl JOIN params p ON p.id=pl.param_id WHERE pl.user_id=$1
+  ) AS param_logs,
+  (SELECT COALESCE(jsonb_agg(to_jsonb(m) ORDER BY m.created_at), '[]'::jsonb)
+    FROM media m WHERE m.user_id=$1
+  ) AS media,
+  (SELECT COALESCE(jsonb_agg(to_jsonb(ws) || jsonb_build_object(
+      'set_logs', (SELECT COALESCE(jsonb_agg(to_jsonb(sl) ORDER BY sl.created_at), '[]'::jsonb) FROM set_logs sl WHERE sl.session_id=ws.id)
+    ) ORDER BY ws.started_at), '[]'::jsonb)
+    FROM workout_sessions ws WHERE ws.user_id=$1
+  ) AS workout_sessions,
+  (SELECT COALESCE(jsonb_agg(to_jsonb(pr) || jsonb_build_object('exercise', e.name) ORDER BY pr.achieved_at), '[]'::jsonb)
+    FROM personal_records pr JOIN exercises e ON e.id=pr.exercise_id WHERE pr.user_id=$1
+  ) AS personal_records,
+  (SELECT COALESCE(jsonb_agg(to_jsonb(g) ORDER BY g.created_at), '[]'::jsonb)
+    FROM goals g WHERE g.user_id=$1
+  ) AS goals,
+  (SELECT COALESCE(jsonb_agg(to_jsonb(cm) || jsonb_build_object(
+      'revisions', (SELECT COALESCE(jsonb_agg(to_jsonb(cr) ORDER BY cr.replaced_at), '[]'::jsonb) FROM comment_revisions cr WHERE cr.comment_id=cm.id)
+    ) ORDER BY cm.created_at), '[]'::jsonb)
+    FROM comments cm WHERE cm.user_id=$1
+  ) AS comments
@@ -0,0 +1 @@
+SELECT * FROM users WHERE phone = $1
\ No newline at end of file
@@ -0,0 +1,30 @@
+WITH purged AS (
+  SELECT id FROM users
+  WHERE deleted_at IS NOT NULL AND deleted_at < NOW() - $1::interval
+),
+private_exercises AS (
+  DELETE FROM exercises
+  WHERE user_id IN (SELECT id FROM purged) AND visibility='PRIVATE'
+),
+public_exercises AS (
+  UPDATE exercises SET owner_deleted_at=NOW()
+  WHERE user_id IN (SELECT id FROM purged) AND visibility<>'PRIVATE'
+),
+user_media AS (
+  DELETE FROM media
+  WHERE user_id IN (SELECT id FROM purged)
+),
+user_comments AS (
+  DELETE FROM comments
+  WHERE (target_type='PLAN' AND target_id IN (SELECT id FROM plans WHERE user_id IN (SELECT id FROM purged)))
+    OR (target_type='PLAN_ASSIGNMENT' AND target_id IN (
+      SELECT pa.id FROM plan_assignees pa
+      JOIN plans p ON p.id=pa.plan_id
+      WHERE pa.user_id IN (SELECT id FROM purged) OR p.user_id IN (SELECT id FROM purged)
+    ))
+    OR (target_type='WORKOUT_SESSION' AND target_id IN (SELECT id FROM workout_sessions WHERE user_id IN (SELECT id FROM purged)))
+    OR (target_type='PARAM_LOG' AND target_id IN (SELECT id FROM param_logs WHERE user_id IN (SELECT id FROM purged)))
+)
+DELETE FROM users
+WHERE id IN (SELECT id FROM purged)
+RETURNING id
@@ -1,3 +1,8 @@
-INSERT INTO users (first_name, last_name, username, email, password) VALUES (
-  $1, $2, $3, $4, $5
-) RETURNING *
\ No newline at end of file
+WITH u AS (
+  INSERT INTO users (first_name, last_name, username, email, password, phone) VALUES (
+    $1, $2, $3, $4, $5, $6
+  ) RETURNING *
+), r AS (
+  INSERT INTO user_roles (user_id, role) SELECT id, 'athlete' FROM u
+)
+SELECT * FROM u
@@ -0,0 +1,4 @@
+UPDATE users
+SET deleted_at=NULL
+WHERE id=$1 AND deleted_at IS NOT NULL
+RETURNING *
\ No newline at end of file
@@ -0,0 +1,4 @@
+UPDATE users
+SET phone=$2, updated_at=NOW()
+WHERE id=$1
+RETURNING *
\ No newline at end of file
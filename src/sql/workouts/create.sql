@@ -0,0 +1,3 @@
+INSERT INTO workout_sessions (user_id, plan_id, plan_assignee_id, note, plan_revision)
+VALUES ($1, $2, $3, $4, (SELECT MAX(revision) FROM plan_revisions WHERE plan_id=$2))
+RETURNING *
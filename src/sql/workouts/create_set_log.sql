@@ -0,0 +1,21 @@
+WITH prescribed AS (
+  SELECT s.id, s.set_number, s.set_type, s.rep_count, s.duration, s.rep_min, s.rep_max
+  FROM sets s
+  WHERE s.exercise_id=$2 AND (s.id=$3 OR ($3::uuid IS NULL AND s.set_number=$4))
+  LIMIT 1
+)
+INSERT INTO set_logs (
+  session_id, exercise_id, set_id, set_number, rep_count, duration, load, unit, rpe, note,
+  prescribed_set_type, prescribed_rep_count, prescribed_duration, prescribed_rep_min, prescribed_rep_max,
+  exercise_revision
+)
+SELECT
+  $1, $2, p.id,
+  COALESCE($4, p.set_number, (SELECT COUNT(*) + 1 FROM set_logs WHERE session_id=$1 AND exercise_id=$2)),
+  $5, $6, $7, $8, $9, $10,
+  p.set_type, p.rep_count, p.duration, p.rep_min, p.rep_max,
+  (SELECT MAX(revision) FROM exercise_revisions WHERE exercise_id=$2)
+FROM (SELECT 1) AS one
+LEFT JOIN prescribed p ON true
+WHERE $3::uuid IS NULL OR p.id IS NOT NULL
+RETURNING *
@@ -0,0 +1,31 @@
+SELECT
+  ws.id AS session_id,
+  ws.started_at,
+  ws.finished_at,
+  jsonb_agg(json_build_object(
+      'id', sl.id,
+      'session_id', sl.session_id,
+      'exercise_id', sl.exercise_id,
+      'set_id', sl.set_id,
+      'set_number', sl.set_number,
+      'rep_count', sl.rep_count,
+      'duration', sl.duration,
+      'load', sl.load,
+      'unit', sl.unit,
+      'rpe', sl.rpe,
+      'prescribed_rep_count', sl.prescribed_rep_count,
+      'prescribed_duration', sl.prescribed_duration,
+      'prescribed_set_type', sl.prescribed_set_type,
+      'prescribed_rep_min', sl.prescribed_rep_min,
+      'prescribed_rep_max', sl.prescribed_rep_max,
+      'exercise_revision', sl.exercise_revision,
+      'note', sl.note,
+      'created_at', sl.created_at
+    ) ORDER BY sl.set_number, sl.created_at) AS sets,
+  COUNT(*) OVER () AS total_count
+FROM set_logs sl
+JOIN workout_sessions ws ON ws.id=sl.session_id
+WHERE sl.exercise_id=$1 AND ws.user_id=$2
+GROUP BY ws.id
+ORDER BY ws.started_at DESC
+LIMIT $3 OFFSET $4
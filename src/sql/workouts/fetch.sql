@@ -0,0 +1,27 @@
+SELECT ws.*,
+  (SELECT
+    jsonb_agg(json_build_object(
+        'id', sl.id,
+        'session_id', sl.session_id,
+        'exercise_id', sl.exercise_id,
+        'set_id', sl.set_id,
+        'set_number', sl.set_number,
+        'rep_count', sl.rep_count,
+        'duration', sl.duration,
+        'load', sl.load,
+        'unit', sl.unit,
+        'rpe', sl.rpe,
+        'prescribed_rep_count', sl.prescribed_rep_count,
+        'prescribed_duration', sl.prescribed_duration,
+        'prescribed_set_type', sl.prescribed_set_type,
+        'prescribed_rep_min', sl.prescribed_rep_min,
+        'prescribed_rep_max', sl.prescribed_rep_max,
+        'exercise_revision', sl.exercise_revision,
+        'note', sl.note,
+        'created_at', sl.created_at
+      ) ORDER BY sl.created_at)
+      FROM set_logs sl
+      WHERE sl.session_id=ws.id
+  ) AS set_logs
+FROM workout_sessions ws
+WHERE id IN (?)
@@ -0,0 +1 @@
+SELECT * FROM set_logs WHERE id IN (?)
\ No newline at end of file
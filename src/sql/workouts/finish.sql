@@ -0,0 +1,6 @@
+UPDATE workout_sessions SET
+  finished_at=NOW(),
+  note=COALESCE($2, note),
+  updated_at=NOW()
+WHERE id=$1 AND finished_at IS NULL
+RETURNING *
@@ -0,0 +1,5 @@
+SELECT ws.id, COUNT(*) OVER () AS total_count
+FROM workout_sessions ws
+WHERE ws.user_id=$1
+ORDER BY ws.started_at DESC
+LIMIT $2 OFFSET $3
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"bytes"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ICalEvent is a VEVENT, AllDay events span the date of Start and Duration is used otherwise
+type ICalEvent struct {
+	UID         string
+	Summary     string
+	Description string
+	URL         string
+	Start       time.Time
+	Duration    time.Duration
+	AllDay      bool
+	Stamp       time.Time
+}
+
+// ICal writes an iCalendar feed of the events in UTC as described by RFC 5545
+func ICal(name string, events []ICalEvent) []byte {
+	var buf bytes.Buffer
+	line := func(content string) {
+		buf.WriteString(foldICalLine(content))
+		buf.WriteString("\r\n")
+	}
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//Coachwise//Calendar//EN")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:" + escapeICal(name))
+	for _, e := range events {
+		line("BEGIN:VEVENT")
+		line("UID:" + escapeICal(e.UID))
+		line("DTSTAMP:" + e.Stamp.UTC().Format("20060102T150405Z"))
+		if e.AllDay {
+			line("DTSTART;VALUE=DATE:" + e.Start.Format("20060102"))
+			line("DTEND;VALUE=DATE:" + e.Start.AddDate(0, 0, 1).Format("20060102"))
+		} else {
+			line("DTSTART:" + e.Start.UTC().Format("20060102T150405Z"))
+			line("DTEND:" + e.Start.Add(e.Duration).UTC().Format("20060102T150405Z"))
+		}
+		line("SUMMARY:" + escapeICal(e.Summary))
+		if e.Description != "" {
+			line("DESCRIPTION:" + escapeICal(e.Description))
+		}
+		if e.URL != "" {
+			line("URL:" + e.URL)
+		}
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	return buf.Bytes()
+}
+
+func escapeICal(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(text)
+}
+
+// foldICalLine breaks the line into 75 octet pieces without splitting a character, continuations start with a space
+func foldICalLine(content string) string {
+	if len(content) <= 75 {
+		return content
+	}
+	var b strings.Builder
+	limit := 75
+	for len(content) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		b.WriteString(content[:cut])
+		b.WriteString("\r\n ")
+		content = content[cut:]
+		limit = 74
+	}
+	b.WriteString(content)
+	return b.String()
+}
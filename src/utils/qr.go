@@ -0,0 +1,403 @@
+package utils
+
+import (
+	"errors"
+)
+
+// qrVersion holds the error correction layout of a version at level M
+type qrVersion struct {
+	ecPerBlock int
+	blocks     [2][2]int // count and data codewords of the two block groups
+	alignment  []int
+}
+
+// versions 1 to 10 at level M, enough for up to 213 bytes
+var qrVersions = []qrVersion{
+	{10, [2][2]int{{1, 16}}, nil},
+	{16, [2][2]int{{1, 28}}, []int{6, 18}},
+	{26, [2][2]int{{1, 44}}, []int{6, 22}},
+	{18, [2][2]int{{2, 32}}, []int{6, 26}},
+	{24, [2][2]int{{2, 43}}, []int{6, 30}},
+	{16, [2][2]int{{4, 27}}, []int{6, 34}},
+	{18, [2][2]int{{4, 31}}, []int{6, 22, 38}},
+	{22, [2][2]int{{2, 38}, {2, 39}}, []int{6, 24, 42}},
+	{22, [2][2]int{{3, 36}, {2, 37}}, []int{6, 26, 46}},
+	{26, [2][2]int{{4, 43}, {1, 44}}, []int{6, 28, 50}},
+}
+
+type qrCode struct {
+	size     int
+	modules  [][]bool
+	function [][]bool
+}
+
+// QRCode encodes data in byte mode at error correction level M and returns the dark modules by row,
+// the smallest version that fits is used and the quiet zone is left to the caller
+func QRCode(data []byte) ([][]bool, error) {
+	version := 0
+	for v := range qrVersions {
+		countBits := 8
+		if v+1 >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+len(data)*8 <= qrVersions[v].dataCodewords()*8 {
+			version = v + 1
+			break
+		}
+	}
+	if version == 0 {
+		return nil, errors.New("data is too long for a QR code")
+	}
+	info := qrVersions[version-1]
+
+	bits := qrBits{}
+	bits.append(0x4, 4)
+	if version >= 10 {
+		bits.append(len(data), 16)
+	} else {
+		bits.append(len(data), 8)
+	}
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	capacity := info.dataCodewords() * 8
+	terminator := capacity - len(bits)
+	if terminator > 4 {
+		terminator = 4
+	}
+	bits.append(0, terminator)
+	bits.append(0, (8-len(bits)%8)%8)
+	codewords := bits.bytes()
+	for pad := byte(0xEC); len(codewords) < info.dataCodewords(); pad ^= 0xEC ^ 0x11 {
+		codewords = append(codewords, pad)
+	}
+
+	q := newQRCode(version)
+	q.drawFunctionPatterns(version, info)
+	q.drawCodewords(info.interleave(codewords))
+
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		q.applyMask(mask)
+		q.drawFormat(mask)
+		if penalty := q.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		q.applyMask(mask)
+	}
+	q.applyMask(best)
+	q.drawFormat(best)
+	return q.modules, nil
+}
+
+func (v qrVersion) dataCodewords() int {
+	return v.blocks[0][0]*v.blocks[0][1] + v.blocks[1][0]*v.blocks[1][1]
+}
+
+// interleave splits the data into blocks, appends their error correction and takes the codewords a column at a time
+func (v qrVersion) interleave(data []byte) []byte {
+	divisor := rsDivisor(v.ecPerBlock)
+	dataBlocks, ecBlocks := [][]byte{}, [][]byte{}
+	for _, group := range v.blocks {
+		for i := 0; i < group[0]; i++ {
+			block := data[:group[1]]
+			data = data[group[1]:]
+			dataBlocks = append(dataBlocks, block)
+			ecBlocks = append(ecBlocks, rsRemainder(block, divisor))
+		}
+	}
+	out := []byte{}
+	longest := v.blocks[0][1]
+	if v.blocks[1][1] > longest {
+		longest = v.blocks[1][1]
+	}
+	for i := 0; i < longest; i++ {
+		for _, block := range dataBlocks {
+			if i < len(block) {
+				out = append(out, block[i])
+			}
+		}
+	}
+	for i := 0; i < v.ecPerBlock; i++ {
+		for _, block := range ecBlocks {
+			out = append(out, block[i])
+		}
+	}
+	return out
+}
+
+func newQRCode(version int) *qrCode {
+	size := version*4 + 17
+	q := &qrCode{size: size, modules: make([][]bool, size), function: make([][]bool, size)}
+	for i := range q.modules {
+		q.modules[i] = make([]bool, size)
+		q.function[i] = make([]bool, size)
+	}
+	return q
+}
+
+func (q *qrCode) set(row, col int, dark bool) {
+	q.modules[row][col] = dark
+	q.function[row][col] = true
+}
+
+func (q *qrCode) drawFunctionPatterns(version int, info qrVersion) {
+	for i := 0; i < q.size; i++ {
+		q.set(6, i, i%2 == 0)
+		q.set(i, 6, i%2 == 0)
+	}
+	q.drawFinder(3, 3)
+	q.drawFinder(3, q.size-4)
+	q.drawFinder(q.size-4, 3)
+
+	last := len(info.alignment) - 1
+	for i, row := range info.alignment {
+		for j, col := range info.alignment {
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			for dr := -2; dr <= 2; dr++ {
+				for dc := -2; dc <= 2; dc++ {
+					q.set(row+dr, col+dc, max(abs(dr), abs(dc)) != 1)
+				}
+			}
+		}
+	}
+
+	// reserve the format areas until the mask is known
+	q.drawFormat(0)
+
+	if version >= 7 {
+		rem := version
+		for i := 0; i < 12; i++ {
+			rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+		}
+		bits := version<<12 | rem
+		for i := 0; i < 18; i++ {
+			dark := (bits>>i)&1 == 1
+			a, b := q.size-11+i%3, i/3
+			q.set(b, a, dark)
+			q.set(a, b, dark)
+		}
+	}
+}
+
+// drawFinder draws the finder centered at row, col with its light separator
+func (q *qrCode) drawFinder(row, col int) {
+	for dr := -4; dr <= 4; dr++ {
+		for dc := -4; dc <= 4; dc++ {
+			r, c := row+dr, col+dc
+			if r < 0 || r >= q.size || c < 0 || c >= q.size {
+				continue
+			}
+			d := max(abs(dr), abs(dc))
+			q.set(r, c, d != 2 && d != 4)
+		}
+	}
+}
+
+// drawFormat writes both copies of the level M format bits for the mask
+func (q *qrCode) drawFormat(mask int) {
+	data := mask // level M is 00
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return (bits>>i)&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		q.set(i, 8, bit(i))
+	}
+	q.set(7, 8, bit(6))
+	q.set(8, 8, bit(7))
+	q.set(8, 7, bit(8))
+	for i := 9; i < 15; i++ {
+		q.set(8, 14-i, bit(i))
+	}
+	for i := 0; i < 8; i++ {
+		q.set(8, q.size-1-i, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.set(q.size-15+i, 8, bit(i))
+	}
+	q.set(q.size-8, 8, true)
+}
+
+// drawCodewords fills the data modules in the two column zigzag from the bottom right corner
+func (q *qrCode) drawCodewords(data []byte) {
+	i := 0
+	for right := q.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < q.size; vert++ {
+			for j := 0; j < 2; j++ {
+				col := right - j
+				row := vert
+				if (right+1)&2 == 0 {
+					row = q.size - 1 - vert
+				}
+				if !q.function[row][col] && i < len(data)*8 {
+					q.modules[row][col] = (data[i>>3]>>(7-i&7))&1 == 1
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyMask flips the data modules selected by the mask, applying it twice undoes it
+func (q *qrCode) applyMask(mask int) {
+	for row := 0; row < q.size; row++ {
+		for col := 0; col < q.size; col++ {
+			if q.function[row][col] {
+				continue
+			}
+			var flip bool
+			switch mask {
+			case 0:
+				flip = (col+row)%2 == 0
+			case 1:
+				flip = row%2 == 0
+			case 2:
+				flip = col%3 == 0
+			case 3:
+				flip = (col+row)%3 == 0
+			case 4:
+				flip = (col/3+row/2)%2 == 0
+			case 5:
+				flip = col*row%2+col*row%3 == 0
+			case 6:
+				flip = (col*row%2+col*row%3)%2 == 0
+			case 7:
+				flip = ((col+row)%2+col*row%3)%2 == 0
+			}
+			if flip {
+				q.modules[row][col] = !q.modules[row][col]
+			}
+		}
+	}
+}
+
+// penalty scores how hard the symbol is to scan, the mask with the lowest score is kept
+func (q *qrCode) penalty() int {
+	penalty := 0
+	at := func(row, col int, transposed bool) bool {
+		if transposed {
+			return q.modules[col][row]
+		}
+		return q.modules[row][col]
+	}
+	for _, transposed := range []bool{false, true} {
+		for row := 0; row < q.size; row++ {
+			run := 1
+			for col := 1; col <= q.size; col++ {
+				if col < q.size && at(row, col, transposed) == at(row, col-1, transposed) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					penalty += run - 2
+				}
+				run = 1
+			}
+			// dark light dark dark dark light dark with four light modules on either side looks like a finder
+			for col := 0; col+11 <= q.size; col++ {
+				pattern := 0
+				for k := 0; k < 11; k++ {
+					pattern <<= 1
+					if at(row, col+k, transposed) {
+						pattern |= 1
+					}
+				}
+				if pattern == 0x5D0 || pattern == 0x05D {
+					penalty += 40
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for row := 0; row < q.size; row++ {
+		for col := 0; col < q.size; col++ {
+			if q.modules[row][col] {
+				dark++
+			}
+			if row+1 < q.size && col+1 < q.size {
+				c := q.modules[row][col]
+				if c == q.modules[row+1][col] && c == q.modules[row][col+1] && c == q.modules[row+1][col+1] {
+					penalty += 3
+				}
+			}
+		}
+	}
+	total := q.size * q.size
+	penalty += ((abs(dark*20-total*10)+total-1)/total - 1) * 10
+	return penalty
+}
+
+type qrBits []bool
+
+func (b *qrBits) append(value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		*b = append(*b, (value>>i)&1 == 1)
+	}
+}
+
+func (b qrBits) bytes() []byte {
+	out := make([]byte, len(b)/8)
+	for i, bit := range b {
+		if bit {
+			out[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	return out
+}
+
+// rsDivisor is the Reed-Solomon generator polynomial of the degree without its leading term
+func rsDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i := range result {
+			result[i] ^= gfMultiply(divisor[i], factor)
+		}
+	}
+	return result
+}
+
+// gfMultiply multiplies in GF(256) modulo x^8 + x^4 + x^3 + x^2 + 1
+func gfMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+)
+
+type ZipFile struct {
+	Name string
+	Body []byte
+}
+
+func Zip(files ...ZipFile) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	w := zip.NewWriter(buf)
+	for _, f := range files {
+		fw, err := w.Create(f.Name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := fw.Write(f.Body); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,283 @@
+package tests_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func adminGroup() {
+	var adminID string
+	var targetID string
+
+	Describe("Roles", func() {
+		It("should forbid role management without admin role", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/admin/roles", nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(403))
+		})
+
+		It("should forbid exercise changes without exercise permissions", func() {
+			var userID string
+			Expect(db.Get(&userID, "SELECT id FROM users WHERE email = $1", usersData[0]["email"])).To(Succeed())
+			_, err := db.Exec("DELETE FROM user_roles WHERE user_id = $1 AND role = 'athlete'", userID)
+			Expect(err).NotTo(HaveOccurred())
+
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{"name": "Forbidden Press", "description": "No role"})
+			req, _ := http.NewRequest("POST", "/exercises", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(403))
+
+			_, err = db.Exec("INSERT INTO user_roles (user_id, role) VALUES ($1, 'athlete')", userID)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should fail role management without authentication", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/admin/roles", nil)
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(401))
+		})
+
+		It("should list roles as admin", func() {
+			db.Get(&adminID, "SELECT id FROM users WHERE email = $1", usersData[0]["email"])
+			db.Exec("INSERT INTO user_roles (user_id, role) VALUES ($1, 'admin')", adminID)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/admin/roles", nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			Expect(w.Code).To(Equal(200))
+			var body []gin.H
+			json.NewDecoder(w.Body).Decode(&body)
+			names := []interface{}{}
+			for _, r := range body {
+				names = append(names, r["name"])
+			}
+			Expect(names).To(ContainElements("admin", "coach", "athlete"))
+		})
+
+		It("should grant role to user", func() {
+			db.Get(&targetID, "SELECT id FROM users WHERE email = 'another@test.com'")
+			if targetID == "" {
+				Skip("another@test.com is not registered")
+			}
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{"role": "coach"})
+			req, _ := http.NewRequest("POST", fmt.Sprintf("/admin/users/%s/roles", targetID), bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			Expect(w.Code).To(Equal(201))
+			bodyExpect(decodeBody(w.Body), gin.H{
+				"id":              "<ANY>",
+				"user_id":         targetID,
+				"role":            "coach",
+				"organization_id": nil,
+				"granted_by":      adminID,
+				"created_at":      "<ANY>",
+			})
+		})
+
+		It("should fail to grant unknown role", func() {
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{"role": "superhero"})
+			req, _ := http.NewRequest("POST", fmt.Sprintf("/admin/users/%s/roles", adminID), bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(400))
+
+			// The attempt is audited as failed
+			var outcome string
+			db.Get(&outcome, "SELECT outcome FROM admin_audit_logs WHERE action = 'ROLE_GRANT' AND target_id = $1 AND reason = 'superhero'", adminID)
+			Expect(outcome).To(Equal("FAILED"))
+		})
+
+		It("should revoke role from user", func() {
+			if targetID == "" {
+				Skip("another@test.com is not registered")
+			}
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("DELETE", fmt.Sprintf("/admin/users/%s/roles/coach", targetID), nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(200))
+
+			w2 := httptest.NewRecorder()
+			req2, _ := http.NewRequest("DELETE", fmt.Sprintf("/admin/users/%s/roles/coach", targetID), nil)
+			req2.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w2, req2)
+			Expect(w2.Code).To(Equal(400))
+		})
+	})
+
+	Describe("User Moderation", func() {
+		It("should list and search users", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/admin/users?q=test&filter.status=ACTIVE", nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			Expect(w.Code).To(Equal(200))
+			Expect(w.Header().Get("X-Total-Count")).NotTo(BeEmpty())
+			var body []gin.H
+			json.NewDecoder(w.Body).Decode(&body)
+			Expect(len(body)).To(BeNumerically(">=", 1))
+			for _, u := range body {
+				Expect(u["status"]).To(Equal("ACTIVE"))
+			}
+		})
+
+		It("should suspend and reactivate user", func() {
+			if targetID == "" {
+				Skip("another@test.com is not registered")
+			}
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{"reason": "spam"})
+			req, _ := http.NewRequest("POST", fmt.Sprintf("/admin/users/%s/suspend", targetID), bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(200))
+			Expect(decodeBody(w.Body)["status"]).To(Equal("SUSPENDED"))
+
+			// A code can't be used to lift the suspension
+			var code int
+			db.Get(&code, "INSERT INTO otps (user_id, code, perpose) VALUES ($1, 424242, 'AUTH') RETURNING code", targetID)
+			wOTP := httptest.NewRecorder()
+			reqOTPBody, _ := json.Marshal(gin.H{"email": "another@test.com", "code": code})
+			reqOTP, _ := http.NewRequest("POST", "/auth/otp/verify", bytes.NewBuffer(reqOTPBody))
+			reqOTP.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(wOTP, reqOTP)
+			Expect(wOTP.Code).To(Equal(403))
+			var status string
+			db.Get(&status, "SELECT status FROM users WHERE id = $1", targetID)
+			Expect(status).To(Equal("SUSPENDED"))
+
+			w2 := httptest.NewRecorder()
+			req2, _ := http.NewRequest("POST", fmt.Sprintf("/admin/users/%s/verify", targetID), nil)
+			req2.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w2, req2)
+			Expect(w2.Code).To(Equal(200))
+			Expect(decodeBody(w2.Body)["status"]).To(Equal("ACTIVE"))
+		})
+
+		It("should fail on malformed user id", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/admin/users/not-a-uuid/suspend", nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(400))
+		})
+
+		It("should impersonate user with short lived token", func() {
+			if targetID == "" {
+				Skip("another@test.com is not registered")
+			}
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{"reason": "support ticket #42"})
+			req, _ := http.NewRequest("POST", fmt.Sprintf("/admin/users/%s/impersonate", targetID), bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			body := decodeBody(w.Body)
+			Expect(w.Code).To(Equal(200))
+			bodyExpect(body, gin.H{"access_token": "<ANY>", "token_type": "Bearer", "expires_in": float64(900)})
+
+			// Impersonation tokens can not be refreshed
+			w2 := httptest.NewRecorder()
+			reqBody2, _ := json.Marshal(gin.H{"refresh_token": body["access_token"]})
+			req2, _ := http.NewRequest("POST", "/auth/refresh", bytes.NewBuffer(reqBody2))
+			req2.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w2, req2)
+			Expect(w2.Code).To(Equal(400))
+
+			// Changes made while impersonating are recorded against the admin
+			w3 := httptest.NewRecorder()
+			reqBody3, _ := json.Marshal(gin.H{})
+			req3, _ := http.NewRequest("POST", "/exercises", bytes.NewBuffer(reqBody3))
+			req3.Header.Set("Content-Type", "application/json")
+			req3.Header.Set("Authorization", fmt.Sprintf("Bearer %s", body["access_token"]))
+			router.ServeHTTP(w3, req3)
+			var count int
+			db.Get(&count, "SELECT COUNT(*) FROM admin_audit_logs WHERE action = 'IMPERSONATED_REQUEST' AND admin_id = $1 AND target_id = $2 AND reason = 'POST /exercises'", adminID, targetID)
+			Expect(count).To(Equal(1))
+		})
+
+		It("should fail to impersonate without reason", func() {
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{})
+			req, _ := http.NewRequest("POST", fmt.Sprintf("/admin/users/%s/impersonate", adminID), bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(400))
+		})
+	})
+
+	Describe("Content Moderation", func() {
+		It("should unpublish public exercise", func() {
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(exercisesData[0])
+			req, _ := http.NewRequest("POST", "/exercises", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(201))
+			exerciseID := decodeBody(w.Body)["id"].(string)
+
+			w2 := httptest.NewRecorder()
+			reqBody2, _ := json.Marshal(gin.H{"reason": "violates guidelines"})
+			req2, _ := http.NewRequest("POST", fmt.Sprintf("/admin/exercises/%s/unpublish", exerciseID), bytes.NewBuffer(reqBody2))
+			req2.Header.Set("Content-Type", "application/json")
+			req2.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w2, req2)
+			Expect(w2.Code).To(Equal(200))
+
+			w3 := httptest.NewRecorder()
+			req3, _ := http.NewRequest("GET", fmt.Sprintf("/exercises/%s", exerciseID), nil)
+			req3.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w3, req3)
+			Expect(decodeBody(w3.Body)["visibility"]).To(Equal("PRIVATE"))
+		})
+
+		It("should list audit logs", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/admin/audit-logs", nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			Expect(w.Code).To(Equal(200))
+			var body []gin.H
+			json.NewDecoder(w.Body).Decode(&body)
+			actions := []interface{}{}
+			for _, l := range body {
+				actions = append(actions, l["action"])
+			}
+			Expect(actions).To(ContainElement("EXERCISE_UNPUBLISH"))
+		})
+
+		It("should fail to filter audit logs by malformed id", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/admin/audit-logs?filter.target_id=not-a-uuid", nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(400))
+		})
+	})
+}
@@ -275,6 +275,101 @@ func authGroup() {
 		})
 	})
 
+	Describe("Phone Authentication", func() {
+		var phoneToken string
+		latestCode := func() int {
+			otp := struct{ Code int }{}
+			db.Get(&otp, "SELECT o.code FROM otps o JOIN users u ON u.id=o.user_id WHERE u.phone='+4915198765432' ORDER BY o.created_at DESC LIMIT 1")
+			return otp.Code
+		}
+		sendCode := func() {
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{"phone": "+4915198765432"})
+			req, _ := http.NewRequest("POST", "/auth/phone/otp", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(200))
+		}
+
+		It("should register with phone number", func() {
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{
+				"first_name": "Phone",
+				"last_name":  "User",
+				"phone":      "0049 151 987-65432",
+			})
+			req, _ := http.NewRequest("POST", "/auth/phone/register", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(200))
+			bodyExpect(decodeBody(w.Body), gin.H{"message": "success"})
+		})
+
+		It("should fail registration with invalid phone", func() {
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{"phone": "12ab"})
+			req, _ := http.NewRequest("POST", "/auth/phone/register", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(400))
+		})
+
+		It("should verify SMS code and return JWT tokens", func() {
+			otp := struct{ Code int }{}
+			db.Get(&otp, "SELECT o.code FROM otps o JOIN users u ON u.id=o.user_id WHERE u.phone='+4915198765432' ORDER BY o.created_at DESC LIMIT 1")
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{"phone": "+49 151 98765432", "code": otp.Code})
+			req, _ := http.NewRequest("POST", "/auth/phone/otp/verify", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			Expect(w.Code).To(Equal(200))
+			body := decodeBody(w.Body)
+			bodyExpect(body, gin.H{"access_token": "<ANY>", "refresh_token": "<ANY>", "token_type": "Bearer"})
+			phoneToken = body["access_token"].(string)
+		})
+
+		It("should redeem a password reset code by phone", func() {
+			_, err := db.Exec("INSERT INTO otps (user_id, code, perpose) SELECT id, 515151, 'FORGET_PASSWORD' FROM users WHERE phone='+4915198765432'")
+			Expect(err).NotTo(HaveOccurred())
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{"phone": "+4915198765432", "code": 515151})
+			req, _ := http.NewRequest("POST", "/auth/phone/otp/verify", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(200))
+		})
+
+		It("should delete and restore an account registered by phone", func() {
+			sendCode()
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{"code": latestCode()})
+			req, _ := http.NewRequest("DELETE", "/users/me", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", phoneToken))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(202))
+
+			sendCode()
+			w = httptest.NewRecorder()
+			reqBody, _ = json.Marshal(gin.H{"phone": "+49 151 98765432", "code": latestCode()})
+			req, _ = http.NewRequest("POST", "/auth/restore", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(200))
+			bodyExpect(decodeBody(w.Body), gin.H{"access_token": "<ANY>", "refresh_token": "<ANY>", "token_type": "Bearer"})
+		})
+
+		It("should fail SMS code request for unknown phone", func() {
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{"phone": "+15005550000"})
+			req, _ := http.NewRequest("POST", "/auth/phone/otp", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(404))
+		})
+	})
+
 	Describe("Logout", func() {
 		It("should logout successfully with valid token", func() {
 			w := httptest.NewRecorder()
@@ -0,0 +1,159 @@
+package tests_test
+
+import (
+	"bytes"
+	"coachwise/src/config"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func calendarGroup() {
+	var token string
+	var planID string
+
+	feed := func(token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/users/me/calendar.ics?token="+token, nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	It("should give the user a calendar feed url", func() {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/users/me/calendar", nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+		router.ServeHTTP(w, req)
+
+		Expect(w.Code).To(Equal(200))
+		body := decodeBody(w.Body)
+		token = body["token"].(string)
+		Expect(len(token)).To(Equal(64))
+		appURL, _ := config.AppURL()
+		Expect(body["url"]).To(Equal(appURL + "/users/me/calendar.ics?token=" + token))
+		Expect(body["webcal_url"]).To(HavePrefix("webcal://"))
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/users/me/calendar", nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+		router.ServeHTTP(w, req)
+		Expect(decodeBody(w.Body)["token"]).To(Equal(token))
+	})
+
+	It("should list assigned plans and programme sessions as events", func() {
+		w := httptest.NewRecorder()
+		reqBody, _ := json.Marshal(gin.H{
+			"name":        "Push Press",
+			"description": "Dip and drive",
+			"public":      false,
+			"sets": []gin.H{
+				{"name": "Working", "rest_time": 120e9, "rep_count": 5, "load": 60},
+				{"name": "Working", "rest_time": 120e9, "rep_count": 5, "load": 60},
+			},
+		})
+		req, _ := http.NewRequest("POST", "/exercises", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+		router.ServeHTTP(w, req)
+		exerciseID := decodeBody(w.Body)["id"].(string)
+
+		w = httptest.NewRecorder()
+		reqBody, _ = json.Marshal(gin.H{"name": "Shoulder Day", "description": "Strict form, full lockout"})
+		req, _ = http.NewRequest("POST", "/plans", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+		router.ServeHTTP(w, req)
+		planID = decodeBody(w.Body)["id"].(string)
+
+		w = httptest.NewRecorder()
+		reqBody, _ = json.Marshal(gin.H{"exercise_id": exerciseID})
+		req, _ = http.NewRequest("POST", fmt.Sprintf("/plans/%s/exercises", planID), bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+		router.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(201))
+
+		due := time.Now().UTC().AddDate(0, 0, 2).Truncate(24 * time.Hour).Add(18 * time.Hour)
+		_, err := db.Exec(
+			"INSERT INTO plan_assignees (plan_id, user_id, due_at) SELECT $1, id, $2::timestamp FROM users WHERE email=$3",
+			planID, due.Format(time.DateTime), usersData[0]["email"],
+		)
+		Expect(err).To(BeNil())
+
+		w = httptest.NewRecorder()
+		reqBody, _ = json.Marshal(gin.H{
+			"name":  "Shoulder Block",
+			"weeks": 1,
+			"days":  []gin.H{{"week": 1, "day": 1, "plan_id": planID}},
+		})
+		req, _ = http.NewRequest("POST", "/programmes", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+		router.ServeHTTP(w, req)
+		programmeID := decodeBody(w.Body)["id"].(string)
+
+		w = httptest.NewRecorder()
+		reqBody, _ = json.Marshal(gin.H{"start_date": time.Now().Format(time.DateOnly)})
+		req, _ = http.NewRequest("POST", fmt.Sprintf("/programmes/%s/assign", programmeID), bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+		router.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(201))
+
+		w = feed(token)
+		Expect(w.Code).To(Equal(200))
+		Expect(w.Header().Get("Content-Type")).To(HavePrefix("text/calendar"))
+		ics := w.Body.String()
+		Expect(ics).To(HavePrefix("BEGIN:VCALENDAR\r\n"))
+		Expect(ics).To(HaveSuffix("END:VCALENDAR\r\n"))
+		Expect(ics).To(ContainSubstring("SUMMARY:Shoulder Day\r\n"))
+		Expect(ics).To(ContainSubstring("DTSTART:" + due.Format("20060102T150405Z")))
+		Expect(ics).To(ContainSubstring("SUMMARY:Shoulder Block: Shoulder Day\r\n"))
+		Expect(ics).To(ContainSubstring("DTSTART;VALUE=DATE:" + time.Now().Format("20060102")))
+		unfolded := strings.ReplaceAll(ics, "\r\n ", "")
+		Expect(unfolded).To(ContainSubstring(`Strict form\, full lockout`))
+		Expect(unfolded).To(ContainSubstring("A. Push Press: 2 x 5 @ 60kg"))
+		Expect(unfolded).To(ContainSubstring("/plans/" + planID))
+		for _, line := range strings.Split(ics, "\r\n") {
+			Expect(len(line)).To(BeNumerically("<=", 75))
+		}
+	})
+
+	It("should refuse the feed without a valid token", func() {
+		Expect(feed("").Code).To(Equal(401))
+		Expect(feed("not-a-token").Code).To(Equal(401))
+	})
+
+	It("should regenerate the token", func() {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/users/me/calendar/token", nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+		router.ServeHTTP(w, req)
+
+		Expect(w.Code).To(Equal(200))
+		next := decodeBody(w.Body)["token"].(string)
+		Expect(next).NotTo(Equal(token))
+		Expect(feed(token).Code).To(Equal(401))
+		Expect(feed(next).Code).To(Equal(200))
+		token = next
+	})
+
+	It("should refuse the feed of a suspended user", func() {
+		var status string
+		Expect(db.Get(&status, "SELECT status FROM users WHERE email='test@test.com'")).To(BeNil())
+		_, err := db.Exec("UPDATE users SET status='SUSPENDED' WHERE email='test@test.com'")
+		Expect(err).To(BeNil())
+		Expect(feed(token).Code).To(Equal(401))
+
+		_, err = db.Exec("UPDATE users SET status=$1 WHERE email='test@test.com'", status)
+		Expect(err).To(BeNil())
+		Expect(feed(token).Code).To(Equal(200))
+	})
+}
@@ -0,0 +1,209 @@
+package tests_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func coachingGroup() {
+	var athleteToken string
+	var inviteID string
+	var planID string
+	athleteEmail := "athlete@test.com"
+
+	request := func(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		var reqBody []byte
+		if body != nil {
+			reqBody, _ = json.Marshal(body)
+		}
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		router.ServeHTTP(w, req)
+		return w
+	}
+	list := func(w *httptest.ResponseRecorder) []gin.H {
+		var body []gin.H
+		json.NewDecoder(w.Body).Decode(&body)
+		return body
+	}
+	inviteCode := func(id string) int {
+		var code int
+		db.Get(&code, "SELECT code FROM coach_athletes WHERE id=$1", id)
+		return code
+	}
+
+	It("should register the athlete", func() {
+		db.Exec("INSERT INTO user_roles (user_id, role) SELECT id, 'coach' FROM users WHERE email=$1 ON CONFLICT DO NOTHING", usersData[0]["email"])
+
+		w := httptest.NewRecorder()
+		reqBody, _ := json.Marshal(gin.H{"username": "athlete", "email": athleteEmail, "password": "athlete123456"})
+		req, _ := http.NewRequest("POST", "/auth/register", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(200))
+
+		var code int
+		db.Get(&code, "SELECT o.code FROM otps o JOIN users u ON u.id=o.user_id WHERE u.email=$1 ORDER BY o.created_at DESC LIMIT 1", athleteEmail)
+		w = httptest.NewRecorder()
+		reqBody, _ = json.Marshal(gin.H{"email": athleteEmail, "code": code})
+		req, _ = http.NewRequest("POST", "/auth/otp/verify", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(200))
+		athleteToken = decodeBody(w.Body)["access_token"].(string)
+	})
+
+	It("should invite an athlete by email", func() {
+		w := request("POST", "/athletes/invites", authTokens[0], gin.H{"email": " Athlete@Test.com "})
+		Expect(w.Code).To(Equal(201))
+		body := decodeBody(w.Body)
+		inviteID = body["id"].(string)
+		Expect(body["email"]).To(Equal(athleteEmail))
+		Expect(body["status"]).To(Equal("PENDING"))
+		Expect(body["athlete_id"]).To(BeNil())
+		Expect(body["athlete"]).To(BeNil())
+		Expect(body["last_activity_at"]).To(BeNil())
+		Expect(body).NotTo(HaveKey("code"))
+
+		w = request("GET", "/athletes/invites", authTokens[0], nil)
+		Expect(w.Code).To(Equal(200))
+		Expect(list(w)[0]["id"]).To(Equal(inviteID))
+	})
+
+	It("should fail to invite an invalid email or yourself", func() {
+		Expect(request("POST", "/athletes/invites", authTokens[0], gin.H{"email": "not-an-email"}).Code).To(Equal(400))
+		Expect(request("POST", "/athletes/invites", authTokens[0], gin.H{"email": usersData[0]["email"]}).Code).To(Equal(400))
+	})
+
+	It("should forbid athletes to invite and read a roster", func() {
+		Expect(request("POST", "/athletes/invites", athleteToken, gin.H{"email": "someone@test.com"}).Code).To(Equal(403))
+		Expect(request("GET", "/athletes", athleteToken, nil).Code).To(Equal(403))
+	})
+
+	It("should list the invite for the athlete and accept it with the code", func() {
+		w := request("GET", "/coaches/invites", athleteToken, nil)
+		Expect(w.Code).To(Equal(200))
+		invites := list(w)
+		Expect(invites).To(HaveLen(1))
+		Expect(invites[0]["coach"].(map[string]interface{})["username"]).To(Equal(usersData[0]["username"]))
+
+		w = request("POST", fmt.Sprintf("/coaches/invites/%s/accept", inviteID), athleteToken, gin.H{"code": 1})
+		Expect(w.Code).To(Equal(400))
+		Expect(request("POST", fmt.Sprintf("/coaches/invites/%s/accept", inviteID), authTokens[0], gin.H{"code": inviteCode(inviteID)}).Code).To(Equal(403))
+
+		w = request("POST", fmt.Sprintf("/coaches/invites/%s/accept", inviteID), athleteToken, gin.H{"code": inviteCode(inviteID)})
+		Expect(w.Code).To(Equal(200))
+		body := decodeBody(w.Body)
+		Expect(body["status"]).To(Equal("ACCEPTED"))
+		Expect(body["responded_at"]).NotTo(BeNil())
+		Expect(body["athlete"].(map[string]interface{})["username"]).To(Equal("athlete"))
+
+		w = request("GET", "/coaches/invites", athleteToken, nil)
+		Expect(list(w)).To(BeEmpty())
+		Expect(request("POST", "/athletes/invites", authTokens[0], gin.H{"email": athleteEmail}).Code).To(Equal(400))
+	})
+
+	It("should show the roster with assigned plans and last activity", func() {
+		w := request("POST", "/plans", authTokens[0], gin.H{"name": "Athlete Base"})
+		Expect(w.Code).To(Equal(201))
+		planID = decodeBody(w.Body)["id"].(string)
+		_, err := db.Exec("INSERT INTO plan_assignees (plan_id, user_id) SELECT $1, id FROM users WHERE email=$2", planID, athleteEmail)
+		Expect(err).To(BeNil())
+
+		w = request("GET", "/athletes", authTokens[0], nil)
+		Expect(w.Code).To(Equal(200))
+		roster := list(w)
+		Expect(roster).To(HaveLen(1))
+		Expect(roster[0]["last_activity_at"]).To(BeNil())
+
+		Expect(request("POST", "/workouts", athleteToken, gin.H{"note": "first session"}).Code).To(Equal(201))
+
+		w = request("GET", "/athletes", authTokens[0], nil)
+		Expect(w.Header().Get("X-Total-Count")).To(Equal("1"))
+		roster = list(w)
+		Expect(roster[0]["id"]).To(Equal(inviteID))
+		Expect(roster[0]["athlete"].(map[string]interface{})["email"]).To(Equal(athleteEmail))
+		Expect(roster[0]["last_activity_at"]).NotTo(BeNil())
+		plans := roster[0]["assigned_plans"].([]interface{})
+		Expect(plans).To(HaveLen(1))
+		Expect(plans[0].(map[string]interface{})["plan_id"]).To(Equal(planID))
+		Expect(plans[0].(map[string]interface{})["name"]).To(Equal("Athlete Base"))
+
+		w = request("GET", "/coaches", athleteToken, nil)
+		Expect(w.Code).To(Equal(200))
+		coaches := list(w)
+		Expect(coaches).To(HaveLen(1))
+		Expect(coaches[0]["coach"].(map[string]interface{})["username"]).To(Equal(usersData[0]["username"]))
+	})
+
+	It("should keep private plans and exercises out of the athlete's workouts", func() {
+		Expect(request("POST", "/workouts", athleteToken, gin.H{"plan_id": planID}).Code).To(Equal(403))
+
+		var assigneeID string
+		db.Get(&assigneeID, "SELECT a.id FROM plan_assignees a JOIN users u ON u.id=a.user_id WHERE a.plan_id=$1 AND u.email=$2", planID, athleteEmail)
+		w := request("POST", "/workouts", athleteToken, gin.H{"plan_assignee_id": assigneeID})
+		Expect(w.Code).To(Equal(201))
+		sessionID := decodeBody(w.Body)["id"].(string)
+
+		w = request("POST", "/exercises", authTokens[0], gin.H{
+			"name":        "Coach Only",
+			"description": "Private to the coach",
+			"visibility":  "PRIVATE",
+			"sets":        []gin.H{{"name": "Working", "rest_time": 60e9, "rep_count": 5}},
+		})
+		Expect(w.Code).To(Equal(201))
+		exerciseID := decodeBody(w.Body)["id"].(string)
+		w = request("POST", fmt.Sprintf("/workouts/%s/sets", sessionID), athleteToken, gin.H{"exercise_id": exerciseID, "set_number": 1, "rep_count": 5})
+		Expect(w.Code).To(Equal(403))
+	})
+
+	It("should cancel an invite to someone not registered yet", func() {
+		w := request("POST", "/athletes/invites", authTokens[0], gin.H{"email": "newcomer@test.com"})
+		Expect(w.Code).To(Equal(201))
+		body := decodeBody(w.Body)
+		Expect(body["athlete_id"]).To(BeNil())
+		id := body["id"].(string)
+
+		Expect(request("POST", fmt.Sprintf("/coaches/invites/%s/decline", id), athleteToken, nil).Code).To(Equal(403))
+		Expect(request("DELETE", "/athletes/"+id, athleteToken, nil).Code).To(Equal(403))
+
+		w = request("DELETE", "/athletes/"+id, authTokens[0], nil)
+		Expect(w.Code).To(Equal(200))
+		Expect(decodeBody(w.Body)["status"]).To(Equal("REVOKED"))
+		w = request("GET", "/athletes/invites", authTokens[0], nil)
+		for _, invite := range list(w) {
+			Expect(invite["id"]).NotTo(Equal(id))
+		}
+	})
+
+	It("should revoke the athlete", func() {
+		w := request("DELETE", "/athletes/"+inviteID, authTokens[0], nil)
+		Expect(w.Code).To(Equal(200))
+		Expect(decodeBody(w.Body)["status"]).To(Equal("REVOKED"))
+		Expect(request("DELETE", "/athletes/"+inviteID, authTokens[0], nil).Code).To(Equal(400))
+
+		Expect(list(request("GET", "/athletes", authTokens[0], nil))).To(BeEmpty())
+		Expect(list(request("GET", "/coaches", athleteToken, nil))).To(BeEmpty())
+	})
+
+	It("should let the athlete decline a new invite", func() {
+		w := request("POST", "/athletes/invites", authTokens[0], gin.H{"email": athleteEmail})
+		Expect(w.Code).To(Equal(201))
+		id := decodeBody(w.Body)["id"].(string)
+		Expect(id).NotTo(Equal(inviteID))
+
+		w = request("POST", fmt.Sprintf("/coaches/invites/%s/decline", id), athleteToken, nil)
+		Expect(w.Code).To(Equal(200))
+		Expect(decodeBody(w.Body)["status"]).To(Equal("DECLINED"))
+		Expect(request("POST", fmt.Sprintf("/coaches/invites/%s/accept", id), athleteToken, gin.H{"code": inviteCode(id)}).Code).To(Equal(400))
+	})
+}
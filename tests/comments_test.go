@@ -0,0 +1,243 @@
+package tests_test
+
+import (
+	"bytes"
+	"coachwise/src/app/events"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func commentsGroup() {
+	var athleteToken string
+	var outsiderToken string
+	var coachID string
+	var athleteID string
+	var paramLogID string
+	var threadID string
+	var replyID string
+	var planID string
+	var assignmentID string
+	athleteEmail := "athlete@test.com"
+	outsiderEmail := "outsider@test.com"
+	publisher := new(recordingPublisher)
+	var defaultPublisher events.Publisher
+
+	request := func(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		var reqBody []byte
+		if body != nil {
+			reqBody, _ = json.Marshal(body)
+		}
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		router.ServeHTTP(w, req)
+		return w
+	}
+	comment := func(token string, body gin.H) gin.H {
+		w := request("POST", "/comments", token, body)
+		Expect(w.Code).To(Equal(201))
+		return decodeBody(w.Body)
+	}
+	usernames := func(mentions interface{}) []interface{} {
+		result := []interface{}{}
+		for _, m := range mentions.([]interface{}) {
+			result = append(result, m.(map[string]interface{})["username"])
+		}
+		return result
+	}
+	mentioned := func() []events.Event {
+		result := []events.Event{}
+		for _, e := range publisher.events {
+			if e.Name == events.CommentMention {
+				result = append(result, e)
+			}
+		}
+		return result
+	}
+
+	BeforeEach(func() {
+		defaultPublisher = events.Default
+		events.Default = publisher
+	})
+
+	AfterEach(func() {
+		events.Default = defaultPublisher
+	})
+
+	It("should login the athlete and register an outsider", func() {
+		w := httptest.NewRecorder()
+		reqBody, _ := json.Marshal(gin.H{"email": athleteEmail, "password": "athlete123456"})
+		req, _ := http.NewRequest("POST", "/auth/login", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(200))
+		athleteToken = decodeBody(w.Body)["access_token"].(string)
+		Expect(db.Get(&athleteID, "SELECT id FROM users WHERE email=$1", athleteEmail)).To(Succeed())
+		Expect(db.Get(&coachID, "SELECT id FROM users WHERE email=$1", usersData[0]["email"])).To(Succeed())
+		_, err := db.Exec(`INSERT INTO coach_athletes (coach_id, athlete_id, email, code, status)
+			SELECT $1, $2, $3, 123456, 'ACCEPTED'
+			WHERE NOT EXISTS (SELECT 1 FROM coach_athletes WHERE coach_id=$1 AND athlete_id=$2 AND status='ACCEPTED')`,
+			coachID, athleteID, athleteEmail)
+		Expect(err).To(BeNil())
+
+		w = httptest.NewRecorder()
+		reqBody, _ = json.Marshal(gin.H{"username": "outsider", "email": outsiderEmail, "password": "outsider123456"})
+		req, _ = http.NewRequest("POST", "/auth/register", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(200))
+		var code int
+		db.Get(&code, "SELECT o.code FROM otps o JOIN users u ON u.id=o.user_id WHERE u.email=$1 ORDER BY o.created_at DESC LIMIT 1", outsiderEmail)
+		w = httptest.NewRecorder()
+		reqBody, _ = json.Marshal(gin.H{"email": outsiderEmail, "code": code})
+		req, _ = http.NewRequest("POST", "/auth/otp/verify", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(200))
+		outsiderToken = decodeBody(w.Body)["access_token"].(string)
+	})
+
+	It("should comment on a param log and notify mentions", func() {
+		var paramID string
+		Expect(db.Get(&paramID, `INSERT INTO params (name, unit, available_sports)
+			VALUES ('Ankle mobility', 'CM', '{THERAPEUTIC}') RETURNING id`)).To(Succeed())
+		w := request("POST", fmt.Sprintf("/params/%s/logs", paramID), athleteToken, gin.H{"value": 9.5, "side": "LEFT"})
+		Expect(w.Code).To(Equal(201))
+		paramLogID = decodeBody(w.Body)["id"].(string)
+
+		body := comment(athleteToken, gin.H{
+			"target_type": "PARAM_LOG",
+			"target_id":   paramLogID,
+			"body":        "Felt tight this morning @test, is this normal? cc @outsider",
+		})
+		threadID = body["id"].(string)
+		Expect(body["author"].(map[string]interface{})["username"]).To(Equal("athlete"))
+		Expect(body["depth"]).To(Equal(float64(0)))
+		Expect(usernames(body["mentions"])).To(Equal([]interface{}{"test"}))
+		Expect(mentioned()).To(HaveLen(1))
+		Expect(mentioned()[0].UserID.String()).To(Equal(coachID))
+	})
+
+	It("should reply in the thread", func() {
+		body := comment(authTokens[0], gin.H{
+			"target_type": "param_log",
+			"target_id":   paramLogID,
+			"parent_id":   threadID,
+			"body":        "Yes, keep stretching @athlete.",
+		})
+		replyID = body["id"].(string)
+		Expect(body["parent_id"]).To(Equal(threadID))
+		Expect(body["depth"]).To(Equal(float64(1)))
+		Expect(usernames(body["mentions"])).To(Equal([]interface{}{"athlete"}))
+		Expect(mentioned()).To(HaveLen(2))
+
+		comment(athleteToken, gin.H{"target_type": "PARAM_LOG", "target_id": paramLogID, "body": "Logged the right side too"})
+
+		w := request("GET", fmt.Sprintf("/comments?target_type=PARAM_LOG&target_id=%s", paramLogID), authTokens[0], nil)
+		Expect(w.Code).To(Equal(200))
+		Expect(w.Header().Get("X-Total-Count")).To(Equal("3"))
+		var comments []gin.H
+		json.NewDecoder(w.Body).Decode(&comments)
+		Expect(comments[0]["id"]).To(Equal(threadID))
+		Expect(comments[0]["replies"]).To(Equal(float64(1)))
+		Expect(comments[1]["id"]).To(Equal(replyID))
+		Expect(comments[2]["body"]).To(Equal("Logged the right side too"))
+	})
+
+	It("should keep comments to the coach and athlete", func() {
+		path := fmt.Sprintf("/comments?target_type=PARAM_LOG&target_id=%s", paramLogID)
+		Expect(request("GET", path, outsiderToken, nil).Code).To(Equal(403))
+		Expect(request("GET", "/comments/"+threadID, outsiderToken, nil).Code).To(Equal(403))
+		Expect(request("POST", "/comments", outsiderToken, gin.H{
+			"target_type": "PARAM_LOG", "target_id": paramLogID, "body": "Hello",
+		}).Code).To(Equal(403))
+	})
+
+	It("should validate comments", func() {
+		Expect(request("POST", "/comments", athleteToken, gin.H{
+			"target_type": "EXERCISE", "target_id": paramLogID, "body": "Hello",
+		}).Code).To(Equal(400))
+		Expect(request("POST", "/comments", athleteToken, gin.H{
+			"target_type": "PARAM_LOG", "target_id": paramLogID, "body": "  ",
+		}).Code).To(Equal(400))
+		Expect(request("POST", "/comments", athleteToken, gin.H{
+			"target_type": "WORKOUT_SESSION", "target_id": paramLogID, "body": "Hello",
+		}).Code).To(Equal(400))
+		Expect(request("GET", "/comments?target_type=PARAM_LOG", athleteToken, nil).Code).To(Equal(400))
+		Expect(request("GET", "/comments/not-a-uuid", athleteToken, nil).Code).To(Equal(400))
+	})
+
+	It("should keep the edit history", func() {
+		Expect(request("PUT", "/comments/"+threadID, authTokens[0], gin.H{"body": "Edited"}).Code).To(Equal(403))
+
+		w := request("PUT", "/comments/"+threadID, athleteToken, gin.H{"body": "Felt tight this morning, better now"})
+		Expect(w.Code).To(Equal(200))
+		body := decodeBody(w.Body)
+		Expect(body["edited_at"]).NotTo(BeNil())
+		Expect(body["revisions"]).To(Equal(float64(1)))
+		Expect(body["mentions"]).To(BeEmpty())
+
+		w = request("GET", fmt.Sprintf("/comments/%s/revisions", threadID), authTokens[0], nil)
+		Expect(w.Code).To(Equal(200))
+		Expect(w.Header().Get("X-Total-Count")).To(Equal("1"))
+		var revisions []gin.H
+		json.NewDecoder(w.Body).Decode(&revisions)
+		Expect(revisions[0]["body"]).To(Equal("Felt tight this morning @test, is this normal? cc @outsider"))
+	})
+
+	It("should soft delete comments", func() {
+		Expect(request("DELETE", "/comments/"+threadID, authTokens[0], nil).Code).To(Equal(403))
+		w := request("DELETE", "/comments/"+threadID, athleteToken, nil)
+		Expect(w.Code).To(Equal(200))
+		body := decodeBody(w.Body)
+		Expect(body["body"]).To(BeNil())
+		Expect(body["deleted_at"]).NotTo(BeNil())
+
+		w = request("GET", fmt.Sprintf("/comments?target_type=PARAM_LOG&target_id=%s", paramLogID), athleteToken, nil)
+		Expect(w.Header().Get("X-Total-Count")).To(Equal("3"))
+		Expect(request("PUT", "/comments/"+threadID, athleteToken, gin.H{"body": "Back"}).Code).To(Equal(400))
+		Expect(request("GET", fmt.Sprintf("/comments/%s/revisions", threadID), athleteToken, nil).Code).To(Equal(400))
+		Expect(request("POST", "/comments", authTokens[0], gin.H{
+			"target_type": "PARAM_LOG", "target_id": paramLogID, "parent_id": threadID, "body": "Noted",
+		}).Code).To(Equal(400))
+	})
+
+	It("should keep plan comments to its editors and assignment comments to the pair", func() {
+		w := request("POST", "/plans", authTokens[0], gin.H{"name": "Comments Block"})
+		Expect(w.Code).To(Equal(201))
+		planID = decodeBody(w.Body)["id"].(string)
+		comment(authTokens[0], gin.H{"target_type": "PLAN", "target_id": planID, "body": "Deload on week 4"})
+		Expect(request("POST", "/comments", athleteToken, gin.H{
+			"target_type": "PLAN", "target_id": planID, "body": "Hello",
+		}).Code).To(Equal(403))
+
+		Expect(db.Get(&assignmentID, "INSERT INTO plan_assignees (plan_id, user_id) VALUES ($1, $2) RETURNING id", planID, athleteID)).To(Succeed())
+		body := comment(athleteToken, gin.H{"target_type": "PLAN_ASSIGNMENT", "target_id": assignmentID, "body": "@test can I move day 2?"})
+		Expect(usernames(body["mentions"])).To(Equal([]interface{}{"test"}))
+		Expect(request("GET", fmt.Sprintf("/comments?target_type=PLAN_ASSIGNMENT&target_id=%s", assignmentID), authTokens[0], nil).Code).To(Equal(200))
+		Expect(request("GET", fmt.Sprintf("/comments?target_type=PLAN_ASSIGNMENT&target_id=%s", assignmentID), outsiderToken, nil).Code).To(Equal(403))
+
+		// Other coaches of the athlete stay out of the assignment
+		_, err := db.Exec(`INSERT INTO coach_athletes (coach_id, athlete_id, email, code, status)
+			SELECT id, $1, $2, 123456, 'ACCEPTED' FROM users WHERE email=$3`, athleteID, athleteEmail, outsiderEmail)
+		Expect(err).To(BeNil())
+		Expect(request("GET", fmt.Sprintf("/comments?target_type=PLAN_ASSIGNMENT&target_id=%s", assignmentID), outsiderToken, nil).Code).To(Equal(403))
+		_, err = db.Exec("DELETE FROM coach_athletes WHERE athlete_id=$1 AND email=$2 AND coach_id=(SELECT id FROM users WHERE email=$3)", athleteID, athleteEmail, outsiderEmail)
+		Expect(err).To(BeNil())
+	})
+
+	It("should delete the comments with their plan", func() {
+		Expect(request("DELETE", "/plans/"+planID, authTokens[0], nil).Code).To(Equal(200))
+		var count int
+		Expect(db.Get(&count, "SELECT COUNT(*) FROM comments WHERE target_id IN ($1, $2)", planID, assignmentID)).To(Succeed())
+		Expect(count).To(Equal(0))
+		Expect(request("GET", fmt.Sprintf("/comments?target_type=PLAN&target_id=%s", planID), authTokens[0], nil).Code).To(Equal(400))
+	})
+}
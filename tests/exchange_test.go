@@ -0,0 +1,526 @@
+package tests_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"coachwise/src/utils"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func exchangeGroup() {
+	var planID string
+	var exported []byte
+
+	upload := func(url, filename string, data []byte, fields map[string]string) *httptest.ResponseRecorder {
+		body := new(bytes.Buffer)
+		mw := multipart.NewWriter(body)
+		fw, _ := mw.CreateFormFile("file", filename)
+		fw.Write(data)
+		for k, v := range fields {
+			mw.WriteField(k, v)
+		}
+		mw.Close()
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", url, body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	programme := strings.Join([]string{
+		"Exercise,Sets,Reps,Rest,Load,Group",
+		"Back Squatt,3,5,2m,100kg,",
+		"back squatt,1,3,,110 kg,",
+		"Romanian Deadlift,3,8-12,90,,A",
+		"Hip Thrust,3,AMRAP,1:30,,A",
+	}, "\n")
+
+	It("should report unmatched rows on a dry run", func() {
+		csv := programme + "\nZzyzx Qwerty Flip,2,10,,,"
+		w := upload("/plans/import?dry_run=true", "block.csv", []byte(csv), nil)
+		Expect(w.Code).To(Equal(200))
+		body := decodeBody(w.Body)
+		report := body["report"].(map[string]interface{})
+		Expect(report["groups"]).To(Equal(float64(3)))
+		matched := report["matched"].([]interface{})
+		Expect(len(matched)).To(Equal(3))
+		first := matched[0].(map[string]interface{})
+		Expect(first["line"]).To(Equal(float64(2)))
+		Expect(first["sets"]).To(Equal(float64(4)))
+		Expect(first["forked"]).To(Equal(true))
+		Expect(first["exercise"].(map[string]interface{})["name"]).To(Equal("Back Squat"))
+		unmatched := report["unmatched"].([]interface{})
+		Expect(len(unmatched)).To(Equal(1))
+		Expect(unmatched[0].(map[string]interface{})["line"]).To(Equal(float64(6)))
+	})
+
+	It("should refuse to import a file with unmatched rows", func() {
+		csv := programme + "\nZzyzx Qwerty Flip,2,10,,,"
+		w := upload("/plans/import", "block.csv", []byte(csv), nil)
+		Expect(w.Code).To(Equal(400))
+		body := decodeBody(w.Body)
+		Expect(body["report"]).NotTo(BeNil())
+	})
+
+	It("should point at the line of invalid values", func() {
+		w := upload("/plans/import", "block.csv", []byte("Exercise,Reps\nBack Squat,lots"), nil)
+		Expect(w.Code).To(Equal(400))
+		body := decodeBody(w.Body)
+		Expect(body["error"]).To(ContainSubstring("line 2"))
+
+		w = upload("/plans/import", "block.txt", []byte(programme), nil)
+		Expect(w.Code).To(Equal(400))
+
+		w = upload("/plans/import", "block.csv", []byte("Exercise,Sets,Reps\nBack Squat,3,5\nDeadlift,100000000,5"), nil)
+		Expect(w.Code).To(Equal(400))
+		Expect(decodeBody(w.Body)["error"]).To(Equal("line 3: sets can not be more than 20"))
+
+		w = upload("/plans/import", "block.csv", []byte("Exercise,Sets,Reps,Group,Rounds\nBurpees,1,10,A,500\nRow,1,10,A,"), nil)
+		Expect(w.Code).To(Equal(400))
+		Expect(decodeBody(w.Body)["error"]).To(ContainSubstring("line 2"))
+	})
+
+	It("should import a CSV programme as a plan", func() {
+		w := upload("/plans/import", "block.csv", []byte(programme), map[string]string{"name": "Imported Block"})
+		Expect(w.Code).To(Equal(201))
+		body := decodeBody(w.Body)
+		plan := body["plan"].(map[string]interface{})
+		Expect(plan["name"]).To(Equal("Imported Block"))
+		groups := plan["groups"].([]interface{})
+		Expect(len(groups)).To(Equal(2))
+		Expect(groups[1].(map[string]interface{})["group_type"]).To(Equal("SUPERSET"))
+
+		squat := groups[0].(map[string]interface{})["exercises"].([]interface{})[0].(map[string]interface{})["exercise"].(map[string]interface{})
+		Expect(squat["name"]).To(Equal("Back Squat"))
+		sets := squat["sets"].([]interface{})
+		Expect(len(sets)).To(Equal(4))
+		Expect(sets[3].(map[string]interface{})["load"]).To(Equal(float64(110)))
+		planID = plan["id"].(string)
+	})
+
+	It("should export the plan as CSV", func() {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/plans/%s/export?format=csv", planID), nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+		router.ServeHTTP(w, req)
+
+		Expect(w.Code).To(Equal(200))
+		Expect(w.Header().Get("Content-Disposition")).To(ContainSubstring("imported-block.csv"))
+		lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+		Expect(lines[0]).To(Equal("group,group_type,rounds,exercise,sets,reps,rest,load"))
+		Expect(lines[1]).To(Equal("1,SINGLE,1,Back Squat,3,5,2m,100kg"))
+		Expect(lines[2]).To(Equal("1,SINGLE,1,Back Squat,1,3,,110kg"))
+		Expect(lines[3]).To(Equal("2,SUPERSET,1,Romanian Deadlift,3,8-12,90s,"))
+	})
+
+	It("should export the plan as JSON, XLSX and PDF", func() {
+		for format, prefix := range map[string]string{"json": "{", "xlsx": "PK", "pdf": "%PDF"} {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", fmt.Sprintf("/plans/%s/export?format=%s", planID, format), nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			Expect(w.Code).To(Equal(200))
+			data, _ := io.ReadAll(w.Body)
+			Expect(string(data)).To(HavePrefix(prefix))
+			if format == "xlsx" {
+				exported = data
+			}
+			if format == "pdf" {
+				// every xref entry points at the object it numbers
+				text := string(data)
+				start := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindStringSubmatch(text)
+				Expect(start).NotTo(BeNil())
+				xref, _ := strconv.Atoi(start[1])
+				Expect(text[xref:]).To(HavePrefix("xref\n0 "))
+				entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(text[xref:], -1)
+				Expect(len(entries)).To(BeNumerically(">", 0))
+				for i, entry := range entries {
+					offset, _ := strconv.Atoi(entry[1])
+					Expect(text[offset:]).To(HavePrefix(fmt.Sprintf("%d 0 obj", i+1)))
+				}
+			}
+			if format == "json" {
+				var plan gin.H
+				Expect(json.Unmarshal(data, &plan)).To(BeNil())
+				Expect(plan["id"]).To(Equal(planID))
+			}
+		}
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/plans/%s/export?format=docx", planID), nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+		router.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(400))
+	})
+
+	It("should import an exported XLSX back", func() {
+		w := upload("/plans/import", "Imported Block.xlsx", exported, nil)
+		Expect(w.Code).To(Equal(201))
+		body := decodeBody(w.Body)
+		plan := body["plan"].(map[string]interface{})
+		Expect(plan["name"]).To(Equal("Imported Block"))
+		Expect(len(plan["groups"].([]interface{}))).To(Equal(2))
+		report := body["report"].(map[string]interface{})
+		Expect(len(report["unmatched"].([]interface{}))).To(Equal(0))
+	})
+
+	It("should encode QR codes that decode back", func() {
+		// lengths that land on versions 1, 2, 7, 9 and 10
+		for _, n := range []int{10, 20, 120, 180, 213} {
+			payload := strings.Repeat("https://coachwise.test/plans/", 8)[:n]
+			modules, err := utils.QRCode([]byte(payload))
+			Expect(err).To(BeNil())
+			decoded, err := decodeQR(modules)
+			Expect(err).To(BeNil())
+			Expect(string(decoded)).To(Equal(payload))
+		}
+		_, err := utils.QRCode(make([]byte, 214))
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("should print a workout sheet with the coach notes", func() {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/plans/%s", planID), nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+		router.ServeHTTP(w, req)
+		plan := decodeBody(w.Body)
+		groups := plan["groups"].([]interface{})
+		exerciseID := groups[0].(map[string]interface{})["exercises"].([]interface{})[0].(map[string]interface{})["exercise_id"]
+
+		reqBody, _ := json.Marshal(gin.H{"exercise_id": exerciseID, "notes": "Pause two seconds at the bottom"})
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("POST", fmt.Sprintf("/plans/%s/exercises", planID), bytes.NewBuffer(reqBody))
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+		router.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(201))
+		plan = decodeBody(w.Body)
+		groups = plan["groups"].([]interface{})
+		added := groups[len(groups)-1].(map[string]interface{})["exercises"].([]interface{})[0].(map[string]interface{})
+		Expect(added["notes"]).To(Equal("Pause two seconds at the bottom"))
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", fmt.Sprintf("/plans/%s/pdf", planID), nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+		router.ServeHTTP(w, req)
+
+		Expect(w.Code).To(Equal(200))
+		Expect(w.Header().Get("Content-Type")).To(Equal("application/pdf"))
+		sheet := w.Body.String()
+		Expect(sheet).To(HavePrefix("%PDF"))
+		Expect(sheet).To(ContainSubstring("(Back Squat)"))
+		Expect(sheet).To(ContainSubstring("(Pause two seconds at the bottom)"))
+		Expect(sheet).To(ContainSubstring("(Load)"))
+	})
+
+	It("should quote formula cells and read them back", func() {
+		reqBody, _ := json.Marshal(gin.H{
+			"name": "=Cable Row",
+			"sets": []gin.H{{"name": "Set 1", "rest_time": 60e9, "rep_count": 12}},
+		})
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/exercises", bytes.NewBuffer(reqBody))
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+		router.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(201))
+		exerciseID := decodeBody(w.Body)["id"]
+
+		reqBody, _ = json.Marshal(gin.H{"exercise_id": exerciseID})
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("POST", fmt.Sprintf("/plans/%s/exercises", planID), bytes.NewBuffer(reqBody))
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+		router.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(201))
+		original := decodeBody(w.Body)
+
+		export := func(format string) []byte {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", fmt.Sprintf("/plans/%s/export?format=%s", planID, format), nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(200))
+			return w.Body.Bytes()
+		}
+
+		csv := export("csv")
+		Expect(string(csv)).To(ContainSubstring(",'=Cable Row,1,12,1m,"))
+
+		xlsx := export("xlsx")
+		archive, err := zip.NewReader(bytes.NewReader(xlsx), int64(len(xlsx)))
+		Expect(err).To(BeNil())
+		sheet, err := archive.Open("xl/worksheets/sheet1.xml")
+		Expect(err).To(BeNil())
+		content, _ := io.ReadAll(sheet)
+		Expect(string(content)).To(ContainSubstring(">&#39;=Cable Row</t>"))
+
+		// the imported plan has the same groups, exercises and sets as the exported one
+		for filename, data := range map[string][]byte{"Round Trip.csv": csv, "Round Trip.xlsx": xlsx} {
+			w = upload("/plans/import", filename, data, nil)
+			Expect(w.Code).To(Equal(201))
+			imported := decodeBody(w.Body)["plan"].(map[string]interface{})
+			Expect(imported["name"]).To(Equal("Round Trip"))
+
+			groups := original["groups"].([]interface{})
+			importedGroups := imported["groups"].([]interface{})
+			Expect(len(importedGroups)).To(Equal(len(groups)))
+			for i := range groups {
+				group := groups[i].(map[string]interface{})
+				importedGroup := importedGroups[i].(map[string]interface{})
+				Expect(importedGroup["group_type"]).To(Equal(group["group_type"]))
+				exercises := group["exercises"].([]interface{})
+				importedExercises := importedGroup["exercises"].([]interface{})
+				Expect(len(importedExercises)).To(Equal(len(exercises)))
+				for j := range exercises {
+					exercise := exercises[j].(map[string]interface{})["exercise"].(map[string]interface{})
+					importedExercise := importedExercises[j].(map[string]interface{})["exercise"].(map[string]interface{})
+					Expect(importedExercise["name"]).To(Equal(exercise["name"]))
+					sets := exercise["sets"].([]interface{})
+					importedSets := importedExercise["sets"].([]interface{})
+					Expect(len(importedSets)).To(Equal(len(sets)))
+					for k := range sets {
+						set := sets[k].(map[string]interface{})
+						importedSet := importedSets[k].(map[string]interface{})
+						Expect(importedSet["rep_count"]).To(Equal(set["rep_count"]))
+						Expect(importedSet["load"]).To(Equal(set["load"]))
+						Expect(importedSet["rest_time"]).To(Equal(set["rest_time"]))
+					}
+				}
+			}
+		}
+	})
+}
+
+// qrBlocks are the count and data codewords of the block groups and the ec codewords per block at level M
+var qrBlocks = [][5]int{
+	{1, 16, 0, 0, 10}, {1, 28, 0, 0, 16}, {1, 44, 0, 0, 26}, {2, 32, 0, 0, 18}, {2, 43, 0, 0, 24},
+	{4, 27, 0, 0, 16}, {4, 31, 0, 0, 18}, {2, 38, 2, 39, 22}, {3, 36, 2, 37, 22}, {4, 43, 1, 44, 26},
+}
+
+// decodeQR reads a byte mode symbol at level M as laid out in ISO/IEC 18004 and checks the error correction of every block
+func decodeQR(modules [][]bool) ([]byte, error) {
+	size := len(modules)
+	version := (size - 17) / 4
+	if version < 1 || version > len(qrBlocks) || size != version*4+17 {
+		return nil, fmt.Errorf("unexpected size %d", size)
+	}
+
+	// both copies of the format bits, most significant bit first
+	first, second := 0, 0
+	firstAt := [][2]int{{8, 0}, {8, 1}, {8, 2}, {8, 3}, {8, 4}, {8, 5}, {8, 7}, {8, 8}, {7, 8}, {5, 8}, {4, 8}, {3, 8}, {2, 8}, {1, 8}, {0, 8}}
+	for i, at := range firstAt {
+		if modules[at[0]][at[1]] {
+			first |= 1 << (14 - i)
+		}
+		var bit bool
+		if i < 7 {
+			bit = modules[size-1-i][8]
+		} else {
+			bit = modules[8][size-15+i]
+		}
+		if bit {
+			second |= 1 << (14 - i)
+		}
+	}
+	if first != second {
+		return nil, fmt.Errorf("format copies differ")
+	}
+	mask := -1
+	for data := 0; data < 32; data++ {
+		bch := data << 10
+		for i := 14; i >= 10; i-- {
+			if bch&(1<<i) != 0 {
+				bch ^= 0x537 << (i - 10)
+			}
+		}
+		if (data<<10|bch)^0x5412 == first {
+			if data>>3 != 0 {
+				return nil, fmt.Errorf("unexpected level %d", data>>3)
+			}
+			mask = data & 7
+		}
+	}
+	if mask < 0 {
+		return nil, fmt.Errorf("unreadable format bits")
+	}
+
+	reserved := make([][]bool, size)
+	for r := range reserved {
+		reserved[r] = make([]bool, size)
+		for c := range reserved[r] {
+			reserved[r][c] = r == 6 || c == 6 || (r < 9 && c < 9) || (r < 9 && c >= size-8) || (r >= size-8 && c < 9)
+			if version >= 7 && ((r < 6 && c >= size-11 && c < size-8) || (c < 6 && r >= size-11 && r < size-8)) {
+				reserved[r][c] = true
+			}
+		}
+	}
+	if version >= 2 {
+		count := version/7 + 2
+		step := (version*4 + count*2 + 1) / (count*2 - 2) * 2
+		positions := make([]int, count)
+		positions[0] = 6
+		for i, pos := count-1, size-7; i >= 1; i, pos = i-1, pos-step {
+			positions[i] = pos
+		}
+		last := size - 7
+		for _, r := range positions {
+			for _, c := range positions {
+				// the corners already hold the finders
+				if (r == 6 && c == 6) || (r == 6 && c == last) || (r == last && c == 6) {
+					continue
+				}
+				for dr := -2; dr <= 2; dr++ {
+					for dc := -2; dc <= 2; dc++ {
+						reserved[r+dr][c+dc] = true
+					}
+				}
+			}
+		}
+	}
+
+	masked := func(r, c int) bool {
+		switch mask {
+		case 0:
+			return (r+c)%2 == 0
+		case 1:
+			return r%2 == 0
+		case 2:
+			return c%3 == 0
+		case 3:
+			return (r+c)%3 == 0
+		case 4:
+			return (r/2+c/3)%2 == 0
+		case 5:
+			return (r*c)%2+(r*c)%3 == 0
+		case 6:
+			return ((r*c)%2+(r*c)%3)%2 == 0
+		default:
+			return ((r+c)%2+(r*c)%3)%2 == 0
+		}
+	}
+	bits := []bool{}
+	upward := true
+	for right := size - 1; right > 0; right -= 2 {
+		if right == 6 {
+			right--
+		}
+		for i := 0; i < size; i++ {
+			r := i
+			if upward {
+				r = size - 1 - i
+			}
+			for _, c := range []int{right, right - 1} {
+				if !reserved[r][c] {
+					bits = append(bits, modules[r][c] != masked(r, c))
+				}
+			}
+		}
+		upward = !upward
+	}
+
+	layout := qrBlocks[version-1]
+	lengths := []int{}
+	for g := 0; g < 2; g++ {
+		for i := 0; i < layout[g*2]; i++ {
+			lengths = append(lengths, layout[g*2+1])
+		}
+	}
+	ec := layout[4]
+	codeword := func(i int) byte {
+		b := byte(0)
+		for _, bit := range bits[i*8 : i*8+8] {
+			b <<= 1
+			if bit {
+				b |= 1
+			}
+		}
+		return b
+	}
+	blocks := make([][]byte, len(lengths))
+	next := 0
+	for i := 0; i < max(layout[1], layout[3]); i++ {
+		for b, n := range lengths {
+			if i < n {
+				blocks[b] = append(blocks[b], codeword(next))
+				next++
+			}
+		}
+	}
+	for i := 0; i < ec; i++ {
+		for b := range blocks {
+			blocks[b] = append(blocks[b], codeword(next))
+			next++
+		}
+	}
+
+	// every block evaluates to zero at the roots of the generator, a^0 to a^(ec-1)
+	multiply := func(x, y byte) byte {
+		z := byte(0)
+		for ; y > 0; y >>= 1 {
+			if y&1 == 1 {
+				z ^= x
+			}
+			carry := x&0x80 != 0
+			x <<= 1
+			if carry {
+				x ^= 0x1D
+			}
+		}
+		return z
+	}
+	data := []byte{}
+	for b, block := range blocks {
+		root := byte(1)
+		for j := 0; j < ec; j++ {
+			syndrome := byte(0)
+			for _, v := range block {
+				syndrome = multiply(syndrome, root) ^ v
+			}
+			if syndrome != 0 {
+				return nil, fmt.Errorf("block %d fails error correction", b)
+			}
+			root = multiply(root, 2)
+		}
+		data = append(data, block[:lengths[b]]...)
+	}
+
+	read := func(pos, n int) int {
+		v := 0
+		for i := 0; i < n; i++ {
+			v <<= 1
+			if data[(pos+i)/8]>>(7-(pos+i)%8)&1 == 1 {
+				v |= 1
+			}
+		}
+		return v
+	}
+	if mode := read(0, 4); mode != 0x4 {
+		return nil, fmt.Errorf("unexpected mode %d", mode)
+	}
+	countBits := 8
+	if version >= 10 {
+		countBits = 16
+	}
+	length := read(4, countBits)
+	if 4+countBits+length*8 > len(data)*8 {
+		return nil, fmt.Errorf("length %d is past the data", length)
+	}
+	out := make([]byte, length)
+	for i := range out {
+		out[i] = byte(read(4+countBits+i*8, 8))
+	}
+	return out, nil
+}
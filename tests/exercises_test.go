@@ -54,6 +54,7 @@ func exerciseGroup() {
 			body := decodeBody(w.Body)
 			Expect(w.Code).To(Equal(201))
 			Expect(body["public"]).To(Equal(true))
+			Expect(body["visibility"]).To(Equal("PUBLIC"))
 			publicExerciseId = body["id"].(string)
 		})
 
@@ -118,6 +119,245 @@ func exerciseGroup() {
 		})
 	})
 
+	Describe("Set Intensity", func() {
+		It("should create strength and climbing sets with load and intensity", func() {
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{
+				"name":        "Single Arm Hang",
+				"description": "Hangboard with added weight",
+				"public":      false,
+				"sets": []gin.H{
+					{"name": "Left", "rest_time": 180e9, "duration": 10e9, "load": 12.5, "rpe": 8, "side": "LEFT", "grade": "7a"},
+					{"name": "Right", "rest_time": 180e9, "duration": 10e9, "load": 12.5, "rpe": 8, "side": "RIGHT", "grade": "7a"},
+					{"name": "Squat", "rest_time": 120e9, "rep_count": 5, "percent_1rm": 80, "rir": 2, "tempo": "3-1-X-0"},
+					{"name": "Row", "rest_time": 60e9, "distance": 500},
+				},
+			})
+			req, _ := http.NewRequest("POST", "/exercises", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			body := decodeBody(w.Body)
+			Expect(w.Code).To(Equal(201))
+			sets := body["sets"].([]interface{})
+			Expect(len(sets)).To(Equal(4))
+			for _, s := range sets {
+				set := s.(map[string]interface{})
+				switch set["set_number"] {
+				case float64(1):
+					Expect(set["load"]).To(Equal(12.5))
+					Expect(set["load_unit"]).To(Equal("KG"))
+					Expect(set["side"]).To(Equal("LEFT"))
+				case float64(4):
+					Expect(set["distance_unit"]).To(Equal("M"))
+				}
+			}
+		})
+
+		It("should fail to create set with both load and percent_1rm", func() {
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{
+				"name":        "Invalid Intensity",
+				"description": "Invalid sets",
+				"public":      false,
+				"sets": []gin.H{
+					{"name": "Invalid", "rest_time": 30e9, "rep_count": 5, "load": 100, "percent_1rm": 80},
+				},
+			})
+			req, _ := http.NewRequest("POST", "/exercises", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(400))
+		})
+
+		It("should fail to create set with invalid tempo or side", func() {
+			for _, set := range []gin.H{
+				{"name": "Invalid", "rest_time": 30e9, "rep_count": 5, "tempo": "slow"},
+				{"name": "Invalid", "rest_time": 30e9, "rep_count": 5, "side": "UP"},
+				{"name": "Invalid", "rest_time": 30e9, "rep_count": 5, "rpe": 8, "rir": 2},
+			} {
+				w := httptest.NewRecorder()
+				reqBody, _ := json.Marshal(gin.H{
+					"name":        "Invalid Set",
+					"description": "Invalid sets",
+					"public":      false,
+					"sets":        []gin.H{set},
+				})
+				req, _ := http.NewRequest("POST", "/exercises", bytes.NewBuffer(reqBody))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+				router.ServeHTTP(w, req)
+				Expect(w.Code).To(Equal(400))
+			}
+		})
+	})
+
+	Describe("Set Types", func() {
+		It("should create range, AMRAP, EMOM, interval and drop sets", func() {
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{
+				"name":        "Conditioning",
+				"description": "Mixed set types",
+				"public":      false,
+				"sets": []gin.H{
+					{"name": "Bench", "set_type": "RANGE", "rest_time": 90e9, "rep_min": 8, "rep_max": 12, "load": 60},
+					{"name": "Push Ups", "set_type": "AMRAP", "rest_time": 60e9, "duration": 60e9},
+					{"name": "Burpees", "set_type": "EMOM", "rest_time": 0, "rep_count": 10, "rounds": 10},
+					{"name": "Bike", "set_type": "INTERVAL", "rest_time": 0, "duration": 20e9, "interval": 30e9, "rounds": 8},
+					{"name": "Curl", "set_type": "DROP", "rest_time": 60e9, "rep_count": 10, "load": 20, "drops": 2, "drop_percent": 20},
+				},
+			})
+			req, _ := http.NewRequest("POST", "/exercises", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			body := decodeBody(w.Body)
+			Expect(w.Code).To(Equal(201))
+			sets := body["sets"].([]interface{})
+			Expect(len(sets)).To(Equal(5))
+			for _, s := range sets {
+				set := s.(map[string]interface{})
+				switch set["set_number"] {
+				case float64(1):
+					Expect(set["set_type"]).To(Equal("RANGE"))
+					Expect(set["rep_min"]).To(Equal(float64(8)))
+					Expect(set["rep_max"]).To(Equal(float64(12)))
+				case float64(3):
+					Expect(set["set_type"]).To(Equal("EMOM"))
+					Expect(set["interval"]).To(Equal(float64(60e9)))
+				}
+			}
+		})
+
+		It("should fail to create sets with invalid type fields", func() {
+			for _, set := range []gin.H{
+				{"name": "Invalid", "set_type": "RANGE", "rest_time": 30e9, "rep_min": 12, "rep_max": 8},
+				{"name": "Invalid", "set_type": "RANGE", "rest_time": 30e9, "rep_count": 5},
+				{"name": "Invalid", "set_type": "AMRAP", "rest_time": 30e9, "rep_count": 5},
+				{"name": "Invalid", "set_type": "EMOM", "rest_time": 30e9, "rep_count": 5},
+				{"name": "Invalid", "set_type": "DROP", "rest_time": 30e9, "rep_count": 5, "drops": 2},
+				{"name": "Invalid", "set_type": "PYRAMID", "rest_time": 30e9, "rep_count": 5},
+				{"name": "Invalid", "rest_time": 30e9, "rep_count": 5, "rep_min": 3},
+			} {
+				w := httptest.NewRecorder()
+				reqBody, _ := json.Marshal(gin.H{
+					"name":        "Invalid Set",
+					"description": "Invalid sets",
+					"public":      false,
+					"sets":        []gin.H{set},
+				})
+				req, _ := http.NewRequest("POST", "/exercises", bytes.NewBuffer(reqBody))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+				router.ServeHTTP(w, req)
+				Expect(w.Code).To(Equal(400))
+			}
+		})
+	})
+
+	Describe("Exercise Taxonomy", func() {
+		var taggedId string
+
+		It("should create exercise with normalized taxonomy tags", func() {
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{
+				"name":              "Romanian Deadlift",
+				"description":       "Hip hinge with dumbbells",
+				"public":            true,
+				"sports":            []string{"fitness"},
+				"primary_muscles":   []string{"hamstrings"},
+				"secondary_muscles": []string{"glutes", "lower back"},
+				"equipment":         []string{"dumbbell"},
+				"difficulty":        "intermediate",
+				"sets": []gin.H{
+					{"name": "Working", "rest_time": 90e9, "rep_count": 10},
+				},
+			})
+			req, _ := http.NewRequest("POST", "/exercises", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			body := decodeBody(w.Body)
+			Expect(w.Code).To(Equal(201))
+			Expect(body["sports"]).To(Equal([]interface{}{"FITNESS"}))
+			Expect(body["secondary_muscles"]).To(Equal([]interface{}{"GLUTES", "LOWER_BACK"}))
+			Expect(body["difficulty"]).To(Equal("INTERMEDIATE"))
+			taggedId = body["id"].(string)
+		})
+
+		It("should fail to create exercise with unknown equipment", func() {
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{
+				"name":        "Invalid Tags",
+				"description": "Invalid tags",
+				"public":      false,
+				"equipment":   []string{"spaceship"},
+				"sets":        []gin.H{{"name": "Set", "rest_time": 30e9, "rep_count": 5}},
+			})
+			req, _ := http.NewRequest("POST", "/exercises", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(400))
+		})
+
+		It("should filter exercises by sport, equipment and muscle", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/exercises?filter.sport=FITNESS&filter.equipment=dumbbell&filter.muscle=hamstrings", nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			Expect(w.Code).To(Equal(200))
+			var body []gin.H
+			json.NewDecoder(w.Body).Decode(&body)
+			Expect(len(body)).To(Equal(1))
+			Expect(body[0]["id"]).To(Equal(taggedId))
+			Expect(w.Header().Get("X-Total-Count")).To(Equal("1"))
+		})
+
+		It("should match secondary muscles but not primary_muscle filter", func() {
+			for query, count := range map[string]int{
+				"filter.muscle=glutes":                                         1,
+				"filter.primary_muscle=glutes":                                 0,
+				"filter.equipment=barbell,kettlebell":                          0,
+				"filter.difficulty=BEGINNER,INTERMEDIATE&filter.sport=FITNESS": 1,
+			} {
+				w := httptest.NewRecorder()
+				req, _ := http.NewRequest("GET", "/exercises?"+query, nil)
+				req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+				router.ServeHTTP(w, req)
+
+				Expect(w.Code).To(Equal(200))
+				var body []gin.H
+				json.NewDecoder(w.Body).Decode(&body)
+				Expect(len(body)).To(Equal(count), query)
+			}
+		})
+
+		It("should fail to filter by unknown term", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/exercises?filter.muscle=wings", nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(400))
+		})
+
+		It("should list taxonomy terms", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/exercises/taxonomy", nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			body := decodeBody(w.Body)
+			Expect(w.Code).To(Equal(200))
+			Expect(body["equipment"]).To(ContainElement("DUMBBELL"))
+		})
+	})
+
 	Describe("Exercise Retrieval", func() {
 		It("should get exercise by ID", func() {
 			w := httptest.NewRecorder()
@@ -174,6 +414,22 @@ func exerciseGroup() {
 			}
 		})
 
+		It("should filter exercises by visibility", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/exercises?visibility=PUBLIC", nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			if w.Code == 200 {
+				var body []interface{}
+				json.NewDecoder(w.Body).Decode(&body)
+				for _, exercise := range body {
+					ex := exercise.(map[string]interface{})
+					Expect(ex["visibility"]).To(Equal("PUBLIC"))
+				}
+			}
+		})
+
 		It("should search exercises by name", func() {
 			w := httptest.NewRecorder()
 			req, _ := http.NewRequest("GET", "/exercises?name=test", nil)
@@ -327,6 +583,156 @@ func exerciseGroup() {
 		})
 	})
 
+	Describe("Exercise Revisions", func() {
+		var revisionedId string
+
+		It("should record a revision on create and update", func() {
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{
+				"name":        "Romanian Deadlift",
+				"description": "Hinge",
+				"public":      false,
+				"sets": []gin.H{
+					{"name": "Working", "rest_time": 90e9, "rep_count": 8},
+					{"name": "Working", "rest_time": 90e9, "rep_count": 8},
+				},
+			})
+			req, _ := http.NewRequest("POST", "/exercises", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(201))
+			revisionedId = decodeBody(w.Body)["id"].(string)
+
+			w = httptest.NewRecorder()
+			reqBody, _ = json.Marshal(gin.H{
+				"name":        "Romanian Deadlift",
+				"description": "Hinge with soft knees",
+				"public":      false,
+				"sets": []gin.H{
+					{"name": "Working", "rest_time": 90e9, "rep_count": 10},
+				},
+			})
+			req, _ = http.NewRequest("PUT", fmt.Sprintf("/exercises/%s", revisionedId), bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(200))
+			Expect(len(decodeBody(w.Body)["sets"].([]interface{}))).To(Equal(1))
+
+			w = httptest.NewRecorder()
+			req, _ = http.NewRequest("GET", fmt.Sprintf("/exercises/%s/revisions", revisionedId), nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			Expect(w.Code).To(Equal(200))
+			Expect(w.Header().Get("X-Total-Count")).To(Equal("2"))
+			var body []map[string]interface{}
+			json.NewDecoder(w.Body).Decode(&body)
+			Expect(body[0]["revision"]).To(Equal(float64(2)))
+			Expect(body[1]["revision"]).To(Equal(float64(1)))
+			snapshot := body[1]["snapshot"].(map[string]interface{})
+			Expect(snapshot["description"]).To(Equal("Hinge"))
+			Expect(len(snapshot["sets"].([]interface{}))).To(Equal(2))
+		})
+
+		It("should diff two revisions", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", fmt.Sprintf("/exercises/%s/revisions/diff?from=1&to=2", revisionedId), nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			body := decodeBody(w.Body)
+			Expect(w.Code).To(Equal(200))
+			changes := map[string]interface{}{}
+			for _, c := range body["changes"].([]interface{}) {
+				change := c.(map[string]interface{})
+				changes[change["path"].(string)] = change["to"]
+			}
+			Expect(changes).To(HaveKeyWithValue("description", "Hinge with soft knees"))
+			Expect(changes).To(HaveKeyWithValue("sets[0].rep_count", float64(10)))
+			Expect(changes).To(HaveKey("sets[1]"))
+			Expect(changes).NotTo(HaveKey("updated_at"))
+		})
+
+		It("should pin workout logs to the revision performed", func() {
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{"note": "hinge day"})
+			req, _ := http.NewRequest("POST", "/workouts", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(201))
+			sessionId := decodeBody(w.Body)["id"].(string)
+
+			w = httptest.NewRecorder()
+			reqBody, _ = json.Marshal(gin.H{"exercise_id": revisionedId, "set_number": 1, "rep_count": 10, "load": 80})
+			req, _ = http.NewRequest("POST", fmt.Sprintf("/workouts/%s/sets", sessionId), bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			body := decodeBody(w.Body)
+			Expect(w.Code).To(Equal(201))
+			Expect(body["exercise_revision"]).To(Equal(float64(2)))
+			Expect(body["prescribed_rep_count"]).To(Equal(float64(10)))
+		})
+
+		It("should restore an older revision as a new one", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", fmt.Sprintf("/exercises/%s/revisions/1/restore", revisionedId), nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			body := decodeBody(w.Body)
+			Expect(w.Code).To(Equal(200))
+			Expect(body["description"]).To(Equal("Hinge"))
+			Expect(len(body["sets"].([]interface{}))).To(Equal(2))
+
+			w = httptest.NewRecorder()
+			req, _ = http.NewRequest("GET", fmt.Sprintf("/exercises/%s/revisions/3", revisionedId), nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(200))
+			Expect(decodeBody(w.Body)["snapshot"].(map[string]interface{})["description"]).To(Equal("Hinge"))
+		})
+
+		It("should restore a revision without sets", func() {
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{"name": "Romanian Deadlift", "description": "No sets", "sets": []gin.H{}})
+			req, _ := http.NewRequest("PUT", fmt.Sprintf("/exercises/%s", revisionedId), bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(200))
+			Expect(decodeBody(w.Body)["sets"]).To(BeNil())
+
+			w = httptest.NewRecorder()
+			req, _ = http.NewRequest("POST", fmt.Sprintf("/exercises/%s/revisions/3/restore", revisionedId), nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(200))
+			Expect(len(decodeBody(w.Body)["sets"].([]interface{}))).To(Equal(2))
+
+			w = httptest.NewRecorder()
+			req, _ = http.NewRequest("POST", fmt.Sprintf("/exercises/%s/revisions/4/restore", revisionedId), nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			body := decodeBody(w.Body)
+			Expect(w.Code).To(Equal(200))
+			Expect(body["description"]).To(Equal("No sets"))
+			Expect(body["sets"]).To(BeNil())
+		})
+
+		It("should fail to fetch a missing revision", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", fmt.Sprintf("/exercises/%s/revisions/99", revisionedId), nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(400))
+		})
+	})
+
 	Describe("Exercise Performance Tracking", func() {
 		It("should track exercise performance over time", func() {
 			// Create an exercise
@@ -0,0 +1,237 @@
+package tests_test
+
+import (
+	"bytes"
+	"coachwise/src/app/events"
+	"coachwise/src/app/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func goalsGroup() {
+	var gripID string
+	var heartRateID string
+	var exerciseID string
+	var sessionID string
+	var gripGoalID string
+	var exerciseGoalID string
+	publisher := new(recordingPublisher)
+	var defaultPublisher events.Publisher
+	deadline := time.Now().AddDate(0, 3, 0).Format(time.DateOnly)
+
+	request := func(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		var reqBody []byte
+		if body != nil {
+			reqBody, _ = json.Marshal(body)
+		}
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		router.ServeHTTP(w, req)
+		return w
+	}
+	logParam := func(paramID string, log gin.H) {
+		Expect(request("POST", fmt.Sprintf("/params/%s/logs", paramID), authTokens[0], log).Code).To(Equal(201))
+	}
+	getGoal := func(id string) gin.H {
+		w := request("GET", "/goals/"+id, authTokens[0], nil)
+		Expect(w.Code).To(Equal(200))
+		return decodeBody(w.Body)
+	}
+	achieved := func() []events.Event {
+		result := []events.Event{}
+		for _, e := range publisher.events {
+			if e.Name == events.GoalAchieved {
+				result = append(result, e)
+			}
+		}
+		return result
+	}
+
+	BeforeEach(func() {
+		defaultPublisher = events.Default
+		events.Default = publisher
+	})
+
+	AfterEach(func() {
+		events.Default = defaultPublisher
+	})
+
+	It("should create a param goal", func() {
+		Expect(db.Get(&gripID, `INSERT INTO params (name, unit, available_sports)
+			VALUES ('Grip strength', 'KG', '{THERAPEUTIC}') RETURNING id`)).To(Succeed())
+		w := request("POST", "/goals", authTokens[0], gin.H{
+			"param_id":     gripID,
+			"side":         "RIGHT",
+			"target_value": 50,
+			"deadline":     deadline,
+		})
+		Expect(w.Code).To(Equal(201))
+		body := decodeBody(w.Body)
+		gripGoalID = body["id"].(string)
+		Expect(body["param_name"]).To(Equal("Grip strength"))
+		Expect(body["unit"]).To(Equal("KG"))
+		Expect(body["status"]).To(Equal("ACTIVE"))
+		Expect(body["current_value"]).To(BeNil())
+		Expect(body["progress"]).To(Equal(float64(0)))
+	})
+
+	It("should evaluate the goal on new logs of its side", func() {
+		logParam(gripID, gin.H{"value": 40, "side": "RIGHT"})
+		body := getGoal(gripGoalID)
+		Expect(body["start_value"]).To(Equal(float64(40)))
+		Expect(body["current_value"]).To(Equal(float64(40)))
+		Expect(body["progress"]).To(Equal(float64(0)))
+
+		logParam(gripID, gin.H{"value": 55, "side": "LEFT"})
+		logParam(gripID, gin.H{"value": 45, "side": "RIGHT"})
+		body = getGoal(gripGoalID)
+		Expect(body["current_value"]).To(Equal(float64(45)))
+		Expect(body["progress"]).To(Equal(float64(50)))
+		Expect(body["status"]).To(Equal("ACTIVE"))
+		Expect(achieved()).To(BeEmpty())
+	})
+
+	It("should achieve the goal and notify the user", func() {
+		logParam(gripID, gin.H{"value": 51, "side": "RIGHT"})
+		body := getGoal(gripGoalID)
+		Expect(body["status"]).To(Equal("ACHIEVED"))
+		Expect(body["progress"]).To(Equal(float64(100)))
+		Expect(body["achieved_at"]).NotTo(BeNil())
+		Expect(achieved()).To(HaveLen(1))
+		Expect(achieved()[0].Payload.(models.Goal).TargetValue).To(Equal(float64(50)))
+
+		logParam(gripID, gin.H{"value": 52, "side": "RIGHT"})
+		Expect(achieved()).To(HaveLen(1))
+	})
+
+	It("should reopen the goal on a higher target", func() {
+		w := request("PUT", "/goals/"+gripGoalID, authTokens[0], gin.H{"target_value": 60, "deadline": deadline})
+		Expect(w.Code).To(Equal(200))
+		body := decodeBody(w.Body)
+		Expect(body["status"]).To(Equal("ACTIVE"))
+		Expect(body["achieved_at"]).To(BeNil())
+		Expect(body["start_value"]).To(Equal(float64(40)))
+		Expect(body["progress"]).To(Equal(float64(60)))
+	})
+
+	It("should track goals going down", func() {
+		Expect(db.Get(&heartRateID, `INSERT INTO params (name, unit, available_sports)
+			VALUES ('Resting heart rate', 'COUNT', '{THERAPEUTIC}') RETURNING id`)).To(Succeed())
+		logParam(heartRateID, gin.H{"value": 70})
+		w := request("POST", "/goals", authTokens[0], gin.H{"param_id": heartRateID, "target_value": 60})
+		Expect(w.Code).To(Equal(201))
+		body := decodeBody(w.Body)
+		Expect(body["start_value"]).To(Equal(float64(70)))
+
+		logParam(heartRateID, gin.H{"value": 65})
+		body = getGoal(body["id"].(string))
+		Expect(body["progress"]).To(Equal(float64(50)))
+		Expect(body["status"]).To(Equal("ACTIVE"))
+	})
+
+	It("should evaluate exercise goals on personal records", func() {
+		w := request("POST", "/exercises", authTokens[0], gin.H{"name": "Goals Bench Press"})
+		Expect(w.Code).To(Equal(201))
+		exerciseID = decodeBody(w.Body)["id"].(string)
+		w = request("POST", "/goals", authTokens[0], gin.H{"exercise_id": exerciseID, "metric": "max_load", "target_value": 100})
+		Expect(w.Code).To(Equal(201))
+		body := decodeBody(w.Body)
+		exerciseGoalID = body["id"].(string)
+		Expect(body["exercise_name"]).To(Equal("Goals Bench Press"))
+		Expect(body["metric"]).To(Equal("MAX_LOAD"))
+		Expect(body["unit"]).To(Equal("KG"))
+
+		w = request("POST", "/workouts", authTokens[0], gin.H{})
+		Expect(w.Code).To(Equal(201))
+		sessionID = decodeBody(w.Body)["id"].(string)
+		path := fmt.Sprintf("/workouts/%s/sets", sessionID)
+		Expect(request("POST", path, authTokens[0], gin.H{"exercise_id": exerciseID, "rep_count": 5, "load": 80}).Code).To(Equal(201))
+		Expect(request("POST", path, authTokens[0], gin.H{"exercise_id": exerciseID, "rep_count": 3, "load": 90}).Code).To(Equal(201))
+		body = getGoal(exerciseGoalID)
+		Expect(body["start_value"]).To(Equal(float64(80)))
+		Expect(body["current_value"]).To(Equal(float64(90)))
+		Expect(body["progress"]).To(Equal(float64(50)))
+
+		Expect(request("POST", path, authTokens[0], gin.H{"exercise_id": exerciseID, "rep_count": 1, "load": 225, "unit": "LB"}).Code).To(Equal(201))
+		body = getGoal(exerciseGoalID)
+		Expect(body["status"]).To(Equal("ACHIEVED"))
+		Expect(body["current_value"]).To(Equal(102.06))
+		Expect(achieved()).To(HaveLen(2))
+	})
+
+	It("should validate goals", func() {
+		Expect(request("POST", "/goals", authTokens[0], gin.H{"target_value": 10}).Code).To(Equal(400))
+		Expect(request("POST", "/goals", authTokens[0], gin.H{
+			"param_id": gripID, "exercise_id": exerciseID, "target_value": 10,
+		}).Code).To(Equal(400))
+		Expect(request("POST", "/goals", authTokens[0], gin.H{"exercise_id": exerciseID, "target_value": 10}).Code).To(Equal(400))
+		Expect(request("POST", "/goals", authTokens[0], gin.H{
+			"exercise_id": exerciseID, "metric": "MAX_REPS", "unit": "KG", "target_value": 10,
+		}).Code).To(Equal(400))
+		Expect(request("POST", "/goals", authTokens[0], gin.H{"param_id": gripID, "unit": "CM", "target_value": 10}).Code).To(Equal(400))
+		Expect(request("POST", "/goals", authTokens[0], gin.H{"param_id": gripID, "target_value": 10, "deadline": "2020-01-01"}).Code).To(Equal(400))
+		Expect(request("GET", "/goals?status=done", authTokens[0], nil).Code).To(Equal(400))
+		Expect(request("GET", "/goals/not-a-uuid", authTokens[0], nil).Code).To(Equal(400))
+		Expect(request("POST", "/params/not-a-uuid/logs", authTokens[0], gin.H{"value": 10}).Code).To(Equal(400))
+		Expect(request("GET", "/params/not-a-uuid/trends", authTokens[0], nil).Code).To(Equal(400))
+	})
+
+	It("should miss goals past their deadline", func() {
+		_, err := db.Exec("UPDATE goals SET deadline=CURRENT_DATE - 1 WHERE id=$1", gripGoalID)
+		Expect(err).To(BeNil())
+		w := request("GET", "/goals?status=MISSED", authTokens[0], nil)
+		Expect(w.Code).To(Equal(200))
+		Expect(w.Header().Get("X-Total-Count")).To(Equal("1"))
+		var goals []gin.H
+		json.NewDecoder(w.Body).Decode(&goals)
+		Expect(goals[0]["id"]).To(Equal(gripGoalID))
+
+		logParam(gripID, gin.H{"value": 65, "side": "RIGHT"})
+		Expect(getGoal(gripGoalID)["status"]).To(Equal("MISSED"))
+	})
+
+	It("should list the goals of the user", func() {
+		w := request("GET", "/goals", authTokens[0], nil)
+		Expect(w.Code).To(Equal(200))
+		Expect(w.Header().Get("X-Total-Count")).To(Equal("3"))
+		var goals []gin.H
+		json.NewDecoder(w.Body).Decode(&goals)
+		statuses := []interface{}{}
+		for _, g := range goals {
+			statuses = append(statuses, g["status"])
+		}
+		Expect(statuses).To(Equal([]interface{}{"ACTIVE", "ACHIEVED", "MISSED"}))
+	})
+
+	It("should cancel goals", func() {
+		w := request("DELETE", "/goals/"+gripGoalID, authTokens[0], nil)
+		Expect(w.Code).To(Equal(200))
+		Expect(decodeBody(w.Body)["status"]).To(Equal("CANCELLED"))
+		Expect(request("PUT", "/goals/"+gripGoalID, authTokens[0], gin.H{"target_value": 70}).Code).To(Equal(400))
+		Expect(request("DELETE", "/goals/"+exerciseGoalID, authTokens[0], nil).Code).To(Equal(400))
+	})
+
+	It("should keep goals private", func() {
+		w := httptest.NewRecorder()
+		reqBody, _ := json.Marshal(gin.H{"email": "athlete@test.com", "password": "athlete123456"})
+		req, _ := http.NewRequest("POST", "/auth/login", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(200))
+		athleteToken := decodeBody(w.Body)["access_token"].(string)
+
+		Expect(request("GET", "/goals/"+exerciseGoalID, athleteToken, nil).Code).To(Equal(403))
+		w = request("GET", "/goals", athleteToken, nil)
+		Expect(w.Code).To(Equal(200))
+		Expect(w.Header().Get("X-Total-Count")).To(Equal("0"))
+	})
+}
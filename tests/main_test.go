@@ -54,7 +54,21 @@ var _ = Describe("coachwise Test Suite", func() {
 	Context("Exercise", exerciseGroup)
 	Context("Users", usersGroup)
 	Context("Plans", plansGroup)
+	Context("Programmes", programmesGroup)
+	Context("Workouts", workoutsGroup)
+	Context("Search", searchGroup)
+	Context("Admin", adminGroup)
 	Context("Edge Cases", edgeCasesGroup)
+	Context("Seed", seedGroup)
+	Context("Exchange", exchangeGroup)
+	Context("Calendar", calendarGroup)
+	Context("Coaching", coachingGroup)
+	Context("Organizations", organizationsGroup)
+	Context("Stats", statsGroup)
+	Context("Records", recordsGroup)
+	Context("Params", paramsGroup)
+	Context("Goals", goalsGroup)
+	Context("Comments", commentsGroup)
 })
 
 func init() {
@@ -93,6 +107,9 @@ func bodyExpect(body, expect gin.H) {
 
 func setupTestEnvironment() (*sqlx.DB, *gin.Engine) {
 	config.Init(configPath)
+	if config.Config.App.URL == "" {
+		config.Config.App.URL = "https://coachwise.test"
+	}
 	db := database.Connect(&database.ConnectOption{
 		URL:         config.Config.Database.URL,
 		SqlDir:      config.Config.Database.SqlDir,
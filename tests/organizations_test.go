@@ -0,0 +1,206 @@
+package tests_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func organizationsGroup() {
+	var memberToken string
+	var memberID string
+	var organizationID string
+	var exerciseID string
+	var planID string
+	memberEmail := "athlete@test.com"
+
+	request := func(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		var reqBody []byte
+		if body != nil {
+			reqBody, _ = json.Marshal(body)
+		}
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		router.ServeHTTP(w, req)
+		return w
+	}
+	list := func(w *httptest.ResponseRecorder) []gin.H {
+		var body []gin.H
+		json.NewDecoder(w.Body).Decode(&body)
+		return body
+	}
+	ids := func(w *httptest.ResponseRecorder) []interface{} {
+		result := []interface{}{}
+		for _, item := range list(w) {
+			result = append(result, item["id"])
+		}
+		return result
+	}
+
+	It("should login the member", func() {
+		w := httptest.NewRecorder()
+		reqBody, _ := json.Marshal(gin.H{"email": memberEmail, "password": "athlete123456"})
+		req, _ := http.NewRequest("POST", "/auth/login", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(200))
+		memberToken = decodeBody(w.Body)["access_token"].(string)
+		db.Get(&memberID, "SELECT id FROM users WHERE email=$1", memberEmail)
+	})
+
+	It("should create an organization managed by its creator", func() {
+		Expect(request("POST", "/organizations", authTokens[0], gin.H{"name": " "}).Code).To(Equal(400))
+
+		w := request("POST", "/organizations", authTokens[0], gin.H{"name": "Riverside Club", "description": "Rowing and strength"})
+		Expect(w.Code).To(Equal(201))
+		body := decodeBody(w.Body)
+		organizationID = body["id"].(string)
+		Expect(body["name"]).To(Equal("Riverside Club"))
+		Expect(body["members"]).To(Equal(float64(1)))
+
+		w = request("GET", "/organizations", authTokens[0], nil)
+		Expect(w.Code).To(Equal(200))
+		Expect(ids(w)).To(ContainElement(organizationID))
+	})
+
+	It("should hide the organization from non members", func() {
+		Expect(request("GET", "/organizations/"+organizationID, memberToken, nil).Code).To(Equal(403))
+		Expect(request("PUT", "/organizations/"+organizationID, memberToken, gin.H{"name": "Taken"}).Code).To(Equal(403))
+		Expect(list(request("GET", "/organizations", memberToken, nil))).To(BeEmpty())
+	})
+
+	It("should add a member who accepts the invite", func() {
+		w := request("POST", fmt.Sprintf("/organizations/%s/members", organizationID), authTokens[0], gin.H{"email": memberEmail, "role": "owner"})
+		Expect(w.Code).To(Equal(400))
+		w = request("POST", fmt.Sprintf("/organizations/%s/members", organizationID), authTokens[0], gin.H{"email": "not-an-email", "role": "athlete"})
+		Expect(w.Code).To(Equal(400))
+		// unregistered emails are invited the same way so accounts can't be probed
+		w = request("POST", fmt.Sprintf("/organizations/%s/members", organizationID), authTokens[0], gin.H{"email": "nobody@test.com", "role": "athlete"})
+		Expect(w.Code).To(Equal(201))
+
+		w = request("POST", fmt.Sprintf("/organizations/%s/members", organizationID), authTokens[0], gin.H{"email": " Athlete@Test.com ", "role": "athlete"})
+		Expect(w.Code).To(Equal(201))
+		invite := decodeBody(w.Body)
+		inviteID := invite["id"].(string)
+		Expect(invite["email"]).To(Equal(memberEmail))
+		Expect(invite["status"]).To(Equal("PENDING"))
+		Expect(request("GET", "/organizations/"+organizationID, memberToken, nil).Code).To(Equal(403))
+
+		w = request("GET", "/organizations/invites", memberToken, nil)
+		Expect(w.Code).To(Equal(200))
+		invites := list(w)
+		Expect(invites).To(HaveLen(1))
+		Expect(invites[0]["id"]).To(Equal(inviteID))
+		Expect(invites[0]["organization_name"]).To(Equal("Riverside Club"))
+		Expect(invites[0]["role"]).To(Equal("athlete"))
+
+		Expect(request("POST", fmt.Sprintf("/organizations/invites/%s/accept", inviteID), authTokens[0], nil).Code).To(Equal(403))
+		w = request("POST", fmt.Sprintf("/organizations/invites/%s/accept", inviteID), memberToken, nil)
+		Expect(w.Code).To(Equal(200))
+		Expect(decodeBody(w.Body)["status"]).To(Equal("ACCEPTED"))
+		Expect(request("POST", fmt.Sprintf("/organizations/invites/%s/accept", inviteID), memberToken, nil).Code).To(Equal(400))
+		Expect(list(request("GET", "/organizations/invites", memberToken, nil))).To(BeEmpty())
+
+		w = request("GET", fmt.Sprintf("/organizations/%s/members", organizationID), memberToken, nil)
+		Expect(w.Code).To(Equal(200))
+		Expect(w.Header().Get("X-Total-Count")).To(Equal("2"))
+		for _, member := range list(w) {
+			if member["id"] == memberID {
+				Expect(member["roles"]).To(Equal([]interface{}{"athlete"}))
+			}
+		}
+		Expect(request("POST", fmt.Sprintf("/organizations/%s/members", organizationID), memberToken, gin.H{"email": memberEmail, "role": "manager"}).Code).To(Equal(403))
+	})
+
+	It("should share exercises and plans with the organization", func() {
+		w := request("POST", "/exercises", authTokens[0], gin.H{"name": "Club Erg Intervals", "visibility": "ORG"})
+		Expect(w.Code).To(Equal(400))
+		w = request("POST", "/exercises", authTokens[0], gin.H{"name": "Club Erg Intervals", "visibility": "TEAM", "organization_id": organizationID})
+		Expect(w.Code).To(Equal(400))
+		w = request("POST", "/exercises", memberToken, gin.H{"name": "Club Erg Intervals", "visibility": "ORG", "organization_id": organizationID})
+		Expect(w.Code).To(Equal(403))
+
+		w = request("POST", "/exercises", authTokens[0], gin.H{"name": "Club Erg Intervals", "visibility": "org", "organization_id": organizationID})
+		Expect(w.Code).To(Equal(201))
+		body := decodeBody(w.Body)
+		exerciseID = body["id"].(string)
+		Expect(body["visibility"]).To(Equal("ORG"))
+		Expect(body["organization_id"]).To(Equal(organizationID))
+
+		w = request("POST", "/plans", authTokens[0], gin.H{"name": "Club Base", "visibility": "ORG", "organization_id": organizationID})
+		Expect(w.Code).To(Equal(201))
+		planID = decodeBody(w.Body)["id"].(string)
+
+		Expect(request("GET", "/exercises/"+exerciseID, memberToken, nil).Code).To(Equal(200))
+		Expect(request("GET", "/plans/"+planID, memberToken, nil).Code).To(Equal(200))
+
+		w = request("GET", "/exercises?organization_id="+organizationID, memberToken, nil)
+		Expect(w.Code).To(Equal(200))
+		Expect(ids(w)).To(Equal([]interface{}{exerciseID}))
+		w = request("GET", "/plans?visibility=ORG", memberToken, nil)
+		Expect(w.Code).To(Equal(200))
+		Expect(ids(w)).To(Equal([]interface{}{planID}))
+		Expect(request("GET", "/plans?visibility=TEAM", memberToken, nil).Code).To(Equal(400))
+	})
+
+	It("should let only library editors of the organization update shared content", func() {
+		Expect(request("PUT", "/plans/"+planID, memberToken, gin.H{"name": "Club Base 2", "visibility": "ORG", "organization_id": organizationID}).Code).To(Equal(403))
+
+		w := request("PUT", fmt.Sprintf("/organizations/%s/members/%s", organizationID, memberID), authTokens[0], gin.H{"role": "coach"})
+		Expect(w.Code).To(Equal(200))
+
+		w = request("PUT", "/plans/"+planID, memberToken, gin.H{"name": "Club Base 2", "visibility": "ORG", "organization_id": organizationID})
+		Expect(w.Code).To(Equal(200))
+		Expect(decodeBody(w.Body)["name"]).To(Equal("Club Base 2"))
+	})
+
+	It("should keep the sharing of content updated by older clients", func() {
+		for _, form := range []gin.H{{"name": "Club Base 3", "public": false}, {"name": "Club Base 3"}} {
+			w := request("PUT", "/plans/"+planID, authTokens[0], form)
+			Expect(w.Code).To(Equal(200))
+			body := decodeBody(w.Body)
+			Expect(body["visibility"]).To(Equal("ORG"))
+			Expect(body["organization_id"]).To(Equal(organizationID))
+			Expect(body["public"]).To(Equal(false))
+
+			w = request("PUT", "/exercises/"+exerciseID, authTokens[0], gin.H{"name": "Club Erg Intervals", "public": form["public"]})
+			Expect(w.Code).To(Equal(200))
+			body = decodeBody(w.Body)
+			Expect(body["visibility"]).To(Equal("ORG"))
+			Expect(body["organization_id"]).To(Equal(organizationID))
+		}
+	})
+
+	It("should keep at least one manager", func() {
+		var managerID string
+		db.Get(&managerID, "SELECT id FROM users WHERE email=$1", usersData[0]["email"])
+		Expect(request("DELETE", fmt.Sprintf("/organizations/%s/members/%s", organizationID, managerID), authTokens[0], nil).Code).To(Equal(400))
+		Expect(request("PUT", fmt.Sprintf("/organizations/%s/members/%s", organizationID, managerID), authTokens[0], gin.H{"role": "coach"}).Code).To(Equal(400))
+		Expect(request("DELETE", fmt.Sprintf("/organizations/%s/members/%s", organizationID, managerID), memberToken, nil).Code).To(Equal(403))
+	})
+
+	It("should hide shared content from members who leave", func() {
+		Expect(request("DELETE", fmt.Sprintf("/organizations/%s/members/%s", organizationID, memberID), memberToken, nil).Code).To(Equal(200))
+		Expect(request("GET", "/exercises/"+exerciseID, memberToken, nil).Code).To(Equal(403))
+		Expect(list(request("GET", "/plans?visibility=ORG", memberToken, nil))).To(BeEmpty())
+	})
+
+	It("should turn shared content private when the organization is deleted", func() {
+		Expect(request("DELETE", "/organizations/"+organizationID, authTokens[0], nil).Code).To(Equal(200))
+		Expect(request("GET", "/organizations/"+organizationID, authTokens[0], nil).Code).To(Equal(400))
+
+		w := request("GET", "/exercises/"+exerciseID, authTokens[0], nil)
+		Expect(w.Code).To(Equal(200))
+		body := decodeBody(w.Body)
+		Expect(body["visibility"]).To(Equal("PRIVATE"))
+		Expect(body["organization_id"]).To(BeNil())
+	})
+}
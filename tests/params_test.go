@@ -0,0 +1,140 @@
+package tests_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func paramsGroup() {
+	var athleteToken string
+	var athleteID string
+	var paramID string
+	athleteEmail := "athlete@test.com"
+	window := "from=2026-01-01&to=2026-01-31&bucket=week&window=2"
+
+	request := func(path, token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		router.ServeHTTP(w, req)
+		return w
+	}
+	bucket := func(body gin.H, i int) map[string]interface{} {
+		return body["buckets"].([]interface{})[i].(map[string]interface{})
+	}
+
+	It("should log a bilateral param", func() {
+		w := httptest.NewRecorder()
+		reqBody, _ := json.Marshal(gin.H{"email": athleteEmail, "password": "athlete123456"})
+		req, _ := http.NewRequest("POST", "/auth/login", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(200))
+		athleteToken = decodeBody(w.Body)["access_token"].(string)
+		Expect(db.Get(&athleteID, "SELECT id FROM users WHERE email=$1", athleteEmail)).To(Succeed())
+
+		Expect(db.Get(&paramID, `INSERT INTO params (name, unit, available_sports)
+			VALUES ('Knee flexion', 'COUNT', '{THERAPEUTIC}') RETURNING id`)).To(Succeed())
+		_, err := db.Exec(`INSERT INTO param_logs (user_id, param_id, side, value, created_at) VALUES
+			($1, $2, 'LEFT', 40, '2026-01-05 09:00'),
+			($1, $2, 'LEFT', 50, '2026-01-07 09:00'),
+			($1, $2, 'RIGHT', 80, '2026-01-06 09:00'),
+			($1, $2, 'LEFT', 60, '2026-01-12 09:00'),
+			($1, $2, 'RIGHT', 80, '2026-01-13 09:00'),
+			($1, $2, 'LEFT', 75, '2026-01-19 09:00'),
+			($1, $2, 'LEFT', 20, '2026-02-10 09:00')`, athleteID, paramID)
+		Expect(err).To(BeNil())
+	})
+
+	It("should bucket the logs by week and side", func() {
+		w := request(fmt.Sprintf("/params/%s/trends?%s", paramID, window), athleteToken)
+		Expect(w.Code).To(Equal(200))
+		body := decodeBody(w.Body)
+		Expect(body["param"].(map[string]interface{})["name"]).To(Equal("Knee flexion"))
+		Expect(body["to"]).To(Equal("2026-01-31T00:00:00Z"))
+		Expect(body["buckets"]).To(HaveLen(5))
+
+		first := bucket(body, 0)
+		Expect(first["bucket"]).To(Equal("2026-01-05T00:00:00Z"))
+		Expect(first["side"]).To(Equal("LEFT"))
+		Expect(first["count"]).To(Equal(float64(2)))
+		Expect(first["min"]).To(Equal(float64(40)))
+		Expect(first["max"]).To(Equal(float64(50)))
+		Expect(first["average"]).To(Equal(float64(45)))
+		Expect(first["change"]).To(BeNil())
+
+		second := bucket(body, 2)
+		Expect(second["side"]).To(Equal("LEFT"))
+		Expect(second["moving_average"]).To(Equal(float64(50)))
+		Expect(second["change"]).To(Equal(float64(15)))
+		Expect(second["rate_per_day"]).To(BeNumerically("~", 15.0/7, 0.0001))
+
+		last := bucket(body, 4)
+		Expect(last["bucket"]).To(Equal("2026-01-19T00:00:00Z"))
+		Expect(last["moving_average"]).To(Equal(67.5))
+	})
+
+	It("should summarize each side over the window", func() {
+		body := decodeBody(request(fmt.Sprintf("/params/%s/trends?%s", paramID, window), athleteToken).Body)
+		summary := body["summary"].([]interface{})
+		Expect(summary).To(HaveLen(2))
+		left := summary[0].(map[string]interface{})
+		Expect(left["side"]).To(Equal("LEFT"))
+		Expect(left["count"]).To(Equal(float64(4)))
+		Expect(left["first"]).To(Equal(float64(40)))
+		Expect(left["last"]).To(Equal(float64(75)))
+		Expect(left["max"]).To(Equal(float64(75)))
+		Expect(left["rate_per_day"]).To(BeNumerically(">", 0))
+		right := summary[1].(map[string]interface{})
+		Expect(right["rate_per_day"]).To(Equal(float64(0)))
+	})
+
+	It("should compare the left and right sides", func() {
+		body := decodeBody(request(fmt.Sprintf("/params/%s/trends?%s", paramID, window), athleteToken).Body)
+		asymmetry := body["asymmetry"].([]interface{})
+		Expect(asymmetry).To(HaveLen(2))
+		Expect(asymmetry[0]).To(Equal(map[string]interface{}{
+			"bucket":     "2026-01-05T00:00:00Z",
+			"left":       float64(45),
+			"right":      float64(80),
+			"difference": float64(-35),
+			"asymmetry":  -43.75,
+		}))
+		Expect(asymmetry[1].(map[string]interface{})["asymmetry"]).To(Equal(float64(-25)))
+	})
+
+	It("should bucket by month", func() {
+		body := decodeBody(request(fmt.Sprintf("/params/%s/trends?from=2026-01-01&to=2026-02-28&bucket=month", paramID), athleteToken).Body)
+		Expect(body["buckets"]).To(HaveLen(3))
+		Expect(bucket(body, 2)["bucket"]).To(Equal("2026-02-01T00:00:00Z"))
+		Expect(bucket(body, 2)["change"]).To(Equal(float64(-36.25)))
+	})
+
+	It("should validate the query", func() {
+		path := fmt.Sprintf("/params/%s/trends?", paramID)
+		Expect(request(path+"bucket=year", athleteToken).Code).To(Equal(400))
+		Expect(request(path+"window=0", athleteToken).Code).To(Equal(400))
+		Expect(request(path+"from=2026-02-01&to=2026-01-01", athleteToken).Code).To(Equal(400))
+		Expect(request(path+"from=yesterday", athleteToken).Code).To(Equal(400))
+	})
+
+	It("should let only coaches of the athlete read their trends", func() {
+		path := fmt.Sprintf("/params/%s/trends?%s&user_id=%s", paramID, window, athleteID)
+		Expect(request(path, authTokens[0]).Code).To(Equal(403))
+
+		_, err := db.Exec(`INSERT INTO coach_athletes (coach_id, athlete_id, email, code, status)
+			SELECT id, $1, $2, 123456, 'ACCEPTED' FROM users WHERE email=$3`, athleteID, athleteEmail, usersData[0]["email"])
+		Expect(err).To(BeNil())
+
+		w := request(path, authTokens[0])
+		Expect(w.Code).To(Equal(200))
+		Expect(decodeBody(w.Body)["buckets"]).To(HaveLen(5))
+	})
+}
@@ -77,6 +77,7 @@ func plansGroup() {
 			if w.Code == 201 {
 				body := decodeBody(w.Body)
 				Expect(body["public"]).To(Equal(true))
+				Expect(body["visibility"]).To(Equal("PUBLIC"))
 			}
 		})
 
@@ -323,6 +324,7 @@ func plansGroup() {
 					if p["public"] != nil {
 						Expect(p["public"]).To(Equal(true))
 					}
+					Expect(p["visibility"]).To(Equal("PUBLIC"))
 				}
 			}
 		})
@@ -391,6 +393,341 @@ func plansGroup() {
 		})
 	})
 
+	Describe("Plan Groups", func() {
+		var groupPlanId string
+		var circuitId string
+
+		It("should create a plan with a superset", func() {
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{"name": "Grouped Plan", "public": false})
+			req, _ := http.NewRequest("POST", "/plans", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(201))
+			groupPlanId = decodeBody(w.Body)["id"].(string)
+
+			w = httptest.NewRecorder()
+			reqBody, _ = json.Marshal(gin.H{
+				"group_type": "SUPERSET",
+				"name":       "A",
+				"rounds":     3,
+				"rest_time":  90e9,
+				"exercises": []gin.H{
+					{"exercise_id": exerciseIds[1], "rest_time": 0},
+					{"exercise_id": exerciseIds[2], "rest_time": 0},
+				},
+			})
+			req, _ = http.NewRequest("POST", fmt.Sprintf("/plans/%s/groups", groupPlanId), bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			body := decodeBody(w.Body)
+			Expect(w.Code).To(Equal(201))
+			groups := body["groups"].([]interface{})
+			Expect(len(groups)).To(Equal(1))
+			group := groups[0].(map[string]interface{})
+			Expect(group["group_type"]).To(Equal("SUPERSET"))
+			Expect(group["rounds"]).To(Equal(float64(3)))
+			exercises := group["exercises"].([]interface{})
+			Expect(len(exercises)).To(Equal(2))
+			first := exercises[0].(map[string]interface{})
+			Expect(first["exercise_id"]).To(Equal(exerciseIds[1]))
+			Expect(len(first["exercise"].(map[string]interface{})["sets"].([]interface{}))).To(Equal(2))
+		})
+
+		It("should add a circuit and append to it", func() {
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{
+				"group_type": "CIRCUIT",
+				"rounds":     2,
+				"rest_time":  120e9,
+				"exercises": []gin.H{
+					{"exercise_id": exerciseIds[0], "rest_time": 15e9},
+					{"exercise_id": exerciseIds[1], "rest_time": 15e9},
+				},
+			})
+			req, _ := http.NewRequest("POST", fmt.Sprintf("/plans/%s/groups", groupPlanId), bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(201))
+			groups := decodeBody(w.Body)["groups"].([]interface{})
+			Expect(len(groups)).To(Equal(2))
+			circuit := groups[1].(map[string]interface{})
+			Expect(circuit["group_order"]).To(Equal(float64(2)))
+			circuitId = circuit["id"].(string)
+
+			w = httptest.NewRecorder()
+			reqBody, _ = json.Marshal(gin.H{"exercise_id": exerciseIds[2], "group_id": circuitId, "rest_time": 15e9})
+			req, _ = http.NewRequest("POST", fmt.Sprintf("/plans/%s/exercises", groupPlanId), bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(201))
+			groups = decodeBody(w.Body)["groups"].([]interface{})
+			exercises := groups[1].(map[string]interface{})["exercises"].([]interface{})
+			Expect(len(exercises)).To(Equal(3))
+			Expect(exercises[2].(map[string]interface{})["exercise_order"]).To(Equal(float64(3)))
+		})
+
+		It("should list plan exercises flattened in order", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", fmt.Sprintf("/plans/%s/exercises", groupPlanId), nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			Expect(w.Code).To(Equal(200))
+			var body []gin.H
+			json.NewDecoder(w.Body).Decode(&body)
+			Expect(len(body)).To(Equal(5))
+			Expect(body[0]["exercise_id"]).To(Equal(exerciseIds[1]))
+			Expect(body[4]["exercise_id"]).To(Equal(exerciseIds[2]))
+		})
+
+		It("should fail to create invalid groups", func() {
+			for _, group := range []gin.H{
+				{"group_type": "SUPERSET", "exercises": []gin.H{{"exercise_id": exerciseIds[0]}}},
+				{"group_type": "SINGLE", "exercises": []gin.H{{"exercise_id": exerciseIds[0]}, {"exercise_id": exerciseIds[1]}}},
+				{"group_type": "CIRCUIT", "rounds": -1, "exercises": []gin.H{{"exercise_id": exerciseIds[0]}, {"exercise_id": exerciseIds[1]}}},
+				{"group_type": "PYRAMID", "exercises": []gin.H{{"exercise_id": exerciseIds[0]}}},
+			} {
+				w := httptest.NewRecorder()
+				reqBody, _ := json.Marshal(group)
+				req, _ := http.NewRequest("POST", fmt.Sprintf("/plans/%s/groups", groupPlanId), bytes.NewBuffer(reqBody))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+				router.ServeHTTP(w, req)
+				Expect(w.Code).To(Equal(400))
+			}
+		})
+
+		It("should update group rounds and rest", func() {
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{
+				"group_type": "CIRCUIT",
+				"rounds":     4,
+				"rest_time":  60e9,
+				"exercises": []gin.H{
+					{"exercise_id": exerciseIds[2], "rest_time": 10e9},
+					{"exercise_id": exerciseIds[0], "rest_time": 10e9},
+				},
+			})
+			req, _ := http.NewRequest("PUT", fmt.Sprintf("/plans/%s/groups/%s", groupPlanId, circuitId), bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			Expect(w.Code).To(Equal(200))
+			groups := decodeBody(w.Body)["groups"].([]interface{})
+			circuit := groups[1].(map[string]interface{})
+			Expect(circuit["rounds"]).To(Equal(float64(4)))
+			exercises := circuit["exercises"].([]interface{})
+			Expect(len(exercises)).To(Equal(2))
+			Expect(exercises[0].(map[string]interface{})["exercise_id"]).To(Equal(exerciseIds[2]))
+		})
+
+		It("should delete a group", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("DELETE", fmt.Sprintf("/plans/%s/groups/%s", groupPlanId, circuitId), nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			Expect(w.Code).To(Equal(200))
+			Expect(len(decodeBody(w.Body)["groups"].([]interface{}))).To(Equal(1))
+		})
+
+		It("should turn a superset left with one exercise into a single group", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("DELETE", fmt.Sprintf("/plans/%s/exercises/%s", groupPlanId, exerciseIds[2]), nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			Expect(w.Code).To(Equal(200))
+			groups := decodeBody(w.Body)["groups"].([]interface{})
+			Expect(len(groups)).To(Equal(1))
+			group := groups[0].(map[string]interface{})
+			Expect(group["group_type"]).To(Equal("SINGLE"))
+			Expect(len(group["exercises"].([]interface{}))).To(Equal(1))
+		})
+	})
+
+	Describe("Forking", func() {
+		var sourcePlanId string
+
+		It("should fork an exercise with its sets", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", fmt.Sprintf("/exercises/%s/fork", exerciseIds[0]), nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			body := decodeBody(w.Body)
+			Expect(w.Code).To(Equal(201))
+			Expect(body["id"]).NotTo(Equal(exerciseIds[0]))
+			Expect(body["forked_from"]).To(Equal(exerciseIds[0]))
+			Expect(body["visibility"]).To(Equal("PRIVATE"))
+			Expect(body["name"]).To(Equal("Plan Exercise 1"))
+			Expect(len(body["sets"].([]interface{}))).To(Equal(2))
+
+			w = httptest.NewRecorder()
+			req, _ = http.NewRequest("GET", fmt.Sprintf("/exercises/%s", exerciseIds[0]), nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(200))
+			Expect(decodeBody(w.Body)["forks"]).To(Equal(float64(1)))
+		})
+
+		It("should fork a plan with its groups", func() {
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{"name": "Shared Plan", "description": "Push day", "public": true})
+			req, _ := http.NewRequest("POST", "/plans", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(201))
+			sourcePlanId = decodeBody(w.Body)["id"].(string)
+
+			w = httptest.NewRecorder()
+			reqBody, _ = json.Marshal(gin.H{
+				"group_type": "SUPERSET",
+				"rounds":     2,
+				"exercises": []gin.H{
+					{"exercise_id": exerciseIds[0], "rest_time": 0},
+					{"exercise_id": exerciseIds[1], "rest_time": 30e9},
+				},
+			})
+			req, _ = http.NewRequest("POST", fmt.Sprintf("/plans/%s/groups", sourcePlanId), bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(201))
+
+			w = httptest.NewRecorder()
+			req, _ = http.NewRequest("POST", fmt.Sprintf("/plans/%s/fork", sourcePlanId), nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			body := decodeBody(w.Body)
+			Expect(w.Code).To(Equal(201))
+			Expect(body["id"]).NotTo(Equal(sourcePlanId))
+			Expect(body["forked_from"]).To(Equal(sourcePlanId))
+			Expect(body["visibility"]).To(Equal("PRIVATE"))
+			Expect(body["description"]).To(Equal("Push day"))
+			groups := body["groups"].([]interface{})
+			Expect(len(groups)).To(Equal(1))
+			group := groups[0].(map[string]interface{})
+			Expect(group["group_type"]).To(Equal("SUPERSET"))
+			Expect(group["rounds"]).To(Equal(float64(2)))
+			exercises := group["exercises"].([]interface{})
+			Expect(len(exercises)).To(Equal(2))
+			Expect(exercises[1].(map[string]interface{})["rest_time"]).To(Equal(30e9))
+
+			// The exercises are forked with their sets instead of being shared with the source plan
+			forked := exercises[1].(map[string]interface{})
+			Expect(forked["exercise_id"]).NotTo(Equal(exerciseIds[1]))
+			exercise := forked["exercise"].(map[string]interface{})
+			Expect(exercise["visibility"]).To(Equal("PRIVATE"))
+			var forkedFrom string
+			db.Get(&forkedFrom, "SELECT forked_from FROM exercises WHERE id=$1", forked["exercise_id"])
+			Expect(forkedFrom).To(Equal(exerciseIds[1]))
+			Expect(len(exercise["sets"].([]interface{}))).To(Equal(2))
+
+			w = httptest.NewRecorder()
+			req, _ = http.NewRequest("GET", fmt.Sprintf("/plans/%s", sourcePlanId), nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(200))
+			Expect(decodeBody(w.Body)["forks"]).To(Equal(float64(1)))
+		})
+
+		It("should fail to fork without authentication", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", fmt.Sprintf("/plans/%s/fork", sourcePlanId), nil)
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(401))
+		})
+	})
+
+	Describe("Plan Revisions", func() {
+		var revisionedPlanId string
+
+		It("should record a revision for every plan change", func() {
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{"name": "Revisioned Plan", "public": false})
+			req, _ := http.NewRequest("POST", "/plans", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(201))
+			revisionedPlanId = decodeBody(w.Body)["id"].(string)
+
+			w = httptest.NewRecorder()
+			reqBody, _ = json.Marshal(gin.H{"exercise_id": exerciseIds[0], "rest_time": 60e9})
+			req, _ = http.NewRequest("POST", fmt.Sprintf("/plans/%s/exercises", revisionedPlanId), bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(201))
+
+			w = httptest.NewRecorder()
+			reqBody, _ = json.Marshal(gin.H{"name": "Revisioned Plan v2", "public": false})
+			req, _ = http.NewRequest("PUT", fmt.Sprintf("/plans/%s", revisionedPlanId), bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(200))
+
+			w = httptest.NewRecorder()
+			req, _ = http.NewRequest("GET", fmt.Sprintf("/plans/%s/revisions", revisionedPlanId), nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			Expect(w.Code).To(Equal(200))
+			Expect(w.Header().Get("X-Total-Count")).To(Equal("3"))
+			var body []map[string]interface{}
+			json.NewDecoder(w.Body).Decode(&body)
+			snapshot := body[1]["snapshot"].(map[string]interface{})
+			Expect(snapshot["name"]).To(Equal("Revisioned Plan"))
+			Expect(len(snapshot["groups"].([]interface{}))).To(Equal(1))
+		})
+
+		It("should diff plan revisions", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", fmt.Sprintf("/plans/%s/revisions/diff?from=1&to=3", revisionedPlanId), nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			body := decodeBody(w.Body)
+			Expect(w.Code).To(Equal(200))
+			paths := []string{}
+			for _, c := range body["changes"].([]interface{}) {
+				paths = append(paths, c.(map[string]interface{})["path"].(string))
+			}
+			Expect(paths).To(ContainElements("name", "groups[0]"))
+		})
+
+		It("should restore a plan revision", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", fmt.Sprintf("/plans/%s/revisions/1/restore", revisionedPlanId), nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			body := decodeBody(w.Body)
+			Expect(w.Code).To(Equal(200))
+			Expect(body["name"]).To(Equal("Revisioned Plan"))
+			Expect(body["groups"] == nil || len(body["groups"].([]interface{})) == 0).To(BeTrue())
+		})
+
+		It("should fail to diff without revisions", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", fmt.Sprintf("/plans/%s/revisions/diff?from=1", revisionedPlanId), nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(400))
+		})
+	})
+
 	Describe("Plan Deletion", func() {
 		It("should delete plan", func() {
 			// Create a plan to delete
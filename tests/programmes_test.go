@@ -0,0 +1,190 @@
+package tests_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func programmesGroup() {
+	var planID string
+	var programmeID string
+
+	BeforeEach(func() {
+		if planID == "" {
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{
+				"name":        "Deadlift",
+				"description": "Conventional deadlift",
+				"public":      false,
+				"sets": []gin.H{
+					{"name": "Working", "rest_time": 180e9, "rep_count": 5, "load": 100},
+				},
+			})
+			req, _ := http.NewRequest("POST", "/exercises", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			exerciseID := decodeBody(w.Body)["id"].(string)
+
+			w = httptest.NewRecorder()
+			reqBody, _ = json.Marshal(gin.H{"name": "Pull Day", "public": false})
+			req, _ = http.NewRequest("POST", "/plans", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			planID = decodeBody(w.Body)["id"].(string)
+
+			w = httptest.NewRecorder()
+			reqBody, _ = json.Marshal(gin.H{"exercise_id": exerciseID, "rest_time": 120e9})
+			req, _ = http.NewRequest("POST", fmt.Sprintf("/plans/%s/exercises", planID), bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+		}
+	})
+
+	firstSet := func(session map[string]interface{}) map[string]interface{} {
+		plan := session["plan"].(map[string]interface{})
+		group := plan["groups"].([]interface{})[0].(map[string]interface{})
+		exercise := group["exercises"].([]interface{})[0].(map[string]interface{})["exercise"].(map[string]interface{})
+		return exercise["sets"].([]interface{})[0].(map[string]interface{})
+	}
+
+	Describe("Programmes", func() {
+		It("should create a programme with progressions", func() {
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{
+				"name":  "Deadlift Block",
+				"weeks": 3,
+				"days": []gin.H{
+					{"week": 1, "day": 1, "plan_id": planID},
+					{"week": 1, "day": 4, "plan_id": planID},
+					{"week": 2, "day": 1, "plan_id": planID},
+					{"week": 3, "day": 1, "plan_id": planID, "note": "test day"},
+				},
+				"progressions": []gin.H{
+					{"target": "LOAD", "amount": 2.5, "per": "WEEK"},
+					{"target": "REP_COUNT", "amount": 1, "per": "SESSION", "max": 7},
+				},
+			})
+			req, _ := http.NewRequest("POST", "/programmes", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			body := decodeBody(w.Body)
+			Expect(w.Code).To(Equal(201))
+			Expect(len(body["days"].([]interface{}))).To(Equal(4))
+			Expect(len(body["progressions"].([]interface{}))).To(Equal(2))
+			Expect(body["public"]).To(Equal(false))
+			Expect(body["visibility"]).To(Equal("PRIVATE"))
+			programmeID = body["id"].(string)
+		})
+
+		It("should fail to share a programme with an organization without one", func() {
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{
+				"name":       "Shared Block",
+				"weeks":      1,
+				"visibility": "ORG",
+				"days":       []gin.H{{"week": 1, "day": 1, "plan_id": planID}},
+			})
+			req, _ := http.NewRequest("POST", "/programmes", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(400))
+		})
+
+		It("should fail to create programme with days outside its weeks", func() {
+			for _, day := range []gin.H{
+				{"week": 4, "day": 1, "plan_id": planID},
+				{"week": 1, "day": 8, "plan_id": planID},
+			} {
+				w := httptest.NewRecorder()
+				reqBody, _ := json.Marshal(gin.H{"name": "Invalid", "weeks": 3, "days": []gin.H{day}})
+				req, _ := http.NewRequest("POST", "/programmes", bytes.NewBuffer(reqBody))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+				router.ServeHTTP(w, req)
+				Expect(w.Code).To(Equal(400))
+			}
+		})
+
+		It("should preview the progressed calendar", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", fmt.Sprintf("/programmes/%s/calendar?start=2026-11-02", programmeID), nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			Expect(w.Code).To(Equal(200))
+			var body []map[string]interface{}
+			json.NewDecoder(w.Body).Decode(&body)
+			Expect(len(body)).To(Equal(4))
+			Expect(body[0]["scheduled_at"]).To(HavePrefix("2026-11-02"))
+			Expect(body[1]["scheduled_at"]).To(HavePrefix("2026-11-05"))
+			Expect(body[3]["scheduled_at"]).To(HavePrefix("2026-11-16"))
+
+			loads := []float64{100, 100, 102.5, 105}
+			reps := []float64{5, 6, 7, 7}
+			for i, session := range body {
+				set := firstSet(session)
+				Expect(set["load"]).To(Equal(loads[i]))
+				Expect(set["rep_count"]).To(Equal(reps[i]))
+			}
+		})
+
+		It("should assign programme and store generated sessions", func() {
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{"start_date": "2026-11-02"})
+			req, _ := http.NewRequest("POST", fmt.Sprintf("/programmes/%s/assign", programmeID), bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			body := decodeBody(w.Body)
+			Expect(w.Code).To(Equal(201))
+			sessions := body["sessions"].([]interface{})
+			Expect(len(sessions)).To(Equal(4))
+			Expect(firstSet(sessions[3].(map[string]interface{}))["load"]).To(Equal(105.0))
+
+			w = httptest.NewRecorder()
+			req, _ = http.NewRequest("GET", fmt.Sprintf("/programmes/%s/assignments/%s", programmeID, body["id"]), nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(200))
+			Expect(len(decodeBody(w.Body)["sessions"].([]interface{}))).To(Equal(4))
+		})
+
+		It("should fail to assign programme to a user who is not an athlete of the coach", func() {
+			db.Exec("INSERT INTO user_roles (user_id, role) SELECT id, 'coach' FROM users WHERE email=$1 ON CONFLICT DO NOTHING", usersData[0]["email"])
+			var userID string
+			db.Get(&userID, "SELECT id FROM users WHERE email IS DISTINCT FROM $1 LIMIT 1", usersData[0]["email"])
+			if userID == "" {
+				Skip("no other user is registered")
+			}
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{"start_date": "2026-11-02", "user_id": userID})
+			req, _ := http.NewRequest("POST", fmt.Sprintf("/programmes/%s/assign", programmeID), bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(403))
+		})
+
+		It("should fail to preview with invalid start date", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", fmt.Sprintf("/programmes/%s/calendar?start=next-monday", programmeID), nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(400))
+		})
+	})
+}
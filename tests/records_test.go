@@ -0,0 +1,178 @@
+package tests_test
+
+import (
+	"bytes"
+	"coachwise/src/app/events"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type recordingPublisher struct {
+	events []events.Event
+}
+
+func (p *recordingPublisher) Publish(e events.Event) error {
+	p.events = append(p.events, e)
+	return nil
+}
+
+func recordsGroup() {
+	var exerciseID string
+	var sessionID string
+	publisher := new(recordingPublisher)
+	var defaultPublisher events.Publisher
+
+	request := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		var reqBody []byte
+		if body != nil {
+			reqBody, _ = json.Marshal(body)
+		}
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+		router.ServeHTTP(w, req)
+		return w
+	}
+	list := func(w *httptest.ResponseRecorder) []gin.H {
+		var body []gin.H
+		json.NewDecoder(w.Body).Decode(&body)
+		return body
+	}
+	logSet := func(set gin.H) gin.H {
+		set["exercise_id"] = exerciseID
+		w := request("POST", fmt.Sprintf("/workouts/%s/sets", sessionID), set)
+		Expect(w.Code).To(Equal(201))
+		return decodeBody(w.Body)
+	}
+	kinds := func(records interface{}) []interface{} {
+		result := []interface{}{}
+		for _, r := range records.([]interface{}) {
+			result = append(result, r.(map[string]interface{})["kind"])
+		}
+		return result
+	}
+
+	BeforeEach(func() {
+		defaultPublisher = events.Default
+		events.Default = publisher
+	})
+
+	AfterEach(func() {
+		events.Default = defaultPublisher
+	})
+
+	It("should set the first records of an exercise", func() {
+		w := request("POST", "/exercises", gin.H{"name": "Records Deadlift"})
+		Expect(w.Code).To(Equal(201))
+		exerciseID = decodeBody(w.Body)["id"].(string)
+		w = request("POST", "/workouts", gin.H{})
+		Expect(w.Code).To(Equal(201))
+		sessionID = decodeBody(w.Body)["id"].(string)
+
+		body := logSet(gin.H{"rep_count": 5, "load": 100})
+		Expect(kinds(body["records"])).To(Equal([]interface{}{"MAX_LOAD", "MAX_REPS", "E1RM"}))
+		e1rm := body["records"].([]interface{})[2].(map[string]interface{})
+		Expect(e1rm["value"]).To(Equal(112.5))
+		Expect(e1rm["unit"]).To(Equal("KG"))
+		Expect(e1rm["previous_value"]).To(BeNil())
+		Expect(e1rm["exercise_name"]).To(Equal("Records Deadlift"))
+
+		Expect(publisher.events).To(HaveLen(3))
+		Expect(publisher.events[0].Name).To(Equal(events.PersonalRecord))
+	})
+
+	It("should not set records for weaker sets", func() {
+		body := logSet(gin.H{"rep_count": 3, "load": 100})
+		Expect(body).NotTo(HaveKey("records"))
+		Expect(publisher.events).To(HaveLen(3))
+	})
+
+	It("should set reps records at a lower load", func() {
+		body := logSet(gin.H{"rep_count": 8, "load": 90})
+		records := body["records"].([]interface{})
+		Expect(kinds(records)).To(Equal([]interface{}{"MAX_REPS"}))
+		Expect(records[0].(map[string]interface{})["load"]).To(Equal(float64(90)))
+		Expect(records[0].(map[string]interface{})["previous_value"]).To(Equal(float64(5)))
+	})
+
+	It("should convert pounds and track durations", func() {
+		body := logSet(gin.H{"rep_count": 1, "load": 250, "unit": "LB"})
+		records := body["records"].([]interface{})
+		Expect(kinds(records)).To(Equal([]interface{}{"MAX_LOAD", "MAX_REPS", "E1RM"}))
+		Expect(records[0].(map[string]interface{})["value"]).To(Equal(113.4))
+		Expect(records[0].(map[string]interface{})["previous_value"]).To(Equal(float64(100)))
+
+		body = logSet(gin.H{"duration": 60e9})
+		Expect(kinds(body["records"])).To(Equal([]interface{}{"MAX_DURATION"}))
+		Expect(body["records"].([]interface{})[0].(map[string]interface{})["value"]).To(Equal(float64(60)))
+		Expect(publisher.events).To(HaveLen(8))
+	})
+
+	It("should list the current records", func() {
+		w := request("GET", "/users/me/records?exercise_id="+exerciseID, nil)
+		Expect(w.Code).To(Equal(200))
+		Expect(w.Header().Get("X-Total-Count")).To(Equal("6"))
+		records := list(w)
+		loads := []interface{}{}
+		for _, r := range records {
+			if r["kind"] == "MAX_REPS" {
+				loads = append(loads, r["load"])
+			}
+		}
+		Expect(loads).To(Equal([]interface{}{113.4, float64(100), float64(90)}))
+
+		w = request("GET", "/users/me/records?kind=e1rm&exercise_id="+exerciseID, nil)
+		Expect(w.Code).To(Equal(200))
+		records = list(w)
+		Expect(records).To(HaveLen(1))
+		Expect(records[0]["value"]).To(Equal(113.4))
+
+		Expect(request("GET", "/users/me/records?kind=fastest", nil).Code).To(Equal(400))
+	})
+
+	It("should list the record history of the exercise", func() {
+		w := request("GET", fmt.Sprintf("/exercises/%s/records", exerciseID), nil)
+		Expect(w.Code).To(Equal(200))
+		Expect(w.Header().Get("X-Total-Count")).To(Equal("8"))
+
+		w = request("GET", fmt.Sprintf("/exercises/%s/records?kind=E1RM", exerciseID), nil)
+		Expect(w.Code).To(Equal(200))
+		values := []interface{}{}
+		for _, r := range list(w) {
+			values = append(values, r["value"])
+		}
+		Expect(values).To(Equal([]interface{}{113.4, 112.5}))
+		Expect(request("GET", "/exercises/not-a-uuid/records", nil).Code).To(Equal(400))
+	})
+
+	It("should not set records for warmups or loads in other units", func() {
+		w := request("POST", "/exercises", gin.H{
+			"name": "Records Squat",
+			"sets": []gin.H{
+				{"set_type": "WARMUP", "rest_time": 60e9, "rep_count": 10, "load": 40},
+				{"set_type": "STANDARD", "rest_time": 120e9, "rep_count": 5, "load": 100},
+			},
+		})
+		Expect(w.Code).To(Equal(201))
+		exerciseID = decodeBody(w.Body)["id"].(string)
+		published := len(publisher.events)
+
+		body := logSet(gin.H{"set_number": 1, "rep_count": 10, "load": 40})
+		Expect(body["prescribed_set_type"]).To(Equal("WARMUP"))
+		Expect(body).NotTo(HaveKey("records"))
+
+		body = logSet(gin.H{"set_number": 2, "rep_count": 5, "load": 2, "unit": "COUNT"})
+		Expect(body).NotTo(HaveKey("records"))
+		Expect(publisher.events).To(HaveLen(published))
+
+		body = logSet(gin.H{"set_number": 2, "rep_count": 5, "load": 100})
+		Expect(kinds(body["records"])).To(Equal([]interface{}{"MAX_LOAD", "MAX_REPS", "E1RM"}))
+	})
+}
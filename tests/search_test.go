@@ -0,0 +1,153 @@
+package tests_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func searchGroup() {
+	var exerciseID string
+	var planID string
+
+	BeforeEach(func() {
+		if exerciseID == "" {
+			for _, exercise := range []gin.H{
+				{
+					"name":        "Bulgarian Split Squat",
+					"description": "Single leg squatting variation for quads and glutes",
+					"public":      true,
+				},
+				{
+					"name":        "Élévation latérale",
+					"description": "Isolation des épaules avec haltères",
+					"public":      true,
+					"language":    "french",
+				},
+			} {
+				exercise["sets"] = []gin.H{{"name": "Working", "rest_time": 60e9, "rep_count": 10}}
+				w := httptest.NewRecorder()
+				reqBody, _ := json.Marshal(exercise)
+				req, _ := http.NewRequest("POST", "/exercises", bytes.NewBuffer(reqBody))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+				router.ServeHTTP(w, req)
+				if exerciseID == "" {
+					exerciseID = decodeBody(w.Body)["id"].(string)
+				}
+			}
+
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{
+				"name":        "Leg Day",
+				"description": "Heavy squats followed by accessory work",
+				"public":      false,
+			})
+			req, _ := http.NewRequest("POST", "/plans", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			planID = decodeBody(w.Body)["id"].(string)
+		}
+	})
+
+	Describe("Full Text Search", func() {
+		It("should rank name matches above description matches", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/search?q=squats", nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			Expect(w.Code).To(Equal(200))
+			var body []gin.H
+			json.NewDecoder(w.Body).Decode(&body)
+			Expect(len(body)).To(BeNumerically(">=", 2))
+			exerciseRank, planRank := -1, -1
+			for i, r := range body {
+				switch r["id"] {
+				case exerciseID:
+					exerciseRank = i
+					Expect(r["type"]).To(Equal("exercise"))
+					Expect(r["headline"]).To(ContainSubstring("<b>Squat</b>"))
+				case planID:
+					planRank = i
+					Expect(r["type"]).To(Equal("plan"))
+				}
+			}
+			Expect(exerciseRank).To(BeNumerically(">=", 0))
+			Expect(planRank).To(BeNumerically(">", exerciseRank))
+		})
+
+		It("should fold accents and stem with the row language", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/search?q=elevation+epaule", nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			Expect(w.Code).To(Equal(200))
+			var body []gin.H
+			json.NewDecoder(w.Body).Decode(&body)
+			Expect(len(body)).To(Equal(1))
+			Expect(body[0]["name"]).To(Equal("Élévation latérale"))
+			Expect(body[0]["snippet"]).To(ContainSubstring("<b>"))
+		})
+
+		It("should filter search results by type", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/search?q=squat&filter.type=plan", nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			Expect(w.Code).To(Equal(200))
+			var body []gin.H
+			json.NewDecoder(w.Body).Decode(&body)
+			Expect(len(body)).To(BeNumerically(">=", 1))
+			for _, r := range body {
+				Expect(r["type"]).To(Equal("plan"))
+			}
+		})
+
+		It("should search the exercise library with q", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/exercises?q=split+squat", nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			Expect(w.Code).To(Equal(200))
+			var body []gin.H
+			json.NewDecoder(w.Body).Decode(&body)
+			Expect(len(body)).To(Equal(1))
+			Expect(body[0]["id"]).To(Equal(exerciseID))
+		})
+
+		It("should fail to search without query", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/search", nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(400))
+		})
+
+		It("should fail to create exercises and plans in an unsupported language", func() {
+			for path, content := range map[string]gin.H{
+				"/exercises": {"name": "Sentadilla", "language": "klingon", "sets": []gin.H{{"name": "Working", "rest_time": 60e9, "rep_count": 10}}},
+				"/plans":     {"name": "Dia de pierna", "language": "klingon"},
+			} {
+				w := httptest.NewRecorder()
+				reqBody, _ := json.Marshal(content)
+				req, _ := http.NewRequest("POST", path, bytes.NewBuffer(reqBody))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+				router.ServeHTTP(w, req)
+				Expect(w.Code).To(Equal(400))
+				Expect(decodeBody(w.Body)["error"]).To(Equal(`unsupported language "klingon"`))
+			}
+		})
+	})
+}
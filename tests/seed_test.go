@@ -0,0 +1,106 @@
+package tests_test
+
+import (
+	"coachwise/src/app/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func seedGroup() {
+	var catalogue *models.Catalogue
+
+	BeforeEach(func() {
+		if catalogue == nil {
+			var err error
+			catalogue, err = models.LoadCatalogue("src/seeds/exercises.json")
+			Expect(err).To(BeNil())
+		}
+	})
+
+	Describe("Exercise Catalogue", func() {
+		It("should seed the catalogue as public system exercises", func() {
+			written, err := catalogue.Seed(context.Background())
+			Expect(err).To(BeNil())
+			Expect(written).To(Equal(len(catalogue.Exercises)))
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/exercises?q=max+hang&filter.equipment=hangboard", nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			Expect(w.Code).To(Equal(200))
+			var body []gin.H
+			json.NewDecoder(w.Body).Decode(&body)
+			Expect(len(body)).To(Equal(1))
+			Expect(body[0]["seed_key"]).To(Equal("max-hang"))
+			Expect(body[0]["user_id"]).To(BeNil())
+			Expect(body[0]["visibility"]).To(Equal("PUBLIC"))
+			Expect(len(body[0]["sets"].([]interface{}))).To(Equal(6))
+		})
+
+		It("should skip exercises already seeded at the same version", func() {
+			written, err := catalogue.Seed(context.Background())
+			Expect(err).To(BeNil())
+			Expect(written).To(Equal(0))
+		})
+
+		It("should rewrite exercises when the catalogue version grows", func() {
+			next, err := models.LoadCatalogue("src/seeds/exercises.json")
+			Expect(err).To(BeNil())
+			next.Version = catalogue.Version + 1
+			for i := range next.Exercises {
+				if next.Exercises[i].Key == "max-hang" {
+					next.Exercises[i].Description = "Hang from a 20 mm edge"
+					next.Exercises[i].Sets = next.Exercises[i].Sets[:4]
+				}
+			}
+			written, err := next.Seed(context.Background())
+			Expect(err).To(BeNil())
+			Expect(written).To(Equal(len(next.Exercises)))
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/exercises?q=max+hang&filter.equipment=hangboard", nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			var body []gin.H
+			json.NewDecoder(w.Body).Decode(&body)
+			Expect(len(body)).To(Equal(1))
+			Expect(body[0]["description"]).To(Equal("Hang from a 20 mm edge"))
+			Expect(len(body[0]["sets"].([]interface{}))).To(Equal(4))
+
+			w = httptest.NewRecorder()
+			req, _ = http.NewRequest("GET", fmt.Sprintf("/exercises/%s/revisions", body[0]["id"]), nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Header().Get("X-Total-Count")).To(Equal("2"))
+		})
+
+		It("should let users fork seeded exercises", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/exercises?q=dead+bug&filter.sport=therapeutic", nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			var body []gin.H
+			json.NewDecoder(w.Body).Decode(&body)
+			Expect(len(body)).To(Equal(1))
+
+			w = httptest.NewRecorder()
+			req, _ = http.NewRequest("POST", fmt.Sprintf("/exercises/%s/fork", body[0]["id"]), nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			fork := decodeBody(w.Body)
+			Expect(w.Code).To(Equal(201))
+			Expect(fork["seed_key"]).To(BeNil())
+			Expect(fork["forked_from"]).To(Equal(body[0]["id"]))
+		})
+	})
+}
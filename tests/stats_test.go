@@ -0,0 +1,171 @@
+package tests_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func statsGroup() {
+	var athleteToken string
+	var exerciseID string
+	var planID string
+	var missedID string
+	var completedID string
+	athleteEmail := "athlete@test.com"
+
+	request := func(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		var reqBody []byte
+		if body != nil {
+			reqBody, _ = json.Marshal(body)
+		}
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		router.ServeHTTP(w, req)
+		return w
+	}
+	logSet := func(sessionID string, reps int, load float64, unit string) {
+		w := request("POST", fmt.Sprintf("/workouts/%s/sets", sessionID), athleteToken, gin.H{
+			"exercise_id": exerciseID,
+			"rep_count":   reps,
+			"load":        load,
+			"unit":        unit,
+		})
+		Expect(w.Code).To(Equal(201))
+	}
+
+	It("should prepare a plan assigned to the athlete", func() {
+		w := httptest.NewRecorder()
+		reqBody, _ := json.Marshal(gin.H{"email": athleteEmail, "password": "athlete123456"})
+		req, _ := http.NewRequest("POST", "/auth/login", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(200))
+		athleteToken = decodeBody(w.Body)["access_token"].(string)
+
+		w = request("POST", "/exercises", authTokens[0], gin.H{
+			"name": "Stats Back Squat",
+			"sets": []gin.H{
+				{"name": "Set 1", "rest_time": 90e9, "rep_count": 5},
+				{"name": "Set 2", "rest_time": 90e9, "rep_count": 5},
+			},
+		})
+		Expect(w.Code).To(Equal(201))
+		exerciseID = decodeBody(w.Body)["id"].(string)
+
+		w = request("POST", "/plans", authTokens[0], gin.H{"name": "Stats Block"})
+		Expect(w.Code).To(Equal(201))
+		planID = decodeBody(w.Body)["id"].(string)
+		w = request("POST", fmt.Sprintf("/plans/%s/groups", planID), authTokens[0], gin.H{
+			"exercises": []gin.H{{"exercise_id": exerciseID}},
+		})
+		Expect(w.Code).To(Equal(201))
+
+		Expect(db.Get(&missedID, "INSERT INTO plan_assignees (plan_id, user_id, due_at) SELECT $1, id, NOW() - INTERVAL '1 day' FROM users WHERE email=$2 RETURNING id", planID, athleteEmail)).To(Succeed())
+		Expect(db.Get(&completedID, "INSERT INTO plan_assignees (plan_id, user_id) SELECT $1, id FROM users WHERE email=$2 RETURNING id", planID, athleteEmail)).To(Succeed())
+		_, err := db.Exec("INSERT INTO plan_assignees (plan_id, user_id) SELECT $1, id FROM users WHERE email=$2", planID, usersData[0]["email"])
+		Expect(err).To(BeNil())
+	})
+
+	It("should log workouts against the assignments", func() {
+		w := request("POST", "/workouts", athleteToken, gin.H{"plan_assignee_id": missedID})
+		Expect(w.Code).To(Equal(201))
+		logSet(decodeBody(w.Body)["id"].(string), 5, 100, "KG")
+
+		w = request("POST", "/workouts", athleteToken, gin.H{"plan_assignee_id": completedID})
+		Expect(w.Code).To(Equal(201))
+		sessionID := decodeBody(w.Body)["id"].(string)
+		logSet(sessionID, 5, 100, "KG")
+		logSet(sessionID, 2, 100, "LB")
+		Expect(request("POST", fmt.Sprintf("/workouts/%s/finish", sessionID), athleteToken, nil).Code).To(Equal(200))
+	})
+
+	It("should report the progress of every assignee to the plan owner", func() {
+		w := request("GET", fmt.Sprintf("/plans/%s/progress", planID), authTokens[0], nil)
+		Expect(w.Code).To(Equal(200))
+		Expect(w.Header().Get("X-Total-Count")).To(Equal("3"))
+		body := decodeBody(w.Body)
+		Expect(body["summary"]).To(Equal(map[string]interface{}{
+			"assignments": float64(3),
+			"completed":   float64(1),
+			"missed":      float64(1),
+			"completion":  float64(50),
+		}))
+
+		assignments := body["assignments"].([]interface{})
+		Expect(assignments).To(HaveLen(3))
+		missed := assignments[0].(map[string]interface{})
+		Expect(missed["id"]).To(Equal(missedID))
+		Expect(missed["prescribed_sets"]).To(Equal(float64(2)))
+		Expect(missed["logged_sets"]).To(Equal(float64(1)))
+		Expect(missed["completion"]).To(Equal(float64(50)))
+		Expect(missed["missed"]).To(Equal(true))
+		Expect(missed["completed"]).To(Equal(false))
+		Expect(missed["user"].(map[string]interface{})["username"]).To(Equal("athlete"))
+		Expect(missed["user"].(map[string]interface{})["email"]).To(BeNil())
+
+		completed := assignments[1].(map[string]interface{})
+		Expect(completed["id"]).To(Equal(completedID))
+		Expect(completed["completion"]).To(Equal(float64(100)))
+		Expect(completed["completed"]).To(Equal(true))
+		Expect(completed["finished_sessions"]).To(Equal(float64(1)))
+		Expect(completed["missed"]).To(Equal(false))
+
+		exercises := body["exercises"].([]interface{})
+		Expect(exercises).To(HaveLen(1))
+		volume := exercises[0].(map[string]interface{})
+		Expect(volume["exercise_id"]).To(Equal(exerciseID))
+		Expect(volume["sessions"]).To(Equal(float64(2)))
+		Expect(volume["sets"]).To(Equal(float64(3)))
+		Expect(volume["reps"]).To(Equal(float64(12)))
+		Expect(volume["volume"]).To(BeNumerically("~", 1090.72, 0.01))
+	})
+
+	It("should only show athletes their own assignments", func() {
+		Expect(request("GET", "/plans/not-a-uuid/progress", athleteToken, nil).Code).To(Equal(400))
+
+		w := request("GET", fmt.Sprintf("/plans/%s/progress", planID), athleteToken, nil)
+		Expect(w.Code).To(Equal(200))
+		Expect(w.Header().Get("X-Total-Count")).To(Equal("2"))
+		for _, a := range decodeBody(w.Body)["assignments"].([]interface{}) {
+			Expect(a.(map[string]interface{})["user"].(map[string]interface{})["username"]).To(Equal("athlete"))
+		}
+		// Paging past the assignments is an empty page, not a denial
+		w = request("GET", fmt.Sprintf("/plans/%s/progress?page=5&limit=1", planID), athleteToken, nil)
+		Expect(w.Code).To(Equal(200))
+		Expect(decodeBody(w.Body)["assignments"]).To(BeEmpty())
+
+		w = request("POST", "/plans", authTokens[0], gin.H{"name": "Stats Unassigned"})
+		Expect(w.Code).To(Equal(201))
+		otherID := decodeBody(w.Body)["id"].(string)
+		Expect(request("GET", fmt.Sprintf("/plans/%s/progress", otherID), athleteToken, nil).Code).To(Equal(403))
+	})
+
+	It("should report the stats and streaks of the user", func() {
+		_, err := db.Exec(`INSERT INTO workout_sessions (user_id, started_at)
+			SELECT u.id, NOW() - d * INTERVAL '1 day' FROM users u, unnest(ARRAY[1, 2, 5]) d WHERE u.email=$1`, athleteEmail)
+		Expect(err).To(BeNil())
+
+		w := request("GET", "/users/me/stats", athleteToken, nil)
+		Expect(w.Code).To(Equal(200))
+		body := decodeBody(w.Body)
+		Expect(body["sets"]).To(Equal(float64(3)))
+		Expect(body["reps"]).To(Equal(float64(12)))
+		Expect(body["volume"]).To(BeNumerically("~", 1090.72, 0.01))
+		Expect(body["finished_sessions"]).To(Equal(float64(1)))
+		Expect(body["current_streak"]).To(Equal(float64(3)))
+		Expect(body["longest_streak"]).To(Equal(float64(3)))
+		Expect(body["summary"].(map[string]interface{})["assignments"]).To(Equal(float64(2)))
+		Expect(body["summary"].(map[string]interface{})["missed"]).To(Equal(float64(1)))
+		Expect(body["assignments"]).To(HaveLen(2))
+		Expect(body["exercises"]).To(HaveLen(1))
+	})
+}
@@ -1,6 +1,7 @@
 package tests_test
 
 import (
+	"archive/zip"
 	"bytes"
 	"encoding/json"
 	"fmt"
@@ -207,7 +208,9 @@ func usersGroup() {
 
 			// Now delete the account
 			w3 := httptest.NewRecorder()
-			req3, _ := http.NewRequest("DELETE", "/users/me", nil)
+			reqBody3, _ := json.Marshal(gin.H{"password": "password123"})
+			req3, _ := http.NewRequest("DELETE", "/users/me", bytes.NewBuffer(reqBody3))
+			req3.Header.Set("Content-Type", "application/json")
 			req3.Header.Set("Authorization", fmt.Sprintf("Bearer %s", deleteToken))
 			router.ServeHTTP(w3, req3)
 			
@@ -225,9 +228,33 @@ func usersGroup() {
 				req4.Header.Set("Content-Type", "application/json")
 				router.ServeHTTP(w4, req4)
 				Expect(w4.Code).To(Equal(400))
+
+				// Verify the deleted account token is rejected
+				w5 := httptest.NewRecorder()
+				req5, _ := http.NewRequest("GET", "/users/me/export", nil)
+				req5.Header.Set("Authorization", fmt.Sprintf("Bearer %s", deleteToken))
+				router.ServeHTTP(w5, req5)
+				Expect(w5.Code).To(Equal(401))
+
+				// Restore within the grace period
+				w6 := httptest.NewRecorder()
+				req6, _ := http.NewRequest("POST", "/auth/restore", bytes.NewBuffer(reqBody4))
+				req6.Header.Set("Content-Type", "application/json")
+				router.ServeHTTP(w6, req6)
+				Expect(w6.Code).To(Equal(200))
 			}
 		})
 
+		It("should fail to delete account with wrong password", func() {
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{"password": "wrongpassword"})
+			req, _ := http.NewRequest("DELETE", "/users/me", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(400))
+		})
+
 		It("should fail to delete without authentication", func() {
 			w := httptest.NewRecorder()
 			req, _ := http.NewRequest("DELETE", "/users/me", nil)
@@ -239,6 +266,122 @@ func usersGroup() {
 		})
 	})
 
+	Describe("Contact Change", func() {
+		It("should require re-authentication to change email", func() {
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{"email": "changed@test.com"})
+			req, _ := http.NewRequest("POST", "/users/me/email", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(400))
+
+			w = httptest.NewRecorder()
+			reqBody, _ = json.Marshal(gin.H{"email": "changed@test.com", "password": "wrongpassword"})
+			req, _ = http.NewRequest("POST", "/users/me/email", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(400))
+		})
+
+		It("should fail to change email to an existing one", func() {
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{"email": "another@test.com", "password": usersData[0]["password"]})
+			req, _ := http.NewRequest("POST", "/users/me/email", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(400))
+		})
+
+		It("should stage email change and reject wrong code", func() {
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{"email": "changed@test.com", "password": usersData[0]["password"]})
+			req, _ := http.NewRequest("POST", "/users/me/email", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(200))
+
+			w2 := httptest.NewRecorder()
+			reqBody2, _ := json.Marshal(gin.H{"code": 1})
+			req2, _ := http.NewRequest("POST", "/users/me/email/verify", bytes.NewBuffer(reqBody2))
+			req2.Header.Set("Content-Type", "application/json")
+			req2.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w2, req2)
+			Expect(w2.Code).To(Equal(400))
+
+			// Email is not swapped before confirmation
+			w3 := httptest.NewRecorder()
+			req3, _ := http.NewRequest("GET", "/users/me", nil)
+			req3.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w3, req3)
+			Expect(w3.Code).To(Equal(200))
+			Expect(decodeBody(w3.Body)["email"]).To(Equal(usersData[0]["email"]))
+		})
+
+		It("should change phone after confirmation", func() {
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{"phone": "+4915112345678", "password": usersData[0]["password"]})
+			req, _ := http.NewRequest("POST", "/users/me/phone", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(200))
+
+			otp := struct{ Code int }{}
+			db.Get(&otp, "SELECT code FROM otps WHERE perpose = 'PHONE_CHANGE' AND target = '+4915112345678' ORDER BY created_at DESC LIMIT 1")
+
+			// The change code is not a login code and stays usable
+			wLogin := httptest.NewRecorder()
+			reqLoginBody, _ := json.Marshal(gin.H{"email": usersData[0]["email"], "code": otp.Code})
+			reqLogin, _ := http.NewRequest("POST", "/auth/otp/verify", bytes.NewBuffer(reqLoginBody))
+			reqLogin.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(wLogin, reqLogin)
+			Expect(wLogin.Code).To(Equal(400))
+
+			w2 := httptest.NewRecorder()
+			reqBody2, _ := json.Marshal(gin.H{"code": otp.Code})
+			req2, _ := http.NewRequest("POST", "/users/me/phone/verify", bytes.NewBuffer(reqBody2))
+			req2.Header.Set("Content-Type", "application/json")
+			req2.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w2, req2)
+
+			body := decodeBody(w2.Body)
+			Expect(w2.Code).To(Equal(200))
+			Expect(body["phone"]).To(Equal("+4915112345678"))
+		})
+	})
+
+	Describe("Data Export", func() {
+		It("should export user data as zip", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/users/me/export", nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			Expect(w.Code).To(Equal(200))
+			Expect(w.Header().Get("Content-Type")).To(Equal("application/zip"))
+
+			archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+			Expect(err).To(BeNil())
+			names := []string{}
+			for _, f := range archive.File {
+				names = append(names, f.Name)
+			}
+			Expect(names).To(ContainElements("profile.json", "exercises.json", "plans.json", "param_logs.json", "media.json"))
+			Expect(names).To(ContainElements("workout_sessions.json", "personal_records.json", "goals.json", "comments.json"))
+		})
+
+		It("should fail to export without authentication", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/users/me/export", nil)
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(401))
+		})
+	})
+
 	Describe("Avatar Upload", func() {
 		It("should upload user avatar", func() {
 			// This test would require multipart form data
@@ -0,0 +1,228 @@
+package tests_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func workoutsGroup() {
+	var exerciseID string
+	var setID string
+	var sessionID string
+
+	BeforeEach(func() {
+		if exerciseID == "" {
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{
+				"name":        "Squat",
+				"description": "Back squat",
+				"public":      false,
+				"sets": []gin.H{
+					{"name": "Working", "rest_time": 120e9, "rep_count": 5},
+					{"name": "Working", "rest_time": 120e9, "rep_count": 5},
+				},
+			})
+			req, _ := http.NewRequest("POST", "/exercises", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			body := decodeBody(w.Body)
+			exerciseID = body["id"].(string)
+			for _, s := range body["sets"].([]interface{}) {
+				set := s.(map[string]interface{})
+				if set["set_number"] == float64(1) {
+					setID = set["id"].(string)
+				}
+			}
+		}
+	})
+
+	Describe("Workout Sessions", func() {
+		It("should start a workout session", func() {
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{"note": "leg day"})
+			req, _ := http.NewRequest("POST", "/workouts", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			body := decodeBody(w.Body)
+			Expect(w.Code).To(Equal(201))
+			Expect(body["finished_at"]).To(BeNil())
+			sessionID = body["id"].(string)
+		})
+
+		It("should log performed set against prescription", func() {
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{
+				"exercise_id": exerciseID,
+				"set_id":      setID,
+				"rep_count":   4,
+				"load":        100,
+				"rpe":         9,
+			})
+			req, _ := http.NewRequest("POST", fmt.Sprintf("/workouts/%s/sets", sessionID), bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			body := decodeBody(w.Body)
+			Expect(w.Code).To(Equal(201))
+			Expect(body["prescribed_rep_count"]).To(Equal(float64(5)))
+			Expect(body["unit"]).To(Equal("KG"))
+			Expect(body["achieved"]).To(Equal(false))
+		})
+
+		It("should log set by set number", func() {
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{
+				"exercise_id": exerciseID,
+				"set_number":  2,
+				"rep_count":   6,
+				"load":        95,
+			})
+			req, _ := http.NewRequest("POST", fmt.Sprintf("/workouts/%s/sets", sessionID), bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			body := decodeBody(w.Body)
+			Expect(w.Code).To(Equal(201))
+			Expect(body["achieved"]).To(Equal(true))
+		})
+
+		It("should compare logged reps against a rep range", func() {
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{
+				"name":        "Bench",
+				"description": "Hypertrophy bench",
+				"public":      false,
+				"sets": []gin.H{
+					{"name": "Working", "set_type": "RANGE", "rest_time": 90e9, "rep_min": 8, "rep_max": 12},
+				},
+			})
+			req, _ := http.NewRequest("POST", "/exercises", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(201))
+			rangeExerciseID := decodeBody(w.Body)["id"].(string)
+
+			w = httptest.NewRecorder()
+			reqBody, _ = json.Marshal(gin.H{"note": "push day"})
+			req, _ = http.NewRequest("POST", "/workouts", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(201))
+			rangeSessionID := decodeBody(w.Body)["id"].(string)
+
+			for reps, expected := range map[int]string{6: "BELOW", 10: "WITHIN", 14: "ABOVE"} {
+				w = httptest.NewRecorder()
+				reqBody, _ = json.Marshal(gin.H{
+					"exercise_id": rangeExerciseID,
+					"set_number":  1,
+					"rep_count":   reps,
+				})
+				req, _ = http.NewRequest("POST", fmt.Sprintf("/workouts/%s/sets", rangeSessionID), bytes.NewBuffer(reqBody))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+				router.ServeHTTP(w, req)
+
+				body := decodeBody(w.Body)
+				Expect(w.Code).To(Equal(201))
+				Expect(body["prescribed_set_type"]).To(Equal("RANGE"))
+				Expect(body["range"]).To(Equal(expected))
+				Expect(body["achieved"]).To(Equal(reps >= 8))
+			}
+		})
+
+		It("should fail to log empty set", func() {
+			w := httptest.NewRecorder()
+			reqBody, _ := json.Marshal(gin.H{"exercise_id": exerciseID})
+			req, _ := http.NewRequest("POST", fmt.Sprintf("/workouts/%s/sets", sessionID), bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(400))
+		})
+
+		It("should fail to log negative values or a set of another exercise", func() {
+			for _, form := range []gin.H{
+				{"exercise_id": exerciseID, "rep_count": -5},
+				{"exercise_id": exerciseID, "load": -20},
+				{"exercise_id": exerciseID, "set_id": "00000000-0000-0000-0000-000000000001", "rep_count": 5},
+			} {
+				w := httptest.NewRecorder()
+				reqBody, _ := json.Marshal(form)
+				req, _ := http.NewRequest("POST", fmt.Sprintf("/workouts/%s/sets", sessionID), bytes.NewBuffer(reqBody))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+				router.ServeHTTP(w, req)
+				Expect(w.Code).To(Equal(400))
+			}
+		})
+
+		It("should finish workout session", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", fmt.Sprintf("/workouts/%s/finish", sessionID), nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			body := decodeBody(w.Body)
+			Expect(w.Code).To(Equal(200))
+			Expect(body["finished_at"]).NotTo(BeNil())
+			Expect(len(body["set_logs"].([]interface{}))).To(Equal(2))
+
+			// Finished sessions do not accept logs
+			w2 := httptest.NewRecorder()
+			reqBody2, _ := json.Marshal(gin.H{"exercise_id": exerciseID, "rep_count": 5})
+			req2, _ := http.NewRequest("POST", fmt.Sprintf("/workouts/%s/sets", sessionID), bytes.NewBuffer(reqBody2))
+			req2.Header.Set("Content-Type", "application/json")
+			req2.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w2, req2)
+			Expect(w2.Code).To(Equal(400))
+		})
+
+		It("should list workout sessions", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/workouts", nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			Expect(w.Code).To(Equal(200))
+			var body []interface{}
+			json.NewDecoder(w.Body).Decode(&body)
+			Expect(len(body)).To(BeNumerically(">=", 1))
+		})
+
+		It("should fetch exercise performance history", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", fmt.Sprintf("/exercises/%s/history", exerciseID), nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authTokens[0]))
+			router.ServeHTTP(w, req)
+
+			Expect(w.Code).To(Equal(200))
+			var body []gin.H
+			json.NewDecoder(w.Body).Decode(&body)
+			Expect(len(body)).To(Equal(1))
+			Expect(body[0]["session_id"]).To(Equal(sessionID))
+			Expect(len(body[0]["sets"].([]interface{}))).To(Equal(2))
+		})
+
+		It("should fail to start workout without authentication", func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/workouts", nil)
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(401))
+		})
+	})
+}